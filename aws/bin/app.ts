@@ -65,6 +65,7 @@ const httpApiStack = new HttpApiStack(
     env: { account, region },
     description: `HTTP API Lambda and API Gateway for ${config.domainName}`,
     table: dynamoDbStack.table,
+    ordersTable: dynamoDbStack.ordersTable,
   },
 );
 httpApiStack.addDependency(dynamoDbStack);
@@ -91,6 +92,7 @@ const reattestBatchStack = new ReattestBatchStack(
     env: { account, region },
     description: `Batch re-attestation Lambda for ${config.domainName}`,
     table: dynamoDbStack.table,
+    archiveTable: dynamoDbStack.archiveTable,
     contentBucket: storageStack.contentBucket,
   },
 );
@@ -5,12 +5,14 @@ import { config } from "./config";
 
 export class DynamoDbStack extends cdk.Stack {
   public readonly table: dynamodb.ITable;
+  public readonly ordersTable: dynamodb.ITable;
+  public readonly archiveTable: dynamodb.ITable;
 
   constructor(scope: Construct, id: string, props?: cdk.StackProps) {
     super(scope, id, props);
 
     // Create DynamoDB table
-    this.table = new dynamodb.Table(this, "ApplicationTable", {
+    const table = new dynamodb.Table(this, "ApplicationTable", {
       tableName: `suns-prod-application-table`,
       partitionKey: {
         name: "pk",
@@ -29,6 +31,51 @@ export class DynamoDbStack extends cdk.Stack {
       stream: dynamodb.StreamViewType.NEW_AND_OLD_IMAGES,
     });
 
+    // Conflict detection looks up every group that claims a hostname,
+    // so index the table on its sort key rather than scanning it.
+    // The name must match HostnameIndex in symval/internal/repository/dynamorepo.
+    table.addGlobalSecondaryIndex({
+      indexName: "hostname-index",
+      partitionKey: {
+        name: "sk",
+        type: dynamodb.AttributeType.STRING,
+      },
+      projectionType: dynamodb.ProjectionType.ALL,
+    });
+    this.table = table;
+
+    // Attestation orders are short-lived, so they get their own table without a stream,
+    // and DynamoDB deletes them some time after they expire
+    this.ordersTable = new dynamodb.Table(this, "OrdersTable", {
+      tableName: `suns-prod-orders-table`,
+      partitionKey: {
+        name: "pk",
+        type: dynamodb.AttributeType.STRING,
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: cdk.RemovalPolicy.DESTROY,
+      encryption: dynamodb.TableEncryption.AWS_MANAGED,
+      timeToLiveAttribute: "ExpiresAt",
+    });
+
+    // Groups removed by reattestation are archived in their own table without a stream,
+    // so they stay out of the public list, and DynamoDB deletes them once the archive expires
+    this.archiveTable = new dynamodb.Table(this, "ArchiveTable", {
+      tableName: `suns-prod-archive-table`,
+      partitionKey: {
+        name: "pk",
+        type: dynamodb.AttributeType.STRING,
+      },
+      sortKey: {
+        name: "sk",
+        type: dynamodb.AttributeType.STRING,
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+      encryption: dynamodb.TableEncryption.AWS_MANAGED,
+      timeToLiveAttribute: "ExpiresAt",
+    });
+
     new cdk.CfnOutput(this, "TableName", {
       value: this.table.tableName,
       description: "DynamoDB Table Name",
@@ -38,5 +85,15 @@ export class DynamoDbStack extends cdk.Stack {
       value: this.table.tableArn,
       description: "DynamoDB Table ARN",
     });
+
+    new cdk.CfnOutput(this, "OrdersTableName", {
+      value: this.ordersTable.tableName,
+      description: "DynamoDB Orders Table Name",
+    });
+
+    new cdk.CfnOutput(this, "ArchiveTableName", {
+      value: this.archiveTable.tableName,
+      description: "DynamoDB Archive Table Name",
+    });
   }
 }
@@ -10,6 +10,7 @@ import { getUnifiedLambdaCode } from "./LambdaCode";
 
 export interface HttpApiStackProps extends cdk.StackProps {
   table: dynamodb.ITable;
+  ordersTable: dynamodb.ITable;
 }
 
 export class HttpApiStack extends cdk.Stack {
@@ -30,13 +31,16 @@ export class HttpApiStack extends cdk.Stack {
         // AWS_REGION: this.region, // This is set by the Lambda runtime and cannot be overridden
         LAMBDA_HANDLER: "httpapi",
         DYNAMODB_TABLE: props.table.tableName,
+        ORDERS_TABLE: props.ordersTable.tableName,
       },
-      timeout: cdk.Duration.seconds(5),
+      // Batch attestation performs many DNS lookups; stay just under the 30 second API Gateway limit
+      timeout: cdk.Duration.seconds(29),
       memorySize: 128,
     });
 
     // Grant DynamoDB permissions
     props.table.grantReadWriteData(this.apiFunction);
+    props.ordersTable.grantReadWriteData(this.apiFunction);
 
     // Set log retention on the auto-created log group
     new logs.LogRetention(this, "HttpApiFunctionLogRetention", {
@@ -96,5 +100,10 @@ export class HttpApiStack extends cdk.Stack {
       value: `${this.api.apiEndpoint}/api/v1/attest`,
       description: "Direct API Gateway attest endpoint URL",
     });
+
+    new cdk.CfnOutput(this, "OrdersEndpoint", {
+      value: `${this.api.apiEndpoint}/api/v1/orders`,
+      description: "Direct API Gateway orders endpoint URL",
+    });
   }
 }
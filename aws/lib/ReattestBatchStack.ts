@@ -11,6 +11,7 @@ import { getUnifiedLambdaCode } from "./LambdaCode";
 
 export interface ReattestBatchStackProps extends cdk.StackProps {
   table: dynamodb.ITable;
+  archiveTable: dynamodb.ITable;
   contentBucket: s3.IBucket;
 }
 
@@ -33,8 +34,10 @@ export class ReattestBatchStack extends cdk.Stack {
         environment: {
           LAMBDA_HANDLER: "reattestbatch",
           DYNAMODB_TABLE: props.table.tableName,
+          ARCHIVE_TABLE: props.archiveTable.tableName,
           S3_BUCKET: props.contentBucket.bucketName,
           S3_DATA_KEY: config.domainsDataKey || "records/domains.json",
+          REATTEST_RETENTION: config.reattestRetention,
         },
         timeout: cdk.Duration.minutes(5),
         memorySize: 256,
@@ -43,6 +46,7 @@ export class ReattestBatchStack extends cdk.Stack {
 
     // Grant DynamoDB permissions
     props.table.grantReadWriteData(this.reattestBatchFunction);
+    props.archiveTable.grantReadWriteData(this.reattestBatchFunction);
 
     // Grant S3 permissions
     props.contentBucket.grantReadWrite(this.reattestBatchFunction);
@@ -53,10 +57,15 @@ export class ReattestBatchStack extends cdk.Stack {
       retention: logs.RetentionDays.ONE_WEEK,
     });
 
-    // Create EventBridge rule to trigger the function on a schedule
+    // Create EventBridge rule to trigger the function on a schedule.
+    // Each run only re-attests the groups that are due: healthy groups daily, failing groups
+    // first after an hour and then with backoff (REATTEST_RETRY_INTERVAL and friends).
+    // The rule must run at least as often as the retry interval, or failing groups would wait a day
+    // between retries whatever their backoff; running hourly also spreads the daily lookups of
+    // healthy groups out over the day instead of doing them all at once.
     const rule = new events.Rule(this, "ReattestBatchScheduleRule", {
-      schedule: events.Schedule.rate(cdk.Duration.hours(24)),
-      description: "Trigger re-attestation batch process every day",
+      schedule: events.Schedule.rate(cdk.Duration.hours(1)),
+      description: "Trigger re-attestation batch process every hour",
     });
 
     // Add the Lambda function as a target of the rule
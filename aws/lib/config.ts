@@ -11,6 +11,9 @@ export const config = {
   domainsDataKey: "records/domains.json",
   // Where alerts are sent
   alertEmail: "me+suns-alerts@micahrl.com",
+  // When reattestation removes invalid groups; see 'symval reattest --help' for the syntax.
+  // Passed to the batch Lambda as REATTEST_RETENTION, which the CLI also reads.
+  reattestRetention: "hours=72",
   // Lambda function names - centralized to avoid cross-stack reference issues
   // If we used generated names, we wouldn't be able to ever deploy a new version of a function,
   // because other stacks would depend on the old name (and the generated name changes with each deployment).
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/usecase/archive"
+	"github.com/spf13/cobra"
+)
+
+// ArchiveFlags holds flags that locate the archive of removed groups
+type ArchiveFlags struct {
+	ArchiveFile  string
+	ArchiveTable string
+}
+
+// addArchiveFlags adds the archive location flags to a command
+func addArchiveFlags(cmd *cobra.Command, flags *ArchiveFlags) {
+	cmd.Flags().StringVar(&flags.ArchiveFile, "archive-file", "", "Path to JSON file of archived groups (default: next to --file)")
+	cmd.Flags().StringVar(&flags.ArchiveTable, "archive-table", "", "DynamoDB table of archived groups")
+}
+
+// archiveConfig returns where the archive is kept, or false if there is nowhere to keep it.
+// The archive file defaults to one next to the data file.
+func archiveConfig(persistence PersistenceFlags, flags ArchiveFlags) (repository.ArchiveConfig, bool) {
+	cfg := repository.ArchiveConfig{
+		FilePath:       flags.ArchiveFile,
+		DynamoTable:    flags.ArchiveTable,
+		DynamoEndpoint: persistence.DynamoEndpoint,
+	}
+	if cfg.FilePath == "" && cfg.DynamoTable == "" && persistence.FilePath != "" {
+		cfg.FilePath = strings.TrimSuffix(persistence.FilePath, ".json") + ".archive.json"
+	}
+	return cfg, cfg.FilePath != "" || cfg.DynamoTable != ""
+}
+
+var archiveFlags struct {
+	PersistenceFlags
+	ArchiveFlags
+	SkipCheck bool
+}
+
+var archiveCmd = &cobra.Command{
+	Use:     "archive",
+	Short:   "List and restore groups that reattestation removed",
+	GroupID: "attestation",
+	Long: `Archive lists and restores groups that reattestation removed.
+
+When 'reattest' removes an invalid group, its records are moved to the archive
+rather than deleted, and kept there for --archive-retention (one year by default).
+Archived groups are not part of the public list.
+
+If a member fixes their DNS, 'archive restore' returns the group to the data store,
+keeping the time it was first attested.
+
+The archive is kept in --archive-file (by default next to --file), or in the
+DynamoDB table given with --archive-table.`,
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List archived groups",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `List prints each archived group, when it was archived and why, and when it expires.
+
+Example:
+  symval archive list --file ./data.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		archiveUC, err := newArchiveUseCase(ctx, cmd)
+		if err != nil {
+			return err
+		}
+
+		groups, err := archiveUC.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(groups) == 0 {
+			fmt.Println("\nNo archived groups.")
+			return nil
+		}
+
+		fmt.Printf("\nFound %d archived group(s):\n\n", len(groups))
+		for i, group := range groups {
+			domains := make([]string, 0, len(group.Records))
+			for _, a := range group.Records {
+				domains = append(domains, a.Record.Hostname)
+			}
+			fmt.Printf("%d. %s\n", i+1, group.GroupID)
+			fmt.Printf("   Owner: %s\n", group.Owner)
+			fmt.Printf("   Type: %s\n", group.Type)
+			fmt.Printf("   Domains: %v\n", domains)
+			fmt.Printf("   Archived: %s\n", group.ArchiveTime.Format(time.RFC3339))
+			fmt.Printf("   Expires: %s\n", group.ExpireTime.Format(time.RFC3339))
+			if group.Reason != "" {
+				fmt.Printf("   Reason: %s\n", group.Reason)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var archiveRestoreCmd = &cobra.Command{
+	Use:           "restore <group-id>",
+	Short:         "Restore an archived group whose DNS has been fixed",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Restore returns an archived group to the data store.
+
+The group is attested first, and is only restored if it passes; use --skip-check
+to restore it regardless. Restored records keep the time they were first attested,
+and count as having just passed.
+
+Example:
+  symval archive restore 'v1:m:...:...' --file ./data.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		groupID := args[0]
+
+		if _, err := groupid.ParseGroupIDv1(groupID); err != nil {
+			cmd.SilenceUsage = false
+			return &UsageError{err}
+		}
+
+		archiveUC, err := newArchiveUseCase(ctx, cmd)
+		if err != nil {
+			return err
+		}
+
+		result, err := archiveUC.Restore(ctx, groupID, archiveFlags.SkipCheck)
+		if errors.Is(err, model.ErrArchiveNotFound) {
+			return ExitWithCode(1, fmt.Errorf("group %s not found in the archive", groupID))
+		}
+		if result != nil {
+			fmt.Printf("Group ID: %s\n", result.Group.GroupID)
+			fmt.Printf("Owner: %s\n", result.Group.Owner)
+			fmt.Printf("Type: %s\n", result.Group.Type)
+			fmt.Printf("Archived: %s\n", result.Group.ArchiveTime.Format(time.RFC3339))
+		}
+		if errors.Is(err, archive.ErrStillInvalid) {
+			fmt.Println("\n✗ Restore FAILED: the group still fails attestation")
+			return ExitWithCode(1, err)
+		} else if err != nil {
+			return ExitWithCode(1, fmt.Errorf("restore failed: %w", err))
+		}
+
+		fmt.Printf("\n✓ Restored %d record(s)\n", result.Restored)
+		return nil
+	},
+}
+
+// newArchiveUseCase creates the archive use case from the archive command flags
+func newArchiveUseCase(ctx context.Context, cmd *cobra.Command) (*archive.ArchiveUseCase, error) {
+	if archiveFlags.FilePath == "" && archiveFlags.DynamoTable == "" {
+		cmd.SilenceUsage = false
+		return nil, &UsageError{fmt.Errorf("one of --file or --dynamodb-table is required")}
+	}
+	cfg, ok := archiveConfig(archiveFlags.PersistenceFlags, archiveFlags.ArchiveFlags)
+	if !ok {
+		cmd.SilenceUsage = false
+		return nil, &UsageError{fmt.Errorf("--archive-table is required with --dynamodb-table")}
+	}
+
+	repo, err := repository.NewRepository(ctx, repository.RepositoryConfig{
+		FilePath:       archiveFlags.FilePath,
+		DynamoTable:    archiveFlags.DynamoTable,
+		DynamoEndpoint: archiveFlags.DynamoEndpoint,
+	})
+	if err != nil {
+		return nil, err
+	}
+	archiveRepo, err := repository.NewArchiveRepository(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.NewArchiveUseCase(dnsclaims.NewService(), repo, archiveRepo), nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{archiveListCmd, archiveRestoreCmd} {
+		cmd.Flags().StringVarP(&archiveFlags.FilePath, "file", "f", "", "Path to JSON file for persistence")
+		cmd.Flags().StringVarP(&archiveFlags.DynamoTable, "dynamodb-table", "t", "", "DynamoDB table name for persistence")
+		cmd.Flags().StringVarP(&archiveFlags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
+		addArchiveFlags(cmd, &archiveFlags.ArchiveFlags)
+	}
+	archiveRestoreCmd.Flags().BoolVar(&archiveFlags.SkipCheck, "skip-check", false, "Restore the group without attesting it first")
+
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveRestoreCmd)
+}
@@ -2,22 +2,33 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/hostname"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/repository"
 	"github.com/mrled/suns/symval/internal/repository/memrepo"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
 	"github.com/mrled/suns/symval/internal/symgroup"
 	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/usecase/conflict"
 	"github.com/spf13/cobra"
 )
 
-var attestFlags PersistenceFlags
+var attestFlags struct {
+	PersistenceFlags
+	AttestPolicyFlags
+	FromFile string
+	Workers  int
+}
 
 var attestCmd = &cobra.Command{
-	Use:           "attest <owner> <type> <domain1> [domain2]...",
+	Use:           "attest {<owner> <type> <domain1> [domain2]... | --from-file <groups.json>}",
 	Short:         "Attest a group of domains for consistency and validity",
 	GroupID:       "attestation",
 	SilenceUsage:  true,
@@ -30,17 +41,39 @@ It performs the following checks:
   3. Checks that all group IDs are consistent (same owner hash)
   4. Validates the group according to its symmetry type
 
+Public suffixes (like co.uk or github.io) cannot be claimed.
+If a hostname was already attested by another owner, --conflict-policy decides the outcome:
+first-come refuses the new claim, and require-release allows it once
+the earlier owner's _suns TXT records are gone.
+With --max-groups-per-domain, a group is refused if any of its names falls under
+a registrable domain (eTLD+1) that already has that many groups in the data store.
+With --dry-run, every check runs against the data store but nothing is stored.
+
+With --from-file, attest every group in a JSON file instead, --workers at a time.
+The file holds an array in the same format as the batch API:
+
+  [{"owner": "myowner", "type": "palindrome", "domains": ["example.com", "test.com"]}, ...]
+
 Example:
   symval attest myowner palindrome example.com test.com
   symval attest myowner a example.com test.com
-  symval attest owner123 mirrortext domain1.com domain2.com domain3.com`,
-	Args: cobra.MinimumNArgs(3),
+  symval attest owner123 mirrortext domain1.com domain2.com domain3.com
+  symval attest --from-file groups.json --file ./data.json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if attestFlags.FromFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(3)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
+		if attestFlags.FromFile != "" {
+			return attestFromFile(cmd)
+		}
+
 		owner := args[0]
 		typeName := strings.ToLower(args[1])
-		domains := args[2:]
 
 		// Convert type name to type code
 		typeCode, ok := symgroup.TypeNameToCode[typeName]
@@ -57,30 +90,24 @@ Example:
 
 		symmetryType := symgroup.SymmetryType(typeCode)
 
-		// Create repository based on persistence flags
-		var repo model.DomainRepository
-		if attestFlags.DynamoTable != "" || attestFlags.FilePath != "" {
-			// Use persistent repository (file or DynamoDB)
-			r, err := repository.NewRepository(ctx, repository.RepositoryConfig{
-				FilePath:       attestFlags.FilePath,
-				DynamoTable:    attestFlags.DynamoTable,
-				DynamoEndpoint: attestFlags.DynamoEndpoint,
-			})
-			if err != nil {
-				return err
-			}
-			repo = r
-		} else {
-			// Use in-memory only (no persistence)
-			repo = memrepo.NewMemoryRepository()
+		// Reject malformed hostnames before any DNS lookups
+		domains, err := parseHostnameArgs(cmd, args[2:])
+		if err != nil {
+			return err
 		}
 
-		// Create DNS service and attestation use case
-		dnsService := dnsclaims.NewService()
-		attestUseCase := attestation.NewAttestationUseCase(dnsService, repo)
+		attestUseCase, err := newAttestUseCase(ctx, cmd, attestFlags.PersistenceFlags, attestFlags.AttestPolicyFlags)
+		if err != nil {
+			return err
+		}
 
-		// Perform attestation
-		result, err := attestUseCase.Attest(owner, symmetryType, domains)
+		// Perform attestation; a dry run performs every check but stores nothing
+		var result *attestation.AttestResult
+		if attestFlags.DryRun {
+			result, err = attestUseCase.Check(owner, symmetryType, domains)
+		} else {
+			result, err = attestUseCase.Attest(owner, symmetryType, domains)
+		}
 		if err != nil {
 			return ExitWithCode(1, fmt.Errorf("attestation failed: %w", err))
 		}
@@ -89,10 +116,16 @@ Example:
 		fmt.Printf("Expected Group ID: %s\n", result.ExpectedID)
 		fmt.Printf("Found %d group ID(s) in DNS records\n", len(result.GroupIDs))
 
+		if result.Conflicts != nil && !result.Conflicts.Blocking() {
+			fmt.Printf("\n%s\n", result.Conflicts)
+		}
+
 		if result.IsValid {
 			fmt.Println("\n✓ Attestation PASSED")
 			fmt.Println("The domains form a valid symmetric group.")
-			if attestFlags.DynamoTable != "" {
+			if attestFlags.DryRun {
+				fmt.Println("(No changes made - dry run)")
+			} else if attestFlags.DynamoTable != "" {
 				fmt.Printf("Results persisted to DynamoDB table: %s\n", attestFlags.DynamoTable)
 			} else if attestFlags.FilePath != "" {
 				fmt.Printf("Results persisted to: %s\n", attestFlags.FilePath)
@@ -102,12 +135,157 @@ Example:
 			if result.ErrorMessage != "" {
 				fmt.Printf("Reason: %s\n", result.ErrorMessage)
 			}
+			if len(result.Diagnostics) > 0 {
+				fmt.Println("\nDiagnostics by domain:")
+				printDiagnostics(result.Diagnostics)
+			}
 		}
 
 		return nil
 	},
 }
 
+// newAttestUseCase creates an attestation use case from the persistence and policy flags
+func newAttestUseCase(ctx context.Context, cmd *cobra.Command, persistence PersistenceFlags, policy AttestPolicyFlags) (*attestation.AttestationUseCase, error) {
+	conflictPolicy, err := conflict.ParsePolicy(policy.ConflictPolicy)
+	if err != nil {
+		cmd.SilenceUsage = false
+		return nil, &UsageError{err}
+	}
+
+	// Create repository based on persistence flags
+	var repo model.DomainRepository
+	if persistence.DynamoTable != "" || persistence.FilePath != "" {
+		// Use persistent repository (file or DynamoDB)
+		r, err := repository.NewRepository(ctx, repository.RepositoryConfig{
+			FilePath:       persistence.FilePath,
+			DynamoTable:    persistence.DynamoTable,
+			DynamoEndpoint: persistence.DynamoEndpoint,
+		})
+		if err != nil {
+			return nil, err
+		}
+		repo = r
+	} else {
+		// Use in-memory only (no persistence)
+		repo = memrepo.NewMemoryRepository()
+	}
+
+	// Create DNS service and attestation use case
+	dnsService := dnsclaims.NewService()
+	attestUseCase := attestation.NewAttestationUseCase(dnsService, repo)
+	attestUseCase.SetRegistrableDomainLimit(policy.MaxGroupsPerDomain)
+	attestUseCase.SetConflictPolicy(conflictPolicy)
+	return attestUseCase, nil
+}
+
+// attestFromFile attests every group in the --from-file JSON file, printing one line per group
+func attestFromFile(cmd *cobra.Command) error {
+	ctx := context.Background()
+
+	data, err := os.ReadFile(attestFlags.FromFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", attestFlags.FromFile, err)
+	}
+	var requests []apitypes.AttestRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return fmt.Errorf("failed to parse %s: expected an array of {owner, type, domains} objects: %w", attestFlags.FromFile, err)
+	}
+
+	// Report every invalid entry before attesting anything
+	var items []attestation.BatchItem
+	var invalid []error
+	for i, request := range requests {
+		symmetryType, ok := symgroup.ParseType(request.Type)
+		if !ok {
+			invalid = append(invalid, fmt.Errorf("group %d: invalid symmetry type %q", i+1, request.Type))
+			continue
+		}
+		domains, errs := hostname.CanonicalizeAll(request.Domains)
+		if len(errs) > 0 {
+			invalid = append(invalid, fmt.Errorf("group %d: %w", i+1, errors.Join(errs...)))
+			continue
+		}
+		if request.Owner == "" || len(domains) == 0 {
+			invalid = append(invalid, fmt.Errorf("group %d: owner and at least one domain are required", i+1))
+			continue
+		}
+		items = append(items, attestation.BatchItem{Owner: request.Owner, Type: symmetryType, Domains: domains})
+	}
+	if len(invalid) > 0 {
+		cmd.SilenceUsage = false
+		return &UsageError{fmt.Errorf("%s has %d invalid group(s):\n%w", attestFlags.FromFile, len(invalid), errors.Join(invalid...))}
+	}
+
+	attestUseCase, err := newAttestUseCase(ctx, cmd, attestFlags.PersistenceFlags, attestFlags.AttestPolicyFlags)
+	if err != nil {
+		return err
+	}
+
+	results := attestUseCase.AttestBatch(items, attestFlags.Workers, attestFlags.DryRun)
+
+	failed := 0
+	for i, r := range results {
+		label := fmt.Sprintf("%d. %s %s %s", i+1, items[i].Owner, symgroup.TypeCodeToName[string(items[i].Type)], strings.Join(items[i].Domains, " "))
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Printf("✗ %s\n    Error: %v\n", label, r.Err)
+		case !r.Result.IsValid:
+			failed++
+			fmt.Printf("✗ %s\n    Reason: %s\n", label, r.Result.ErrorMessage)
+		default:
+			fmt.Printf("✓ %s\n", label)
+		}
+	}
+
+	fmt.Printf("\nSummary: %d passed, %d failed\n", len(results)-failed, failed)
+	if attestFlags.DryRun {
+		fmt.Println("(No changes made - dry run)")
+	} else if attestFlags.DynamoTable != "" {
+		fmt.Printf("Results persisted to DynamoDB table: %s\n", attestFlags.DynamoTable)
+	} else if attestFlags.FilePath != "" {
+		fmt.Printf("Results persisted to: %s\n", attestFlags.FilePath)
+	}
+
+	if failed > 0 {
+		return ExitWithCode(1, fmt.Errorf("%d of %d group(s) failed attestation", failed, len(results)))
+	}
+	return nil
+}
+
 func init() {
-	addPersistenceFlags(attestCmd, &attestFlags)
+	addPersistenceFlags(attestCmd, &attestFlags.PersistenceFlags)
+	attestCmd.Flags().StringVar(&attestFlags.FromFile, "from-file", "", "Attest every group in a JSON file instead of the arguments")
+	attestCmd.Flags().IntVar(&attestFlags.Workers, "workers", attestation.DefaultBatchWorkers, "Groups attested at once with --from-file")
+	addAttestPolicyFlags(attestCmd, &attestFlags.AttestPolicyFlags)
+}
+
+// printDiagnostics prints what DNS returned for each domain and why each record was or was not used
+func printDiagnostics(diagnostics []attestation.DomainDiagnostic) {
+	for _, d := range diagnostics {
+		status := "✓"
+		if !d.OK() {
+			status = "✗"
+		}
+		// Domains that failed the checks before DNS lookups have no lookup path
+		if len(d.CNAMEPath) > 0 {
+			fmt.Printf("  %s %s (%s)\n", status, d.Hostname, strings.Join(d.CNAMEPath, " -> "))
+		} else {
+			fmt.Printf("  %s %s\n", status, d.Hostname)
+		}
+		if d.ResolverError != "" {
+			fmt.Printf("      resolver error: %s\n", d.ResolverError)
+		}
+		for _, r := range d.Records {
+			verdict := "matches"
+			if r.Rejected != "" {
+				verdict = "rejected: " + string(r.Rejected)
+			}
+			fmt.Printf("      %s (%s)\n", r.Value, verdict)
+		}
+		if d.Problem != "" {
+			fmt.Printf("      problem: %s\n", d.Problem)
+		}
+	}
 }
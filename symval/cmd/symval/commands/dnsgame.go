@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/adapter/dnsserver"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/usecase/dnsgame"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var dnsgameFlags struct {
+	PersistenceFlags
+	Listen      string
+	Zone        string
+	Resolver    string
+	TTL         uint32
+	Encode      []string
+	MaxInFlight int
+}
+
+var dnsgameCmd = &cobra.Command{
+	Use:           "dnsgame",
+	Short:         "Run an authoritative DNS server that attests groups from DNS queries",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Run a small authoritative DNS server for a claim zone such as claim.suns.bz.
+
+Members attest a group by querying a TXT record under the zone:
+
+  <token>.<domain>.<zone>
+
+The token is the lowercase unpadded base32 encoding of "<typecode>:<owner>".
+For two-domain types the partner domain is derived from <domain>:
+doubleflip180 uses the 180-degree flip, and mirrornames reverses the labels.
+
+Each query runs a full attestation and answers with a TXT record whose first
+word is a result code:
+  ok <groupid>     The group was attested (and stored, if persistence is configured)
+  fail <reason>    The _suns records did not prove the claim
+  badname <reason> The query name could not be decoded
+  error <reason>   Attestation could not be completed
+
+Repeated claims of the same group (resolvers retry, and randomize the case of
+query names) share one attestation, whose result is reused for a short time.
+At most --max-in-flight queries are handled at once; others are dropped.
+
+Use --encode to print the name to query instead of running the server.
+
+Examples:
+  # Serve the claim zone locally on port 5353
+  symval dnsgame --zone claim.suns.bz --listen 127.0.0.1:5353 --file ./data.json
+
+  # Print the query name for a group
+  symval dnsgame --zone claim.suns.bz --encode alice@example.com,palindrome,abba.example
+
+  # Claim it with a plain DNS client
+  dig @127.0.0.1 -p 5353 TXT <token>.abba.example.claim.suns.bz`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(dnsgameFlags.Encode) > 0 {
+			return printClaimName(cmd, dnsgameFlags.Encode)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// Create repository based on persistence flags
+		var repo model.DomainRepository
+		if dnsgameFlags.DynamoTable != "" || dnsgameFlags.FilePath != "" {
+			r, err := repository.NewRepository(ctx, repository.RepositoryConfig{
+				FilePath:       dnsgameFlags.FilePath,
+				DynamoTable:    dnsgameFlags.DynamoTable,
+				DynamoEndpoint: dnsgameFlags.DynamoEndpoint,
+			})
+			if err != nil {
+				return err
+			}
+			repo = r
+		} else {
+			repo = memrepo.NewMemoryRepository()
+			fmt.Println("Using in-memory storage (no persistence)")
+		}
+
+		dnsService := dnsclaims.NewService()
+		if dnsgameFlags.Resolver != "" {
+			dnsService = dnsclaims.NewServiceWithResolver(dnsclaims.NewCustomResolver(dnsgameFlags.Resolver))
+		}
+		gameUseCase := dnsgame.NewDNSGameUseCase(attestation.NewAttestationUseCase(dnsService, repo))
+
+		zone := dnsserver.CanonicalName(dnsgameFlags.Zone)
+		ttl := dnsgameFlags.TTL
+		server := dnsserver.New(zone, dnsserver.HandlerFunc(func(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+			prefix := dnsserver.TrimZone(dnsserver.CanonicalName(q.Name.String()), zone)
+			if prefix == "" || q.Type != dnsmessage.TypeTXT {
+				return dnsmessage.RCodeSuccess, nil
+			}
+			result := gameUseCase.Play(prefix)
+			return dnsmessage.RCodeSuccess, []dnsmessage.Resource{dnsserver.TXTResource(q.Name, ttl, result.TXT())}
+		}))
+
+		server.SetMaxInFlight(dnsgameFlags.MaxInFlight)
+
+		fmt.Printf("Serving claim zone %s on %s (udp)\n", zone, dnsgameFlags.Listen)
+		return server.ListenAndServe(ctx, dnsgameFlags.Listen)
+	},
+}
+
+// printClaimName prints the claim query name for an owner,type,domain triple
+func printClaimName(cmd *cobra.Command, parts []string) error {
+	if len(parts) != 3 {
+		cmd.SilenceUsage = false
+		return &UsageError{fmt.Errorf("--encode expects owner,type,domain")}
+	}
+
+	typeName := strings.ToLower(parts[1])
+	typeCode, ok := symgroup.TypeNameToCode[typeName]
+	if !ok {
+		if _, codeExists := symgroup.TypeCodeToName[typeName]; !codeExists {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("invalid symmetry type: %s\n%s", typeName, symgroup.ValidSymmetryTypesText())}
+		}
+		typeCode = typeName
+	}
+
+	name, err := dnsgame.ClaimName(parts[0], symgroup.SymmetryType(typeCode), parts[2], dnsgameFlags.Zone)
+	if err != nil {
+		return err
+	}
+	fmt.Println(name)
+	return nil
+}
+
+func init() {
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.FilePath, "file", "f", "", "Path to JSON file for persistence")
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.DynamoTable, "dynamodb-table", "t", "", "DynamoDB table name for persistence")
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.Listen, "listen", "l", "127.0.0.1:5353", "UDP address to listen on (host:port)")
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.Zone, "zone", "z", "claim.suns.bz", "Zone the server is authoritative for")
+	dnsgameCmd.Flags().StringVarP(&dnsgameFlags.Resolver, "resolver", "r", "", "DNS resolver for _suns lookups (host:port, optional, uses system resolver if not specified)")
+	dnsgameCmd.Flags().Uint32Var(&dnsgameFlags.TTL, "ttl", 0, "TTL for TXT answers, in seconds")
+	dnsgameCmd.Flags().IntVar(&dnsgameFlags.MaxInFlight, "max-in-flight", dnsserver.DefaultMaxInFlight, "Most queries handled at once; others are dropped")
+	dnsgameCmd.Flags().StringSliceVar(&dnsgameFlags.Encode, "encode", nil, "Print the claim query name for owner,type,domain and exit")
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/doctor"
+	"github.com/spf13/cobra"
+)
+
+var doctorFlags struct {
+	PersistenceFlags
+	Resolver string
+}
+
+var doctorCmd = &cobra.Command{
+	Use:           "doctor <owner> <type> <domain1> [domain2]...",
+	Short:         "Diagnose why a group of domains does or does not attest",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Doctor explains why a group of domains fails attestation, and how to fix it.
+
+It performs the following checks:
+  1. Calculates the expected group ID based on owner, type and domains
+  2. Tests whether the domains form a valid group of the type at all
+  3. Looks up the DNS TXT records (_suns.<domain>) for each domain, following a CNAME,
+     and lints each record: stray quotes or whitespace, wrong version,
+     a different type, a different owner hash, or a different domains hash
+  4. Checks the zone policy (_suns-policy) for each domain
+  5. If a data store is given, checks whether the group and its domains are stored
+
+It finishes with concrete fix-it instructions, including the exact TXT record to publish.
+Doctor never changes DNS or the data store.
+
+Example:
+  symval doctor alice@example.com mirrornames example.com com.example
+  symval doctor alice@example.com palindrome aba.example --file ./data.json`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		owner := args[0]
+		symmetryType, err := parseTypeArg(cmd, args[1])
+		if err != nil {
+			return err
+		}
+
+		domains, err := parseHostnameArgs(cmd, args[2:])
+		if err != nil {
+			return err
+		}
+
+		// The stored state is only checked when a data store is given
+		var repo model.DomainRepository
+		if doctorFlags.FilePath != "" || doctorFlags.DynamoTable != "" {
+			repo, err = repository.NewRepository(ctx, repository.RepositoryConfig{
+				FilePath:       doctorFlags.FilePath,
+				DynamoTable:    doctorFlags.DynamoTable,
+				DynamoEndpoint: doctorFlags.DynamoEndpoint,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		dnsService := dnsclaims.NewService()
+		if doctorFlags.Resolver != "" {
+			dnsService = dnsclaims.NewServiceWithResolver(dnsclaims.NewCustomResolver(doctorFlags.Resolver))
+		}
+
+		report, err := doctor.NewDoctorUseCase(dnsService, repo).Diagnose(ctx, owner, symmetryType, domains)
+		if err != nil {
+			return ExitWithCode(1, fmt.Errorf("diagnosis failed: %w", err))
+		}
+
+		printDoctorReport(report)
+
+		if !report.Healthy() {
+			return ExitWithCode(1, fmt.Errorf("group will not attest as-is"))
+		}
+		return nil
+	},
+}
+
+// printDoctorReport prints the findings for each domain followed by the fix-it steps
+func printDoctorReport(report *doctor.Report) {
+	fmt.Printf("Owner: %s\n", report.Owner)
+	fmt.Printf("Type: %s (%s)\n", report.Type, symgroup.TypeCodeToName[string(report.Type)])
+	fmt.Printf("Expected Group ID: %s\n", report.ExpectedID)
+
+	if report.InputError != "" {
+		fmt.Printf("\n✗ %s\n", report.InputError)
+	} else if report.SymmetryError != "" {
+		fmt.Printf("\n✗ Symmetry: %s\n", report.SymmetryError)
+	} else {
+		fmt.Println("\n✓ Symmetry: the domains form a valid group")
+	}
+
+	for _, d := range report.Domains {
+		status := "✓"
+		if !d.HasExpected() {
+			status = "✗"
+		}
+		fmt.Printf("\n%s %s (%s)\n", status, d.Hostname, strings.Join(d.CNAMEPath, " -> "))
+
+		if d.ResolverError != "" {
+			fmt.Printf("  Resolver error: %s\n", d.ResolverError)
+		} else if len(d.Records) == 0 {
+			fmt.Println("  No _suns records found")
+		}
+		for _, record := range d.Records {
+			if record.Expected {
+				fmt.Printf("  ✓ %s\n", record.Value)
+				continue
+			}
+			fmt.Printf("  ✗ %s\n", record.Value)
+			for _, problem := range record.Problems {
+				fmt.Printf("      %s\n", problem)
+			}
+		}
+		if d.MixedOwners != "" {
+			fmt.Printf("  Note: records at %s were published for more than one owner\n", d.Label)
+		}
+		if d.PolicyRecord != "" {
+			fmt.Printf("  Policy: %s does not allow this owner\n", d.PolicyRecord)
+		}
+
+		if report.StoreChecked {
+			if d.Stored != nil {
+				fmt.Printf("  Stored: yes (validated %s, rev %d)\n", d.Stored.ValidateTime.Format("2006-01-02 15:04:05"), d.Stored.Rev)
+			} else {
+				fmt.Println("  Stored: no")
+			}
+			for _, other := range d.OtherGroups {
+				fmt.Printf("  Also stored in group %s (%s)\n", other.GroupID, other.Owner)
+			}
+		}
+	}
+
+	if report.Healthy() {
+		fmt.Println("\n✓ No problems found; the group should attest.")
+		return
+	}
+
+	fmt.Println("\nTo fix:")
+	for i, fix := range report.Fixes {
+		fmt.Printf("  %d. %s\n", i+1, fix)
+	}
+}
+
+func init() {
+	doctorCmd.Flags().StringVarP(&doctorFlags.FilePath, "file", "f", "", "Path to JSON file of stored records, to check the stored state")
+	doctorCmd.Flags().StringVarP(&doctorFlags.DynamoTable, "dynamodb-table", "t", "", "DynamoDB table of stored records, to check the stored state")
+	doctorCmd.Flags().StringVarP(&doctorFlags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
+	doctorCmd.Flags().StringVarP(&doctorFlags.Resolver, "resolver", "r", "", "DNS resolver address (host:port); uses the system resolver if not specified")
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"github.com/mrled/suns/symval/internal/usecase/conflict"
 	"github.com/spf13/cobra"
 )
 
@@ -19,3 +20,15 @@ func addPersistenceFlags(cmd *cobra.Command, flags *PersistenceFlags) {
 	cmd.Flags().StringVarP(&flags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
 	cmd.Flags().BoolVarP(&flags.DryRun, "dry-run", "r", false, "Show what would be changed without making changes")
 }
+
+// AttestPolicyFlags holds flags for the policies enforced when attesting locally
+type AttestPolicyFlags struct {
+	ConflictPolicy     string
+	MaxGroupsPerDomain int
+}
+
+// addAttestPolicyFlags adds the attestation policy flags to a command
+func addAttestPolicyFlags(cmd *cobra.Command, flags *AttestPolicyFlags) {
+	cmd.Flags().StringVar(&flags.ConflictPolicy, "conflict-policy", string(conflict.DefaultPolicy), "How to resolve claims on hostnames attested by another owner: first-come or require-release")
+	cmd.Flags().IntVar(&flags.MaxGroupsPerDomain, "max-groups-per-domain", 0, "Maximum groups that may include names under one registrable domain (0 for unlimited)")
+}
@@ -17,6 +17,8 @@ var groupidCmd = &cobra.Command{
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Long: `Calculate a group ID by hashing owner and all hostnames, prepending type and version.
+Hostnames are canonicalized before hashing: lowercased, stripped of any trailing dot,
+and internationalized names converted to their punycode (A-label) form.
 
 Arguments:
   owner      Owner of the group
@@ -26,7 +28,10 @@ Arguments:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		owner := args[0]
 		typeName := strings.ToLower(args[1])
-		hostnames := args[2:]
+		hostnames, err := parseHostnameArgs(cmd, args[2:])
+		if err != nil {
+			return err
+		}
 
 		// Convert type name to code
 		typeCode, ok := symgroup.TypeNameToCode[typeName]
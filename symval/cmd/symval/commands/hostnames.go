@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/spf13/cobra"
+)
+
+// parseHostnameArgs canonicalizes hostname arguments,
+// returning a usage error that lists every invalid hostname
+func parseHostnameArgs(cmd *cobra.Command, args []string) ([]string, error) {
+	names, errs := hostname.CanonicalizeAll(args)
+	if len(errs) > 0 {
+		cmd.SilenceUsage = false
+		return nil, &UsageError{errors.Join(errs...)}
+	}
+	return names, nil
+}
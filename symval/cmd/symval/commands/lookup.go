@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/mrled/suns/symval/internal/repository"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
 	"github.com/mrled/suns/symval/internal/usecase/concheck"
+	"github.com/mrled/suns/symval/internal/usecase/conflict"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +16,11 @@ var (
 	resolverAddr string
 )
 
+var lookupFlags struct {
+	PersistenceFlags
+	ConflictPolicy string
+}
+
 var lookupCmd = &cobra.Command{
 	Use:           "lookup <domain> [domain...]",
 	Short:         "Lookup DNS records for one or more domains",
@@ -26,14 +35,44 @@ Arguments:
 For each domain, this command will:
   - Look up TXT records at _suns.<domain>
   - Display all found records, or indicate if no records were found
-  - Follow CNAME records if the TXT record is not found directly`,
+  - Follow CNAME records if the TXT record is not found directly
+  - Display the effective zone policy: the closest _suns-policy TXT record
+    at or above the domain, listing the owner hashes allowed to claim it
+
+A zone owner publishes a policy as one TXT record per allowed owner:
+  _suns-policy.example.com. TXT "v1:allow:<owner hash>"
+The owner hash is the third field of a v1 group ID, as printed by "symval groupid".
+
+If a data store is given with --file or --dynamodb-table, each domain's DNS claims
+are also compared against stored records, and a conflict report is shown for any
+hostname claimed by an owner other than the one who attested it.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
 		domains := args
 		resolver := dnsclaims.NewCustomResolver(resolverAddr)
 		dnsService := dnsclaims.NewServiceWithResolver(resolver)
 		consistencyChecker := concheck.NewConsistencyCheckUseCase(dnsService)
 
+		// Conflict detection needs stored records, so it only runs when a data store is given
+		var detector *conflict.Detector
+		if lookupFlags.FilePath != "" || lookupFlags.DynamoTable != "" {
+			policy, err := conflict.ParsePolicy(lookupFlags.ConflictPolicy)
+			if err != nil {
+				cmd.SilenceUsage = false
+				return &UsageError{err}
+			}
+			repo, err := repository.NewRepository(ctx, repository.RepositoryConfig{
+				FilePath:       lookupFlags.FilePath,
+				DynamoTable:    lookupFlags.DynamoTable,
+				DynamoEndpoint: lookupFlags.DynamoEndpoint,
+			})
+			if err != nil {
+				return err
+			}
+			detector = conflict.NewDetector(dnsService, repo, policy)
+		}
+
 		// Process each domain
 		for _, domain := range domains {
 			fmt.Printf("Domain: %s\n", domain)
@@ -50,6 +89,29 @@ For each domain, this command will:
 				}
 			}
 
+			policy, err := dnsService.FindPolicy(domain)
+			if err != nil {
+				fmt.Printf("  Policy error: %v\n", err)
+			} else if policy == nil {
+				fmt.Println("  Policy: none (any owner may claim)")
+			} else {
+				fmt.Printf("  Policy: %s allows %d owner(s):\n", policy.RecordName(), len(policy.OwnerHashes))
+				for _, ownerHash := range policy.OwnerHashes {
+					fmt.Printf("    %s\n", ownerHash)
+				}
+			}
+
+			if detector != nil {
+				report, err := detector.Inspect(ctx, domain)
+				if err != nil {
+					fmt.Printf("  Conflict check error: %v\n", err)
+				} else {
+					for _, line := range strings.Split(report.String(), "\n") {
+						fmt.Printf("  %s\n", line)
+					}
+				}
+			}
+
 			// Add blank line between domains for readability (except after last one)
 			if domain != domains[len(domains)-1] {
 				fmt.Println()
@@ -62,4 +124,8 @@ For each domain, this command will:
 
 func init() {
 	lookupCmd.Flags().StringVarP(&resolverAddr, "resolver", "r", "1.1.1.1:53", "DNS resolver address (host:port)")
+	lookupCmd.Flags().StringVarP(&lookupFlags.FilePath, "file", "f", "", "Path to JSON file of stored records, to check for ownership conflicts")
+	lookupCmd.Flags().StringVarP(&lookupFlags.DynamoTable, "dynamodb-table", "t", "", "DynamoDB table of stored records, to check for ownership conflicts")
+	lookupCmd.Flags().StringVarP(&lookupFlags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
+	lookupCmd.Flags().StringVar(&lookupFlags.ConflictPolicy, "conflict-policy", string(conflict.DefaultPolicy), "How to resolve conflicts: first-come or require-release")
 }
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/usecase/plan"
+	"github.com/spf13/cobra"
+)
+
+var planFlags struct {
+	Format   string
+	TTL      int
+	Resolver string
+}
+
+var planCmd = &cobra.Command{
+	Use:           "plan <owner> <type> <domain1> [domain2]...",
+	Short:         "Print the DNS changes needed for a group to attest",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Plan prints the _suns TXT records to publish for a group, ready to apply with your DNS host.
+
+It calculates the expected group ID, looks up the records already published for each domain,
+and prints only the delta: domains that already publish the group ID are skipped.
+If a domain's _suns label is a CNAME, the record is planned at the CNAME target.
+
+Formats:
+  zonefile       BIND zone file lines
+  route53-json   A Route 53 change batch per zone, keyed by zone name; pass each batch to
+                 aws route53 change-resource-record-sets --change-batch
+  terraform      aws_route53_record resources
+  octodns        octoDNS records, per zone file
+  bind-nsupdate  nsupdate commands
+
+Route 53, Terraform and octoDNS replace every value at a name,
+so their output keeps the values already published there.
+Changes are grouped by registrable domain, which is usually the zone to change.
+
+Example:
+  symval plan alice@example.com mirrornames example.com com.example
+  symval plan alice@example.com palindrome aba.example --format route53-json --ttl 60`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner := args[0]
+		symmetryType, err := parseTypeArg(cmd, args[1])
+		if err != nil {
+			return err
+		}
+
+		format, err := plan.ParseFormat(planFlags.Format)
+		if err != nil {
+			cmd.SilenceUsage = false
+			return &UsageError{err}
+		}
+		if planFlags.TTL <= 0 {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("--ttl must be positive, got %d", planFlags.TTL)}
+		}
+
+		domains, err := parseHostnameArgs(cmd, args[2:])
+		if err != nil {
+			return err
+		}
+
+		dnsService := dnsclaims.NewService()
+		if planFlags.Resolver != "" {
+			dnsService = dnsclaims.NewServiceWithResolver(dnsclaims.NewCustomResolver(planFlags.Resolver))
+		}
+
+		planUseCase := plan.NewPlanUseCase(dnsService)
+		planUseCase.SetTTL(planFlags.TTL)
+
+		result, err := planUseCase.Plan(owner, symmetryType, domains)
+		if err != nil {
+			return ExitWithCode(1, fmt.Errorf("planning failed: %w", err))
+		}
+
+		// Notes go to stderr, so that stdout can be applied directly
+		fmt.Fprintf(os.Stderr, "Expected Group ID: %s\n", result.ExpectedID)
+		for _, domain := range result.Published {
+			fmt.Fprintf(os.Stderr, "Already published for %s\n", domain)
+		}
+		if len(result.Changes) == 0 {
+			fmt.Fprintln(os.Stderr, "No changes needed.")
+			return nil
+		}
+
+		output, err := result.Render(format)
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVar(&planFlags.Format, "format", string(plan.FormatZoneFile), "Output format: zonefile, route53-json, terraform, octodns, or bind-nsupdate")
+	planCmd.Flags().IntVar(&planFlags.TTL, "ttl", plan.DefaultTTL, "TTL in seconds for new records")
+	planCmd.Flags().StringVarP(&planFlags.Resolver, "resolver", "r", "", "DNS resolver address (host:port); uses the system resolver if not specified")
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/mrled/suns/symval/internal/adapter/dnsserver"
+	"github.com/mrled/suns/symval/internal/service/punyzone"
+	"github.com/spf13/cobra"
+)
+
+var punyzoneFlags struct {
+	Zone       string
+	DNSListen  string
+	HTTPListen string
+	IPv4       string
+	IPv6       string
+	TTL        uint32
+}
+
+var punyzoneCmd = &cobra.Command{
+	Use:           "punyzone",
+	Short:         "Run a wildcard DNS zone and web page for testing how IDN names render",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Run a lightweight authoritative DNS responder that answers every name under a zone,
+including xn-- (punycode) labels, with fixed A/AAAA records.
+
+Alongside it, run an HTTP server that describes the requested Host:
+its decoded Unicode form, its UTS-46 mapping, and any homograph warnings.
+Point the A/AAAA records at the HTTP server to see how browsers render IDN names.
+Requests with "Accept: application/json" receive a JSON report.
+
+Either server can be disabled by passing an empty listen address.
+--zone is only needed for the DNS responder.
+
+Examples:
+  # Serve test.example locally
+  symval punyzone --zone test.example --ipv4 127.0.0.1 --ipv6 ::1
+
+  # Inspect a punycode name without a browser
+  curl -H 'Host: xn--80ak6aa92e.test.example' http://127.0.0.1:8053/`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := punyzone.Config{TTL: punyzoneFlags.TTL}
+		if punyzoneFlags.IPv4 != "" {
+			cfg.IPv4 = net.ParseIP(punyzoneFlags.IPv4).To4()
+			if cfg.IPv4 == nil {
+				cmd.SilenceUsage = false
+				return &UsageError{fmt.Errorf("invalid IPv4 address: %s", punyzoneFlags.IPv4)}
+			}
+		}
+		if punyzoneFlags.IPv6 != "" {
+			cfg.IPv6 = net.ParseIP(punyzoneFlags.IPv6)
+			if cfg.IPv6 == nil || cfg.IPv6.To4() != nil {
+				cmd.SilenceUsage = false
+				return &UsageError{fmt.Errorf("invalid IPv6 address: %s", punyzoneFlags.IPv6)}
+			}
+		}
+		if punyzoneFlags.DNSListen == "" && punyzoneFlags.HTTPListen == "" {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("at least one of --dns-listen or --http-listen is required")}
+		}
+		if punyzoneFlags.DNSListen != "" && punyzoneFlags.Zone == "" {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("--zone is required with --dns-listen")}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		errs := make(chan error, 2)
+
+		if punyzoneFlags.DNSListen != "" {
+			server := dnsserver.New(punyzoneFlags.Zone, punyzone.NewDNSHandler(cfg))
+			fmt.Printf("Serving zone %s on %s (udp)\n", server.Zone(), punyzoneFlags.DNSListen)
+			go func() {
+				errs <- server.ListenAndServe(ctx, punyzoneFlags.DNSListen)
+			}()
+		}
+
+		if punyzoneFlags.HTTPListen != "" {
+			httpServer := &http.Server{Addr: punyzoneFlags.HTTPListen, Handler: punyzone.NewHTTPHandler()}
+			fmt.Printf("Serving IDN report page on http://%s/\n", punyzoneFlags.HTTPListen)
+			go func() {
+				<-ctx.Done()
+				httpServer.Close()
+			}()
+			go func() {
+				if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errs <- err
+					return
+				}
+				errs <- nil
+			}()
+		}
+
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	},
+}
+
+func init() {
+	punyzoneCmd.Flags().StringVarP(&punyzoneFlags.Zone, "zone", "z", "", "Zone the DNS responder is authoritative for (required with --dns-listen)")
+	punyzoneCmd.Flags().StringVar(&punyzoneFlags.DNSListen, "dns-listen", "127.0.0.1:5353", "UDP address for the DNS responder (host:port, empty to disable)")
+	punyzoneCmd.Flags().StringVar(&punyzoneFlags.HTTPListen, "http-listen", "127.0.0.1:8053", "TCP address for the HTTP report page (host:port, empty to disable)")
+	punyzoneCmd.Flags().StringVar(&punyzoneFlags.IPv4, "ipv4", "", "Address returned for A queries (optional)")
+	punyzoneCmd.Flags().StringVar(&punyzoneFlags.IPv6, "ipv6", "", "Address returned for AAAA queries (optional)")
+	punyzoneCmd.Flags().Uint32Var(&punyzoneFlags.TTL, "ttl", 300, "TTL for A/AAAA answers, in seconds")
+}
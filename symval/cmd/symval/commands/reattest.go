@@ -3,16 +3,38 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/repository"
 	"github.com/mrled/suns/symval/internal/repository/memrepo"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
 	"github.com/mrled/suns/symval/internal/usecase/reattest"
 	"github.com/spf13/cobra"
 )
 
-var reattestFlags PersistenceFlags
+var reattestFlags struct {
+	PersistenceFlags
+	ArchiveFlags
+	ArchiveRetention time.Duration
+	Workers          int
+	LookupWorkers    int
+	MaxLookups       int
+	NameserverRate   float64
+	All              bool
+	BreakerThreshold float64
+	StateFile        string
+	Schedule         reattest.Schedule
+	Retention        string
+	GracePeriod      int
+	MinFailures      int
+	Owners           []string
+	Domains          []string
+	GroupIDs         []string
+}
 
 var reattestCmd = &cobra.Command{
 	Use:           "reattest",
@@ -28,18 +50,70 @@ checks stored records without querying DNS, 'reattest' performs a full DNS looku
 and validation for each group.
 
 For valid groups, the validation timestamp is updated. For invalid groups, they are
-removed from the data store only after a grace period (default 72 hours) has elapsed
-since the last successful validation. Use --dry-run to see what would happen without
+removed from the data store only after a grace period (--grace-period, default 72
+hours) has elapsed since the last successful validation, and, if --min-failures is
+set, after failing that many checks in a row. A group is only invalid if DNS answered and
+its records are missing or changed; groups whose lookups failed (timeouts, SERVFAIL)
+are indeterminate and never removed. If more than --breaker-threshold of the groups
+are indeterminate, the resolver is presumed broken: the run stops, and nothing is
+removed. Use --dry-run to see what would happen without
 making any changes.
 
 Invalid groups are always printed in both regular and dry-run modes.
 
+Only groups that are due are re-attested. A group is due again --healthy-interval
+after it passes; a failing group is retried after --retry-interval, doubling with
+each consecutive failure up to --max-backoff. Intervals are randomly adjusted by
+up to --jitter (a fraction), to spread lookups out. When each group is next due is
+kept in a state file (--state-file, by default next to --file); new groups and
+groups without a state file are always due. Use --all to re-attest every group
+regardless, and --dry-run always checks every group.
+
+A --retention policy can set different rules per symmetry type: rules separated by
+semicolons, each a comma-separated list of hours=N and failures=N, optionally
+starting with a type and a colon. For example,
+"hours=72;mirrornames:hours=24,failures=3". It defaults to the
+REATTEST_RETENTION environment variable, as in the batch Lambda. --grace-period
+and --min-failures override the default rule of the policy.
+
+Removed groups are moved to an archive (--archive-file, by default next to --file,
+or the DynamoDB table --archive-table) for --archive-retention, from where
+'symval archive restore' can return them once their DNS is fixed.
+
+You can limit re-attestation to some groups using the following flags:
+  --owner, -o    : Filter by owner(s)
+  --domain, -n   : Filter by domain name(s)
+  --groupid, -g  : Filter by group ID(s)
+
+As with 'revalidate', a group is re-attested whole if any of its records match,
+so filtering by domain re-attests every group that the domain belongs to.
+A filtered run re-attests every matching group whether or not it is due, and
+does not move the regular pass along.
+
+Groups are re-attested concurrently (--workers), as are the domains within each
+group (--lookup-workers). However many workers there are, at most --max-lookups
+DNS lookups are in flight at once, and lookups toward any one nameserver are
+spaced out to --nameserver-rate per second. Results are always printed in group
+ID order.
+
 Examples:
   # Re-attest all groups, update valid ones, and remove invalid ones past grace period
   symval reattest --file ./data.json
 
   # Dry run to see what would happen
-  symval reattest --file ./data.json --dry-run`,
+  symval reattest --file ./data.json --dry-run
+
+  # Re-attest every group, even those not yet due
+  symval reattest --file ./data.json --all
+
+  # Re-attest the groups of one domain right away
+  symval reattest --file ./data.json --domain example.com
+
+  # Remove groups only after a week and 5 failed checks in a row
+  symval reattest --file ./data.json --grace-period 168 --min-failures 5
+
+  # Re-attest more groups at once, with gentler lookups
+  symval reattest --file ./data.json --workers 16 --nameserver-rate 5`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -70,16 +144,69 @@ Examples:
 			fmt.Println("Using in-memory storage (no persistence)")
 		}
 
-		// Create DNS service
-		dnsService := dnsclaims.NewService()
+		// Create DNS service, limited so that concurrent groups do not flood any nameserver
+		dnsService := dnsclaims.NewLimitedService(dnsclaims.Limits{
+			Concurrency:    reattestFlags.MaxLookups,
+			NameserverRate: reattestFlags.NameserverRate,
+		})
 
 		// Create reattest use case
 		reattestUC := reattest.NewReattestUseCase(dnsService, repo)
+		reattestUC.SetWorkers(reattestFlags.Workers, reattestFlags.LookupWorkers)
+		reattestUC.SetCircuitBreaker(reattestFlags.BreakerThreshold, reattest.DefaultBreakerMinGroups)
+
+		// Decide when invalid groups are removed
+		retention, err := reattest.ParseRetentionPolicy(reattestFlags.Retention)
+		if err != nil {
+			if !cmd.Flags().Changed("retention") {
+				return fmt.Errorf("invalid REATTEST_RETENTION: %w", err)
+			}
+			return fmt.Errorf("invalid --retention: %w", err)
+		}
+		if cmd.Flags().Changed("grace-period") {
+			retention.Default.GracePeriod = time.Duration(reattestFlags.GracePeriod) * time.Hour
+		}
+		if cmd.Flags().Changed("min-failures") {
+			retention.Default.MinFailures = reattestFlags.MinFailures
+		}
+		reattestUC.SetRetentionPolicy(retention)
+
+		// Re-attest only the matching groups, if any filters are given
+		reattestUC.SetFilter(model.RecordFilter{
+			Owners:   reattestFlags.Owners,
+			Domains:  reattestFlags.Domains,
+			GroupIDs: reattestFlags.GroupIDs,
+		})
+
+		// Keep when each group is next due in the state file, if there is one
+		stateFile := reattestFlags.StateFile
+		if stateFile == "" && reattestFlags.FilePath != "" {
+			stateFile = strings.TrimSuffix(reattestFlags.FilePath, ".json") + ".reattest-state.json"
+		}
+		if stateFile != "" && !reattestFlags.DryRun {
+			stateStore, err := memrepo.NewMemoryStateStoreWithPersistence(stateFile)
+			if err != nil {
+				return fmt.Errorf("failed to open state file: %w", err)
+			}
+			reattestUC.SetStateStore(stateStore)
+			fmt.Printf("Using reattest state: %s\n", stateFile)
+		}
+		// Archive removed groups, so that they can be restored if their owners fix DNS
+		if archiveCfg, ok := archiveConfig(reattestFlags.PersistenceFlags, reattestFlags.ArchiveFlags); ok && !reattestFlags.DryRun {
+			archiveRepo, err := repository.NewArchiveRepository(ctx, archiveCfg)
+			if err != nil {
+				return err
+			}
+			reattestUC.SetArchive(archiveRepo, reattestFlags.ArchiveRetention)
+		}
+		if !reattestFlags.All {
+			schedule := reattestFlags.Schedule
+			reattestUC.SetSchedule(&schedule)
+		}
 
 		// Perform re-attestation
 		var results []reattest.GroupAttestResult
 		var stats reattest.UpdateStats
-		var err error
 
 		if reattestFlags.DryRun {
 			fmt.Println("\n--- DRY RUN MODE (no changes will be made) ---")
@@ -92,11 +219,16 @@ Examples:
 			if err != nil {
 				return fmt.Errorf("re-attestation failed: %w", err)
 			}
+			if stats.GroupsNotDue > 0 {
+				fmt.Printf("\nSkipped %d group(s) that are not due yet (use --all to re-attest them)\n", stats.GroupsNotDue)
+			}
 			// Log statistics if applicable
-			if stats.RecordsUpdated > 0 || stats.RecordsDeleted > 0 {
+			if stats.RecordsUpdated > 0 || stats.RecordsFailed > 0 || stats.RecordsDeleted > 0 {
 				fmt.Printf("\nUpdate Statistics:\n")
 				fmt.Printf("  Records Updated: %d\n", stats.RecordsUpdated)
+				fmt.Printf("  Records Failing: %d\n", stats.RecordsFailed)
 				fmt.Printf("  Records Deleted: %d\n", stats.RecordsDeleted)
+				fmt.Printf("  Records Archived: %d\n", stats.RecordsArchived)
 				fmt.Printf("  Records Skipped: %d\n", stats.RecordsSkipped)
 				if stats.Errors > 0 {
 					fmt.Printf("  Errors: %d\n", stats.Errors)
@@ -114,14 +246,24 @@ Examples:
 
 		validCount := 0
 		invalidCount := 0
+		indeterminateCount := 0
+		removedCount := 0
 
 		for i, result := range results {
-			status := "✓ VALID"
-			if !result.IsValid {
+			var status string
+			switch result.Outcome {
+			case reattest.OutcomeValid:
+				status = "✓ VALID"
+				validCount++
+			case reattest.OutcomeIndeterminate:
+				status = "? INDETERMINATE"
+				indeterminateCount++
+			default:
 				status = "✗ INVALID"
 				invalidCount++
-			} else {
-				validCount++
+			}
+			if result.Removed {
+				removedCount++
 			}
 
 			fmt.Printf("%d. [%s] Group\n", i+1, status)
@@ -136,13 +278,19 @@ Examples:
 		}
 
 		// Print summary
-		fmt.Printf("Summary: %d valid, %d invalid\n", validCount, invalidCount)
+		fmt.Printf("Summary: %d valid, %d invalid, %d indeterminate\n", validCount, invalidCount, indeterminateCount)
+		if indeterminateCount > 0 {
+			fmt.Printf("(Indeterminate groups could not be checked because DNS lookups failed; they are never removed)\n")
+		}
 
 		if !reattestFlags.DryRun {
-			if invalidCount > 0 && stats.RecordsDeleted > 0 {
-				fmt.Printf("✓ Removed %d invalid group(s) (that exceeded grace period)\n", stats.RecordsDeleted)
+			if stats.CircuitOpen {
+				fmt.Printf("✗ Stopped by the circuit breaker (%s); no groups were removed\n", stats.StopReason)
+			}
+			if removedCount > 0 {
+				fmt.Printf("✓ Removed %d invalid group(s) (that the retention policy expired: %s)\n", removedCount, retention)
 			}
-			if reattestFlags.FilePath != "" && (stats.RecordsUpdated > 0 || stats.RecordsDeleted > 0) {
+			if reattestFlags.FilePath != "" && (stats.RecordsUpdated > 0 || stats.RecordsFailed > 0 || stats.RecordsDeleted > 0) {
 				fmt.Printf("Changes persisted to: %s\n", reattestFlags.FilePath)
 			}
 		} else {
@@ -156,5 +304,24 @@ Examples:
 }
 
 func init() {
-	addPersistenceFlags(reattestCmd, &reattestFlags)
+	addPersistenceFlags(reattestCmd, &reattestFlags.PersistenceFlags)
+	reattestCmd.Flags().IntVar(&reattestFlags.Workers, "workers", reattest.DefaultWorkers, "Groups re-attested at once")
+	reattestCmd.Flags().IntVar(&reattestFlags.LookupWorkers, "lookup-workers", attestation.DefaultLookupWorkers, "Domains of one group looked up at once")
+	reattestCmd.Flags().IntVar(&reattestFlags.MaxLookups, "max-lookups", dnsclaims.DefaultConcurrency, "Most DNS lookups in flight at once (0 for unlimited)")
+	reattestCmd.Flags().Float64Var(&reattestFlags.BreakerThreshold, "breaker-threshold", reattest.DefaultBreakerThreshold, "Fraction of indeterminate groups that stops the run without removing anything (0 to disable)")
+	reattestCmd.Flags().BoolVar(&reattestFlags.All, "all", false, "Re-attest every group, even those not due yet")
+	reattestCmd.Flags().StringVar(&reattestFlags.StateFile, "state-file", "", "Where to keep when each group is next due (default: next to --file)")
+	reattestCmd.Flags().DurationVar(&reattestFlags.Schedule.HealthyInterval, "healthy-interval", reattest.DefaultHealthyInterval, "How long after passing a group is due again")
+	reattestCmd.Flags().DurationVar(&reattestFlags.Schedule.RetryInterval, "retry-interval", reattest.DefaultRetryInterval, "How long after its first failure a group is retried; doubles with each further failure")
+	reattestCmd.Flags().DurationVar(&reattestFlags.Schedule.MaxBackoff, "max-backoff", reattest.DefaultMaxBackoff, "Longest wait between retries of a failing group")
+	reattestCmd.Flags().Float64Var(&reattestFlags.Schedule.Jitter, "jitter", reattest.DefaultJitter, "Fraction by which intervals are randomly adjusted")
+	reattestCmd.Flags().StringSliceVarP(&reattestFlags.Owners, "owner", "o", []string{}, "Filter by owner (can be repeated)")
+	reattestCmd.Flags().StringSliceVarP(&reattestFlags.Domains, "domain", "n", []string{}, "Filter by domain name (can be repeated)")
+	reattestCmd.Flags().StringSliceVarP(&reattestFlags.GroupIDs, "groupid", "g", []string{}, "Filter by group ID (can be repeated)")
+	reattestCmd.Flags().StringVar(&reattestFlags.Retention, "retention", os.Getenv("REATTEST_RETENTION"), "Retention policy for invalid groups, e.g. \"hours=72;mirrornames:hours=24,failures=3\"")
+	reattestCmd.Flags().IntVar(&reattestFlags.GracePeriod, "grace-period", int(reattest.DefaultGracePeriod.Hours()), "Hours since an invalid group last passed before it is removed")
+	addArchiveFlags(reattestCmd, &reattestFlags.ArchiveFlags)
+	reattestCmd.Flags().DurationVar(&reattestFlags.ArchiveRetention, "archive-retention", reattest.DefaultArchiveRetention, "How long removed groups are kept in the archive")
+	reattestCmd.Flags().IntVar(&reattestFlags.MinFailures, "min-failures", 0, "Failed checks in a row before an invalid group is removed (0 for any)")
+	reattestCmd.Flags().Float64Var(&reattestFlags.NameserverRate, "nameserver-rate", dnsclaims.DefaultNameserverRate, "Most DNS lookups per second toward one nameserver (0 for unlimited)")
 }
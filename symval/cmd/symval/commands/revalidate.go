@@ -146,10 +146,11 @@ Examples:
 			}
 		}
 
-		// Create a map of invalid records for quick lookup with reasons
+		// Create a map of invalid records for quick lookup with reasons.
+		// Records are keyed by group and hostname, because a hostname can belong to several groups.
 		invalidMap := make(map[string]string)
 		for _, info := range invalidRecords {
-			invalidMap[info.Record.Hostname] = info.Reason
+			invalidMap[info.Record.GroupID+"#"+info.Record.Hostname] = info.Reason
 		}
 
 		// Print status of all records
@@ -160,7 +161,7 @@ Examples:
 
 		for i, record := range candidateRecords {
 			status := "✓ VALID"
-			reason, isInvalid := invalidMap[record.Hostname]
+			reason, isInvalid := invalidMap[record.GroupID+"#"+record.Hostname]
 			if isInvalid {
 				status = "✗ INVALID"
 				invalidCount++
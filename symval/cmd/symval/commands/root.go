@@ -31,6 +31,13 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(revalidateCmd)
 	rootCmd.AddCommand(attestCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(withdrawCmd)
 	rootCmd.AddCommand(reattestCmd)
+	rootCmd.AddCommand(archiveCmd)
 	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(dnsgameCmd)
+	rootCmd.AddCommand(punyzoneCmd)
 }
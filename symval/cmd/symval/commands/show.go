@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/mrled/suns/symval/internal/model"
@@ -51,7 +52,10 @@ Examples:
   symval show --file ./data.json --sort validate-time
 
   # Show records in compact format
-  symval show --file ./data.json --format compact`,
+  symval show --file ./data.json --format compact
+
+  # Show records grouped by registrable domain (eTLD+1)
+  symval show --file ./data.json --format registrable`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -97,6 +101,8 @@ Examples:
 		switch showFlags.Format {
 		case "compact":
 			displayRecordsCompact(filteredRecords)
+		case "registrable":
+			displayRecordsByRegistrableDomain(filteredRecords)
 		default: // "detailed" or empty
 			displayRecordsDetailed(filteredRecords)
 		}
@@ -129,6 +135,9 @@ func displayRecordsDetailed(records []*model.DomainRecord) {
 	// Group records by GroupID for better display
 	grouped := model.GroupByGroupID(records)
 
+	// A hostname can belong to several groups; note the others next to it
+	byHostname := model.GroupByHostname(records)
+
 	for groupID, groupRecords := range grouped {
 		fmt.Printf("\nGroup ID: %s\n", groupID)
 		fmt.Printf("Type: %s\n", groupRecords[0].Type)
@@ -138,10 +147,63 @@ func displayRecordsDetailed(records []*model.DomainRecord) {
 		for _, record := range groupRecords {
 			timeStr := presenter.FormatTimeSince(record.ValidateTime)
 
-			fmt.Printf("  - %s (validated: %s, rev: %d)\n",
-				record.Hostname,
+			hostname := record.Hostname
+			if display := record.DisplayHostname(); display != hostname {
+				hostname = fmt.Sprintf("%s [%s, idn: %s]", display, record.Hostname, record.Safety())
+			}
+
+			var others string
+			if count := len(byHostname[record.Hostname]) - 1; count > 0 {
+				others = fmt.Sprintf(", also in %d other group(s)", count)
+			}
+
+			fmt.Printf("  - %s (validated: %s, rev: %d%s)\n",
+				hostname,
 				timeStr,
-				record.Rev)
+				record.Rev,
+				others)
+
+			if !record.FirstAttestedTime.IsZero() {
+				fmt.Printf("      first attested: %s\n", presenter.FormatTimeSince(record.FirstAttestedTime))
+			}
+			if !record.LastCheckTime.IsZero() {
+				fmt.Printf("      last checked: %s\n", presenter.FormatTimeSince(record.LastCheckTime))
+			}
+			if record.ConsecutiveFailures > 0 {
+				fmt.Printf("      failing: %d consecutive check(s), last %s: %s\n",
+					record.ConsecutiveFailures,
+					presenter.FormatTimeSince(record.LastFailureTime),
+					record.LastFailureReason)
+			} else if !record.LastFailureTime.IsZero() {
+				fmt.Printf("      last failed: %s: %s\n", presenter.FormatTimeSince(record.LastFailureTime), record.LastFailureReason)
+			}
+		}
+	}
+}
+
+// displayRecordsByRegistrableDomain displays records grouped by registrable domain (eTLD+1)
+func displayRecordsByRegistrableDomain(records []*model.DomainRecord) {
+	fmt.Println("\n=== Domain Records by Registrable Domain ===")
+
+	grouped := model.GroupByRegistrableDomain(records)
+	registrables := make([]string, 0, len(grouped))
+	for registrable := range grouped {
+		registrables = append(registrables, registrable)
+	}
+	sort.Strings(registrables)
+
+	for _, registrable := range registrables {
+		domainRecords := grouped[registrable]
+		groups := model.GroupByGroupID(domainRecords)
+		names := model.GroupByHostname(domainRecords)
+		fmt.Printf("\n%s (%d name(s), %d group(s))\n", registrable, len(names), len(groups))
+
+		for _, record := range domainRecords {
+			fmt.Printf("  - %-38s %-20s %s (%s)\n",
+				record.ListedHostname(),
+				record.Type,
+				truncateString(record.GroupID, 13),
+				record.Owner)
 		}
 	}
 }
@@ -156,7 +218,7 @@ func displayRecordsCompact(records []*model.DomainRecord) {
 		timeStr := presenter.FormatTimeSinceCompact(record.ValidateTime)
 
 		// Truncate long fields for compact display
-		domain := truncateString(record.Hostname, 38)
+		domain := truncateString(record.ListedHostname(), 38)
 		owner := truncateString(record.Owner, 28)
 		groupID := truncateString(record.GroupID, 13)
 
@@ -192,6 +254,6 @@ func init() {
 	showCmd.Flags().StringVarP(&showFlags.Domain, "domain", "d", "", "Filter by domain name")
 
 	// Add format and sort flags
-	showCmd.Flags().StringVar(&showFlags.Format, "format", "detailed", "Output format: detailed or compact")
+	showCmd.Flags().StringVar(&showFlags.Format, "format", "detailed", "Output format: detailed, compact, or registrable")
 	showCmd.Flags().StringVar(&showFlags.SortBy, "sort", "", "Sort by: owner, domain, group, validate-time, or type")
 }
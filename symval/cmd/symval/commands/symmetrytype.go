@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/spf13/cobra"
+)
+
+// parseTypeArg parses a symmetry type argument, either a type name or a type code,
+// returning a usage error that lists the valid types
+func parseTypeArg(cmd *cobra.Command, arg string) (symgroup.SymmetryType, error) {
+	symmetryType, ok := symgroup.ParseType(arg)
+	if !ok {
+		cmd.SilenceUsage = false
+		return "", &UsageError{fmt.Errorf("invalid symmetry type: %s\n%s", arg, symgroup.ValidSymmetryTypesText())}
+	}
+	return symmetryType, nil
+}
@@ -30,7 +30,6 @@ Arguments:
 		owner := args[0]
 		typeName := strings.ToLower(args[1])
 		groupID := args[2]
-		hostnames := args[3:]
 
 		// Convert type name to code
 		typeCode, ok := symgroup.TypeNameToCode[typeName]
@@ -39,6 +38,11 @@ Arguments:
 			return fmt.Errorf("invalid type %q, must be one of: %s", args[1], getAvailableTypes())
 		}
 
+		hostnames, err := parseHostnameArgs(cmd, args[3:])
+		if err != nil {
+			return err
+		}
+
 		dataList := make([]*model.DomainRecord, 0, len(hostnames))
 		validateTime := time.Now()
 
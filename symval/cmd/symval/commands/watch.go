@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/apiclient"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/usecase/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchFlags struct {
+	PersistenceFlags
+	AttestPolicyFlags
+	Resolvers   []string
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Timeout     time.Duration
+	Remote      bool
+	APIURL      string
+}
+
+var watchCmd = &cobra.Command{
+	Use:           "watch <owner> <type> <domain1> [domain2]...",
+	Short:         "Wait for _suns records to propagate, then attest",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Watch polls the _suns TXT records of a group until they propagate, then attests the group.
+
+Attesting right after publishing a record often fails because resolvers still cache the old answer.
+Watch calculates the expected group ID and polls _suns.<domain> for each domain on every resolver
+given with --resolver (or the system resolver), starting at --interval and doubling the delay
+after each poll up to --max-interval. Once every resolver returns the expected group ID
+for every domain, it runs the attestation.
+
+By default the attestation runs locally, like "symval attest", storing the result in
+the data store given with --file or --dynamodb-table, if any, and enforcing the same
+--conflict-policy and --max-groups-per-domain.
+With --remote, it posts to the attestation API instead (see --api-url), which enforces its own policies.
+
+Watch gives up after --timeout.
+
+Example:
+  symval watch alice@example.com palindrome aba.example --remote
+  symval watch alice@example.com mirrornames example.com com.example --resolver 1.1.1.1:53 --resolver 8.8.8.8:53 --timeout 30m`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		owner := args[0]
+		symmetryType, err := parseTypeArg(cmd, args[1])
+		if err != nil {
+			return err
+		}
+
+		if watchFlags.Interval <= 0 || watchFlags.MaxInterval <= 0 || watchFlags.Timeout <= 0 {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("--interval, --max-interval and --timeout must be positive")}
+		}
+		if watchFlags.Remote && (watchFlags.FilePath != "" || watchFlags.DynamoTable != "") {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("--remote cannot be combined with --file or --dynamodb-table")}
+		}
+		if watchFlags.Remote && (cmd.Flags().Changed("conflict-policy") || cmd.Flags().Changed("max-groups-per-domain")) {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("--remote cannot be combined with --conflict-policy or --max-groups-per-domain")}
+		}
+
+		domains, err := parseHostnameArgs(cmd, args[2:])
+		if err != nil {
+			return err
+		}
+
+		expectedID, err := groupid.CalculateV1(owner, string(symmetryType), domains)
+		if err != nil {
+			return fmt.Errorf("failed to calculate group ID: %w", err)
+		}
+
+		// Set up the local attestation before waiting, so that bad flags fail fast
+		var attestUseCase *attestation.AttestationUseCase
+		if !watchFlags.Remote {
+			attestUseCase, err = newAttestUseCase(ctx, cmd, watchFlags.PersistenceFlags, watchFlags.AttestPolicyFlags)
+			if err != nil {
+				return err
+			}
+		}
+
+		var resolvers []watch.Resolver
+		for _, addr := range watchFlags.Resolvers {
+			resolvers = append(resolvers, watch.Resolver{
+				Name:    addr,
+				Service: dnsclaims.NewServiceWithResolver(dnsclaims.NewCustomResolver(addr)),
+			})
+		}
+		if len(resolvers) == 0 {
+			resolvers = append(resolvers, watch.Resolver{Name: "system", Service: dnsclaims.NewService()})
+		}
+
+		watcher := watch.NewWatcher(resolvers)
+		watcher.SetInterval(watchFlags.Interval)
+		watcher.SetMaxInterval(watchFlags.MaxInterval)
+		watcher.SetTimeout(watchFlags.Timeout)
+
+		fmt.Printf("Expected Group ID: %s\n", expectedID)
+		fmt.Printf("Waiting up to %s for %d domain(s) on %d resolver(s)\n\n", watchFlags.Timeout, len(domains), len(resolvers))
+
+		_, err = watcher.Wait(ctx, expectedID, domains, printWatchProgress)
+		if errors.Is(err, watch.ErrTimeout) {
+			return ExitWithCode(1, fmt.Errorf("records did not propagate within %s; run symval doctor to check them", watchFlags.Timeout))
+		} else if err != nil {
+			return ExitWithCode(1, err)
+		}
+
+		fmt.Println("\nAll records propagated.")
+
+		if watchFlags.Remote {
+			return attestRemotely(ctx, owner, string(symmetryType), domains)
+		}
+		return attestLocally(attestUseCase, owner, symmetryType, domains)
+	},
+}
+
+// printWatchProgress prints one line per poll, marking each domain and resolver that has the expected record
+func printWatchProgress(p *watch.Progress) {
+	var parts []string
+	for _, d := range p.Domains {
+		var marks []string
+		for _, r := range d.Resolvers {
+			mark := "…"
+			if r.Seen {
+				mark = "✓"
+			} else if r.Error != "" {
+				mark = "!"
+			}
+			marks = append(marks, fmt.Sprintf("%s %s", r.Resolver, mark))
+		}
+		parts = append(parts, fmt.Sprintf("%s [%s]", d.Hostname, strings.Join(marks, ", ")))
+	}
+
+	line := fmt.Sprintf("[%3d %8s] %s", p.Attempt, p.Elapsed.Round(time.Second), strings.Join(parts, "  "))
+	if p.NextPoll > 0 {
+		line += fmt.Sprintf("  (next poll in %s)", p.NextPoll)
+	}
+	fmt.Println(line)
+}
+
+// attestLocally runs the attestation use case, like the attest command
+func attestLocally(attestUseCase *attestation.AttestationUseCase, owner string, symmetryType symgroup.SymmetryType, domains []string) error {
+	result, err := attestUseCase.Attest(owner, symmetryType, domains)
+	if err != nil {
+		return ExitWithCode(1, fmt.Errorf("attestation failed: %w", err))
+	}
+
+	if !result.IsValid {
+		fmt.Println("\n✗ Attestation FAILED")
+		fmt.Printf("Reason: %s\n", result.ErrorMessage)
+		if len(result.Diagnostics) > 0 {
+			fmt.Println("\nDiagnostics by domain:")
+			printDiagnostics(result.Diagnostics)
+		}
+		return ExitWithCode(1, fmt.Errorf("attestation failed"))
+	}
+
+	fmt.Println("\n✓ Attestation PASSED")
+	if watchFlags.DynamoTable != "" {
+		fmt.Printf("Results persisted to DynamoDB table: %s\n", watchFlags.DynamoTable)
+	} else if watchFlags.FilePath != "" {
+		fmt.Printf("Results persisted to: %s\n", watchFlags.FilePath)
+	}
+	return nil
+}
+
+// attestRemotely posts the attestation request to the API
+func attestRemotely(ctx context.Context, owner, typeCode string, domains []string) error {
+	fmt.Printf("Posting to %s\n", watchFlags.APIURL)
+
+	response, err := apiclient.NewClient().Attest(ctx, watchFlags.APIURL, apitypes.AttestRequest{
+		Owner:   owner,
+		Type:    typeCode,
+		Domains: domains,
+	})
+	if err != nil {
+		return ExitWithCode(1, fmt.Errorf("attestation failed: %w", err))
+	}
+
+	if !response.IsValid {
+		fmt.Println("\n✗ Attestation FAILED")
+		fmt.Printf("Reason: %s\n", response.ErrorMessage)
+		for _, d := range response.Diagnostics {
+			if d.Problem != "" {
+				fmt.Printf("  %s: %s\n", d.Hostname, d.Problem)
+			}
+		}
+		return ExitWithCode(1, fmt.Errorf("attestation failed"))
+	}
+
+	fmt.Println("\n✓ Attestation PASSED")
+	fmt.Println(response.Message)
+	return nil
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&watchFlags.FilePath, "file", "f", "", "Path to JSON file for persistence, when attesting locally")
+	watchCmd.Flags().StringVarP(&watchFlags.DynamoTable, "dynamodb-table", "t", "", "DynamoDB table name for persistence, when attesting locally")
+	watchCmd.Flags().StringVarP(&watchFlags.DynamoEndpoint, "dynamodb-endpoint", "e", "", "DynamoDB endpoint URL (optional, uses AWS SDK default if not specified)")
+	watchCmd.Flags().StringArrayVar(&watchFlags.Resolvers, "resolver", nil, "DNS resolver address (host:port) to poll; may be repeated (default: the system resolver)")
+	watchCmd.Flags().DurationVar(&watchFlags.Interval, "interval", watch.DefaultInterval, "Delay before the second poll; doubles after each poll")
+	watchCmd.Flags().DurationVar(&watchFlags.MaxInterval, "max-interval", watch.DefaultMaxInterval, "Longest delay between polls")
+	watchCmd.Flags().DurationVar(&watchFlags.Timeout, "timeout", watch.DefaultTimeout, "How long to wait for propagation before giving up")
+	watchCmd.Flags().BoolVar(&watchFlags.Remote, "remote", false, "Attest by posting to the API instead of locally")
+	watchCmd.Flags().StringVar(&watchFlags.APIURL, "api-url", apiclient.DefaultAttestURL, "Attestation API endpoint used with --remote")
+	addAttestPolicyFlags(watchCmd, &watchFlags.AttestPolicyFlags)
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mrled/suns/symval/internal/apiclient"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/usecase/withdraw"
+	"github.com/spf13/cobra"
+)
+
+var withdrawFlags struct {
+	PersistenceFlags
+	Remote bool
+	APIURL string
+}
+
+var withdrawCmd = &cobra.Command{
+	Use:           "withdraw <group-id>",
+	Short:         "Remove a group whose _suns records have been withdrawn",
+	GroupID:       "attestation",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Long: `Withdraw removes a group from the data store without waiting for reattestation.
+
+Reattestation only removes a group after its records have been invalid for the grace period.
+Withdraw removes it immediately, once every domain in the group has withdrawn:
+its _suns TXT records no longer include the group ID, or they include the withdrawal marker
+
+  withdraw:<group-id>
+
+Publishing the marker is useful when the group ID record cannot be removed right away,
+or to withdraw while caches still hold the old record.
+A domain whose records cannot be looked up has not withdrawn, so DNS outages never remove a group.
+
+The group's records are removed only if they have not changed since they were read,
+and the removal reaches the public list through the DynamoDB stream like any other change.
+
+By default the group is removed from the data store given with --file or --dynamodb-table.
+With --remote, the request is posted to the withdrawal API instead (see --api-url).
+
+Use "symval groupid" to calculate the group ID.
+
+Example:
+  symval withdraw 'v1:m:...:...' --remote
+  symval withdraw 'v1:m:...:...' --file ./data.json --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		groupID := args[0]
+
+		if _, err := groupid.ParseGroupIDv1(groupID); err != nil {
+			cmd.SilenceUsage = false
+			return &UsageError{err}
+		}
+
+		if withdrawFlags.Remote {
+			if withdrawFlags.FilePath != "" || withdrawFlags.DynamoTable != "" || withdrawFlags.DryRun {
+				cmd.SilenceUsage = false
+				return &UsageError{fmt.Errorf("--remote cannot be combined with --file, --dynamodb-table or --dry-run")}
+			}
+			return withdrawRemotely(ctx, groupID)
+		}
+
+		if withdrawFlags.FilePath == "" && withdrawFlags.DynamoTable == "" {
+			cmd.SilenceUsage = false
+			return &UsageError{fmt.Errorf("one of --file, --dynamodb-table or --remote is required")}
+		}
+
+		repo, err := repository.NewRepository(ctx, repository.RepositoryConfig{
+			FilePath:       withdrawFlags.FilePath,
+			DynamoTable:    withdrawFlags.DynamoTable,
+			DynamoEndpoint: withdrawFlags.DynamoEndpoint,
+		})
+		if err != nil {
+			return err
+		}
+
+		withdrawUC := withdraw.NewWithdrawUseCase(dnsclaims.NewService(), repo)
+		withdrawUC.SetDryRun(withdrawFlags.DryRun)
+
+		result, err := withdrawUC.Withdraw(ctx, groupID)
+		if errors.Is(err, model.ErrNotFound) {
+			return ExitWithCode(1, fmt.Errorf("group %s not found in the data store", groupID))
+		} else if err != nil {
+			return ExitWithCode(1, fmt.Errorf("withdrawal failed: %w", err))
+		}
+
+		fmt.Printf("Group ID: %s\n", result.GroupID)
+		fmt.Printf("Owner: %s\n", result.Owner)
+		fmt.Printf("Type: %s\n\n", result.Type)
+		for _, d := range result.Domains {
+			switch {
+			case d.Marker:
+				fmt.Printf("  ✓ %s (withdrawal marker)\n", d.Hostname)
+			case d.Withdrawn:
+				fmt.Printf("  ✓ %s (group ID removed)\n", d.Hostname)
+			default:
+				fmt.Printf("  ✗ %s: %s\n", d.Hostname, d.Reason)
+			}
+		}
+
+		if !result.IsWithdrawn {
+			fmt.Println("\n✗ Withdrawal FAILED")
+			return ExitWithCode(1, fmt.Errorf("withdrawal failed"))
+		}
+
+		if withdrawFlags.DryRun {
+			fmt.Println("\n✓ Every domain has withdrawn (no changes made - dry run)")
+			return nil
+		}
+
+		fmt.Printf("\n✓ Withdrawal PASSED: removed %d record(s)\n", result.Deleted)
+		if result.Skipped > 0 {
+			fmt.Printf("  Skipped %d record(s) that changed during withdrawal\n", result.Skipped)
+		}
+		return nil
+	},
+}
+
+// withdrawRemotely posts the withdrawal request to the API
+func withdrawRemotely(ctx context.Context, groupID string) error {
+	fmt.Printf("Posting to %s\n", withdrawFlags.APIURL)
+
+	response, err := apiclient.NewClient().Withdraw(ctx, withdrawFlags.APIURL, apitypes.WithdrawRequest{GroupID: groupID})
+	if err != nil {
+		return ExitWithCode(1, fmt.Errorf("withdrawal failed: %w", err))
+	}
+
+	for _, d := range response.Domains {
+		if d.Withdrawn {
+			fmt.Printf("  ✓ %s\n", d.Hostname)
+		} else {
+			fmt.Printf("  ✗ %s: %s\n", d.Hostname, d.Reason)
+		}
+	}
+
+	if !response.IsWithdrawn {
+		fmt.Println("\n✗ Withdrawal FAILED")
+		return ExitWithCode(1, fmt.Errorf("withdrawal failed"))
+	}
+
+	fmt.Printf("\n✓ %s\n", response.Message)
+	return nil
+}
+
+func init() {
+	addPersistenceFlags(withdrawCmd, &withdrawFlags.PersistenceFlags)
+	withdrawCmd.Flags().BoolVar(&withdrawFlags.Remote, "remote", false, "Withdraw by posting to the API instead of changing a local data store")
+	withdrawCmd.Flags().StringVar(&withdrawFlags.APIURL, "api-url", apiclient.DefaultWithdrawURL, "Withdrawal API endpoint used with --remote")
+}
@@ -1,6 +1,6 @@
 module github.com/mrled/suns/symval
 
-go 1.23
+go 1.23.0
 
 require (
 	github.com/aws/aws-lambda-go v1.47.0
@@ -9,7 +9,9 @@ require (
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.20.19
 	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.52.2
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.89.1
+	github.com/mtibben/confusables v0.0.0-20210201002637-9d1b0723b659
 	github.com/spf13/cobra v1.10.1
+	golang.org/x/net v0.38.0
 )
 
 require (
@@ -32,4 +34,5 @@ require (
 	github.com/aws/smithy-go v1.23.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
@@ -0,0 +1,276 @@
+package dnsserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// maxUDPSize is the largest response we send over UDP without EDNS0
+	maxUDPSize = 512
+
+	// maxTXTStringLen is the maximum length of a single character-string in a TXT record
+	maxTXTStringLen = 255
+
+	// DefaultMaxInFlight is the default number of queries handled at once
+	DefaultMaxInFlight = 32
+)
+
+// Handler answers a single DNS question for a name inside the server's zone.
+// It returns the response code and any answer records.
+// Returning dnsmessage.RCodeSuccess with no answers produces a NODATA response.
+type Handler interface {
+	ServeDNS(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource)
+}
+
+// HandlerFunc adapts an ordinary function to the Handler interface
+type HandlerFunc func(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource)
+
+// ServeDNS calls f(ctx, q)
+func (f HandlerFunc) ServeDNS(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+	return f(ctx, q)
+}
+
+// Server is a minimal authoritative DNS server for a single zone over UDP.
+// Queries for names outside the zone are refused.
+type Server struct {
+	zone     string
+	handler  Handler
+	logger   *slog.Logger
+	inFlight chan struct{} // Semaphore bounding the queries handled at once
+}
+
+// New creates a server that is authoritative for zone and delegates
+// every in-zone question to handler
+func New(zone string, handler Handler) *Server {
+	return &Server{
+		zone:     CanonicalName(zone),
+		handler:  handler,
+		logger:   slog.Default().With("component", "dnsserver", "zone", CanonicalName(zone)),
+		inFlight: make(chan struct{}, DefaultMaxInFlight),
+	}
+}
+
+// SetMaxInFlight sets how many queries are handled at once.
+// Queries that arrive while that many are in flight are dropped, and clients retry.
+// It must be called before Serve.
+func (s *Server) SetMaxInFlight(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.inFlight = make(chan struct{}, n)
+}
+
+// Zone returns the canonical (lowercase, fully qualified) zone name
+func (s *Server) Zone() string {
+	return s.zone
+}
+
+// ListenAndServe listens on the UDP address addr and serves queries until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, conn)
+}
+
+// Serve reads queries from conn and answers each one in its own goroutine,
+// dropping queries while the maximum number are already in flight (see SetMaxInFlight).
+// It closes conn and returns nil when ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	s.logger.Info("DNS server listening", slog.String("addr", conn.LocalAddr().String()))
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to read query: %w", err)
+		}
+
+		// Handlers may do a lot of work for one query, so a flood of queries must not start unbounded work.
+		// Dropping is safer than answering, since the source address of a UDP query may be spoofed.
+		select {
+		case s.inFlight <- struct{}{}:
+		default:
+			s.logger.Debug("Dropping query, too many in flight", slog.String("client", addr.String()))
+			continue
+		}
+
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+
+		go func() {
+			defer func() { <-s.inFlight }()
+			resp, err := s.HandlePacket(ctx, packet)
+			if err != nil {
+				s.logger.Debug("Dropping unparseable query",
+					slog.String("client", addr.String()),
+					slog.String("error", err.Error()))
+				return
+			}
+			if _, err := conn.WriteTo(resp, addr); err != nil {
+				s.logger.Warn("Failed to write response",
+					slog.String("client", addr.String()),
+					slog.String("error", err.Error()))
+			}
+		}()
+	}
+}
+
+// HandlePacket parses a raw DNS query and returns the packed response.
+// An error is returned only when the query cannot be parsed at all,
+// in which case no response should be sent.
+func (s *Server) HandlePacket(ctx context.Context, packet []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               header.ID,
+			Response:         true,
+			OpCode:           header.OpCode,
+			RecursionDesired: header.RecursionDesired,
+		},
+	}
+
+	if header.Response || header.OpCode != 0 {
+		resp.Header.RCode = dnsmessage.RCodeNotImplemented
+		return resp.Pack()
+	}
+
+	questions, err := parser.AllQuestions()
+	if err != nil || len(questions) != 1 {
+		resp.Header.RCode = dnsmessage.RCodeFormatError
+		return resp.Pack()
+	}
+	q := questions[0]
+	resp.Questions = questions
+
+	name := CanonicalName(q.Name.String())
+	if !InZone(name, s.zone) {
+		resp.Header.RCode = dnsmessage.RCodeRefused
+		return resp.Pack()
+	}
+
+	rcode, answers := s.handler.ServeDNS(ctx, q)
+	resp.Header.Authoritative = true
+	resp.Header.RCode = rcode
+	resp.Answers = answers
+
+	s.logger.Debug("Answered query",
+		slog.String("name", name),
+		slog.String("type", q.Type.String()),
+		slog.String("rcode", rcode.String()),
+		slog.Int("answers", len(answers)))
+
+	packed, err := resp.Pack()
+	if err != nil {
+		resp.Header.RCode = dnsmessage.RCodeServerFailure
+		resp.Answers = nil
+		return resp.Pack()
+	}
+
+	// Without TCP support, the best we can do for oversized answers is to signal truncation
+	if len(packed) > maxUDPSize {
+		resp.Header.Truncated = true
+		resp.Answers = nil
+		return resp.Pack()
+	}
+
+	return packed, nil
+}
+
+// CanonicalName lowercases name and ensures it ends with a trailing dot
+func CanonicalName(name string) string {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+// InZone reports whether the canonical name is zone itself or a name below it
+func InZone(name, zone string) bool {
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// TrimZone returns the labels of name that precede zone, without a trailing dot.
+// It returns an empty string for the zone apex.
+// Both arguments must be canonical.
+func TrimZone(name, zone string) string {
+	if name == zone {
+		return ""
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// TXTResource builds a TXT answer for name.
+// Strings longer than 255 bytes are split across several character-strings.
+func TXTResource(name dnsmessage.Name, ttl uint32, txt ...string) dnsmessage.Resource {
+	var chunks []string
+	for _, s := range txt {
+		for len(s) > maxTXTStringLen {
+			chunks = append(chunks, s[:maxTXTStringLen])
+			s = s[maxTXTStringLen:]
+		}
+		chunks = append(chunks, s)
+	}
+
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  name,
+			Type:  dnsmessage.TypeTXT,
+			Class: dnsmessage.ClassINET,
+			TTL:   ttl,
+		},
+		Body: &dnsmessage.TXTResource{TXT: chunks},
+	}
+}
+
+// AResource builds an A answer for name
+func AResource(name dnsmessage.Name, ttl uint32, ip net.IP) dnsmessage.Resource {
+	var a [4]byte
+	copy(a[:], ip.To4())
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  name,
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+			TTL:   ttl,
+		},
+		Body: &dnsmessage.AResource{A: a},
+	}
+}
+
+// AAAAResource builds an AAAA answer for name
+func AAAAResource(name dnsmessage.Name, ttl uint32, ip net.IP) dnsmessage.Resource {
+	var aaaa [16]byte
+	copy(aaaa[:], ip.To16())
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  name,
+			Type:  dnsmessage.TypeAAAA,
+			Class: dnsmessage.ClassINET,
+			TTL:   ttl,
+		},
+		Body: &dnsmessage.AAAAResource{AAAA: aaaa},
+	}
+}
@@ -0,0 +1,219 @@
+package dnsserver
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// echoHandler answers TXT queries with the query name and nothing else
+var echoHandler = HandlerFunc(func(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+	if q.Type != dnsmessage.TypeTXT {
+		return dnsmessage.RCodeSuccess, nil
+	}
+	return dnsmessage.RCodeSuccess, []dnsmessage.Resource{TXTResource(q.Name, 0, q.Name.String())}
+})
+
+// buildQuery packs a single-question query
+func buildQuery(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 42, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack query: %v", err)
+	}
+	return packed
+}
+
+// parseResponse unpacks a response message
+func parseResponse(t *testing.T, packed []byte) dnsmessage.Message {
+	t.Helper()
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packed); err != nil {
+		t.Fatalf("failed to unpack response: %v", err)
+	}
+	return msg
+}
+
+func TestHandlePacket(t *testing.T) {
+	server := New("Claim.Example", echoHandler)
+	ctx := context.Background()
+
+	t.Run("in-zone TXT query is answered authoritatively", func(t *testing.T) {
+		resp, err := server.HandlePacket(ctx, buildQuery(t, "abc.claim.example.", dnsmessage.TypeTXT))
+		if err != nil {
+			t.Fatalf("HandlePacket() error = %v", err)
+		}
+		msg := parseResponse(t, resp)
+		if msg.Header.ID != 42 || !msg.Header.Response || !msg.Header.Authoritative {
+			t.Errorf("unexpected header: %+v", msg.Header)
+		}
+		if msg.Header.RCode != dnsmessage.RCodeSuccess {
+			t.Errorf("RCode = %v, want success", msg.Header.RCode)
+		}
+		if len(msg.Answers) != 1 {
+			t.Fatalf("got %d answers, want 1", len(msg.Answers))
+		}
+		txt := msg.Answers[0].Body.(*dnsmessage.TXTResource)
+		if txt.TXT[0] != "abc.claim.example." {
+			t.Errorf("TXT = %v", txt.TXT)
+		}
+	})
+
+	t.Run("mixed-case names are matched case-insensitively", func(t *testing.T) {
+		resp, err := server.HandlePacket(ctx, buildQuery(t, "ABC.cLaIm.example.", dnsmessage.TypeTXT))
+		if err != nil {
+			t.Fatalf("HandlePacket() error = %v", err)
+		}
+		if msg := parseResponse(t, resp); msg.Header.RCode != dnsmessage.RCodeSuccess {
+			t.Errorf("RCode = %v, want success", msg.Header.RCode)
+		}
+	})
+
+	t.Run("out-of-zone query is refused", func(t *testing.T) {
+		resp, err := server.HandlePacket(ctx, buildQuery(t, "example.com.", dnsmessage.TypeTXT))
+		if err != nil {
+			t.Fatalf("HandlePacket() error = %v", err)
+		}
+		msg := parseResponse(t, resp)
+		if msg.Header.RCode != dnsmessage.RCodeRefused || len(msg.Answers) != 0 {
+			t.Errorf("got rcode %v with %d answers, want refused", msg.Header.RCode, len(msg.Answers))
+		}
+	})
+
+	t.Run("suffix that is not a label boundary is refused", func(t *testing.T) {
+		resp, _ := server.HandlePacket(ctx, buildQuery(t, "notclaim.example.", dnsmessage.TypeTXT))
+		if msg := parseResponse(t, resp); msg.Header.RCode != dnsmessage.RCodeRefused {
+			t.Errorf("RCode = %v, want refused", msg.Header.RCode)
+		}
+	})
+
+	t.Run("garbage is dropped", func(t *testing.T) {
+		if _, err := server.HandlePacket(ctx, []byte{1, 2, 3}); err == nil {
+			t.Error("expected error for garbage packet")
+		}
+	})
+}
+
+func TestTXTResourceSplitsLongStrings(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	res := TXTResource(dnsmessage.MustNewName("x.example."), 0, long)
+	txt := res.Body.(*dnsmessage.TXTResource).TXT
+	if len(txt) != 2 || len(txt[0]) != 255 || len(txt[1]) != 45 {
+		t.Errorf("unexpected split: %d strings", len(txt))
+	}
+}
+
+func TestServeUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New("claim.example", echoHandler).Serve(ctx, conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to dial server: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Write(buildQuery(t, "hello.claim.example.", dnsmessage.TypeTXT)); err != nil {
+		t.Fatalf("failed to send query: %v", err)
+	}
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 512)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if msg := parseResponse(t, buf[:n]); len(msg.Answers) != 1 {
+		t.Errorf("got %d answers, want 1", len(msg.Answers))
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() returned %v after cancel, want nil", err)
+	}
+}
+
+func TestServeDropsQueriesOverMaxInFlight(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+
+	// The handler blocks until released, so the first query stays in flight
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	blockingHandler := HandlerFunc(func(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+		started <- struct{}{}
+		<-release
+		return echoHandler(ctx, q)
+	})
+
+	server := New("claim.example", blockingHandler)
+	server.SetMaxInFlight(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to dial server: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Write(buildQuery(t, "first.claim.example.", dnsmessage.TypeTXT)); err != nil {
+		t.Fatalf("failed to send query: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first query was not handled")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.Write(buildQuery(t, "second.claim.example.", dnsmessage.TypeTXT)); err != nil {
+			t.Fatalf("failed to send query: %v", err)
+		}
+	}
+
+	// Give the server time to read (and drop) the extra queries, then let the first one finish
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 512)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if msg := parseResponse(t, buf[:n]); len(msg.Answers) != 1 || !strings.Contains(msg.Answers[0].Body.GoString(), "first") {
+		t.Errorf("got %+v, want the answer to the first query", msg.Answers)
+	}
+	if len(started) != 0 {
+		t.Errorf("%d queries over the limit were handled, want none", len(started))
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() returned %v after cancel, want nil", err)
+	}
+}
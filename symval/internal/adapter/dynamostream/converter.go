@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
@@ -28,6 +29,21 @@ func ConvertToDomainRecord(newImage map[string]events.DynamoDBAttributeValue) (*
 		domainRecord.Hostname = sk.String()
 	}
 
+	// UnicodeHostname - optional, absent on records stored before it was introduced
+	if unicodeHostname, ok := newImage["UnicodeHostname"]; ok && unicodeHostname.DataType() == events.DataTypeString {
+		domainRecord.UnicodeHostname = unicodeHostname.String()
+	}
+
+	// IDNSafety - optional, absent on records stored before it was introduced
+	if idnSafety, ok := newImage["IDNSafety"]; ok && idnSafety.DataType() == events.DataTypeString {
+		domainRecord.IDNSafety = idn.Verdict(idnSafety.String())
+	}
+
+	// RegistrableDomain - optional, absent on records stored before it was introduced
+	if registrableDomain, ok := newImage["RegistrableDomain"]; ok && registrableDomain.DataType() == events.DataTypeString {
+		domainRecord.RegistrableDomain = registrableDomain.String()
+	}
+
 	// Owner - required
 	if owner, ok := newImage["Owner"]; ok && owner.DataType() == events.DataTypeString {
 		domainRecord.Owner = owner.String()
@@ -53,6 +69,29 @@ func ConvertToDomainRecord(newImage map[string]events.DynamoDBAttributeValue) (*
 		return nil, fmt.Errorf("missing required field: ValidateTime")
 	}
 
+	// Check history - optional, absent on records stored before it was introduced
+	for key, field := range map[string]*time.Time{
+		"FirstAttestedTime": &domainRecord.FirstAttestedTime,
+		"LastCheckTime":     &domainRecord.LastCheckTime,
+		"LastFailureTime":   &domainRecord.LastFailureTime,
+	} {
+		if attr, ok := newImage[key]; ok && attr.DataType() == events.DataTypeString {
+			t, err := time.Parse(time.RFC3339, attr.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s format: %w", key, err)
+			}
+			*field = t
+		}
+	}
+	if failures, ok := newImage["ConsecutiveFailures"]; ok && failures.DataType() == events.DataTypeNumber {
+		n, err := failures.Integer()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ConsecutiveFailures: %w", err)
+		}
+		domainRecord.ConsecutiveFailures = int(n)
+	}
+	domainRecord.LastFailureReason = ExtractStringAttribute(newImage, "LastFailureReason")
+
 	// Validate we have the primary key fields
 	if domainRecord.GroupID == "" {
 		return nil, fmt.Errorf("missing required field: GroupID (pk)")
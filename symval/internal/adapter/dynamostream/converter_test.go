@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
 
@@ -58,6 +59,81 @@ func TestConvertToDomainRecord(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "IDN fields present",
+			fixture: `{
+				"eventID": "1",
+				"eventName": "INSERT",
+				"dynamodb": {
+					"NewImage": {
+						"pk": { "S": "grp-123" },
+						"sk": { "S": "xn--s-k2a.su" },
+						"UnicodeHostname": { "S": "ɯs.su" },
+						"IDNSafety": { "S": "punycode" },
+						"Owner": { "S": "alice@example.com" },
+						"Type": { "S": "d" },
+						"ValidateTime": { "S": "2025-10-30T12:34:56Z" }
+					}
+				}
+			}`,
+			wantErr: false,
+			validate: func(t *testing.T, record *events.DynamoDBEventRecord) {
+				result, err := ConvertToDomainRecord(record.Change.NewImage)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if result.UnicodeHostname != "ɯs.su" {
+					t.Errorf("UnicodeHostname = %q, want %q", result.UnicodeHostname, "ɯs.su")
+				}
+				if result.IDNSafety != idn.VerdictPunycode {
+					t.Errorf("IDNSafety = %q, want %q", result.IDNSafety, idn.VerdictPunycode)
+				}
+			},
+		},
+		{
+			name: "check history present",
+			fixture: `{
+				"eventID": "1",
+				"eventName": "MODIFY",
+				"dynamodb": {
+					"NewImage": {
+						"pk": { "S": "grp-123" },
+						"sk": { "S": "host.example.com" },
+						"Owner": { "S": "alice@example.com" },
+						"Type": { "S": "a" },
+						"ValidateTime": { "S": "2025-10-30T12:34:56Z" },
+						"FirstAttestedTime": { "S": "2025-10-01T00:00:00Z" },
+						"LastCheckTime": { "S": "2025-10-31T08:00:00.123456789Z" },
+						"LastFailureTime": { "S": "2025-10-31T08:00:00.123456789Z" },
+						"ConsecutiveFailures": { "N": "2" },
+						"LastFailureReason": { "S": "domain host.example.com: no record" }
+					}
+				}
+			}`,
+			wantErr: false,
+			validate: func(t *testing.T, record *events.DynamoDBEventRecord) {
+				result, err := ConvertToDomainRecord(record.Change.NewImage)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				firstAttested, _ := time.Parse(time.RFC3339, "2025-10-01T00:00:00Z")
+				if !result.FirstAttestedTime.Equal(firstAttested) {
+					t.Errorf("FirstAttestedTime = %v, want %v", result.FirstAttestedTime, firstAttested)
+				}
+				lastCheck, _ := time.Parse(time.RFC3339Nano, "2025-10-31T08:00:00.123456789Z")
+				if !result.LastCheckTime.Equal(lastCheck) || !result.LastFailureTime.Equal(lastCheck) {
+					t.Errorf("LastCheckTime = %v, LastFailureTime = %v, want %v", result.LastCheckTime, result.LastFailureTime, lastCheck)
+				}
+				if result.ConsecutiveFailures != 2 {
+					t.Errorf("ConsecutiveFailures = %d, want 2", result.ConsecutiveFailures)
+				}
+				if result.LastFailureReason != "domain host.example.com: no record" {
+					t.Errorf("LastFailureReason = %q", result.LastFailureReason)
+				}
+			},
+		},
 		{
 			name: "missing Owner field - should fail",
 			fixture: `{
@@ -0,0 +1,80 @@
+package s3materializedview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// DefaultStateKey is where reattest state is kept in the bucket by default, apart from the public records
+const DefaultStateKey = "state/reattest.json"
+
+// StateStore keeps reattest state in a small JSON object in S3.
+// It implements model.ReattestStateStore.
+type StateStore struct {
+	s3Client   *s3.Client
+	bucketName string
+	key        string
+}
+
+// NewStateStore creates a new StateStore adapter
+func NewStateStore(s3Client *s3.Client, bucketName, key string) *StateStore {
+	return &StateStore{
+		s3Client:   s3Client,
+		bucketName: bucketName,
+		key:        key,
+	}
+}
+
+// LoadReattestState loads the state from S3, returning an empty state if the object does not exist yet
+func (s *StateStore) LoadReattestState(ctx context.Context) (*model.ReattestState, error) {
+	result, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucketName,
+		Key:    &s.key,
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return &model.ReattestState{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get state object from S3: %w", err)
+	}
+	defer result.Body.Close()
+
+	bodyBytes, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 state object body: %w", err)
+	}
+
+	var state model.ReattestState
+	if err := json.Unmarshal(bodyBytes, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse reattest state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveReattestState saves the state to S3
+func (s *StateStore) SaveReattestState(ctx context.Context, state *model.ReattestState) error {
+	jsonData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal reattest state: %w", err)
+	}
+
+	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       &s.bucketName,
+		Key:          &s.key,
+		Body:         bytes.NewReader(jsonData),
+		ContentType:  stringPtr("application/json"),
+		CacheControl: stringPtr("no-cache"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload state to S3: %w", err)
+	}
+	return nil
+}
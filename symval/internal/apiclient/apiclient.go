@@ -0,0 +1,94 @@
+// Package apiclient calls the SUNS HTTP API, for CLI commands that attest remotely
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/apitypes"
+)
+
+const (
+	// DefaultAttestURL is the public attestation endpoint
+	DefaultAttestURL = "https://zq.suns.bz/api/v1/attest"
+
+	// DefaultWithdrawURL is the public withdrawal endpoint
+	DefaultWithdrawURL = "https://zq.suns.bz/api/v1/withdraw"
+)
+
+// Client posts requests to the SUNS HTTP API
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new API client
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Attest posts an attestation request to url, which should be a /v1/attest endpoint.
+// Responses with a non-200 status are returned as errors, using the error message from the body if there is one.
+func (c *Client) Attest(ctx context.Context, url string, request apitypes.AttestRequest) (*apitypes.AttestResponse, error) {
+	var response apitypes.AttestResponse
+	if err := c.post(ctx, url, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Withdraw posts a withdrawal request to url, which should be a /v1/withdraw endpoint.
+// Responses with a non-200 status are returned as errors, using the error message from the body if there is one.
+func (c *Client) Withdraw(ctx context.Context, url string, request apitypes.WithdrawRequest) (*apitypes.WithdrawResponse, error) {
+	var response apitypes.WithdrawResponse
+	if err := c.post(ctx, url, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// post posts request as JSON to url and parses the JSON response into response
+func (c *Client) post(ctx context.Context, url string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", url, err)
+	}
+	defer httpResponse.Body.Close()
+
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		var errorBody struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(responseBody, &errorBody) == nil && errorBody.Error != "" {
+			return fmt.Errorf("%s returned %d: %s", url, httpResponse.StatusCode, errorBody.Error)
+		}
+		return fmt.Errorf("%s returned %d: %s", url, httpResponse.StatusCode, responseBody)
+	}
+
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrled/suns/symval/internal/apitypes"
+)
+
+func TestAttest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var request apitypes.AttestRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if request.Owner == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"owner field is required"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(apitypes.AttestResponse{IsValid: true, ExpectedID: "v1:a:owner:domains"})
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	ctx := context.Background()
+
+	response, err := client.Attest(ctx, server.URL, apitypes.AttestRequest{Owner: "alice", Type: "a", Domains: []string{"aba.example"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !response.IsValid || response.ExpectedID != "v1:a:owner:domains" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	_, err = client.Attest(ctx, server.URL, apitypes.AttestRequest{Type: "a", Domains: []string{"aba.example"}})
+	if err == nil || !strings.Contains(err.Error(), "owner field is required") {
+		t.Errorf("expected the API error message, got %v", err)
+	}
+}
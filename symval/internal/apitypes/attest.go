@@ -0,0 +1,68 @@
+// Package apitypes holds the JSON request and response types of the SUNS HTTP API,
+// shared by the API handler and the clients that call it
+package apitypes
+
+// AttestRequest represents the expected JSON payload for attestation
+type AttestRequest struct {
+	Owner   string   `json:"owner"`
+	Type    string   `json:"type"`
+	Domains []string `json:"domains"`
+}
+
+// AttestResponse represents the JSON response for attestation
+type AttestResponse struct {
+	IsValid      bool   `json:"isValid"`
+	ExpectedID   string `json:"expectedId"`
+	GroupIDCount int    `json:"groupIdCount"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	Message      string `json:"message,omitempty"`
+
+	// Persisted is true only if the group's records were stored; it is always false for dry runs
+	Persisted bool `json:"persisted"`
+	DryRun    bool `json:"dryRun,omitempty"`
+
+	Domains []AttestDomain `json:"domains,omitempty"`
+
+	// ConflictReport describes hostnames already attested by another owner, if any
+	ConflictReport string `json:"conflictReport,omitempty"`
+
+	// Diagnostics describes what DNS returned for each domain, so that every problem is reported at once
+	Diagnostics []DomainDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// DomainDiagnostic describes the DNS records found for one domain in an AttestResponse
+type DomainDiagnostic struct {
+	Hostname      string             `json:"hostname"`
+	Label         string             `json:"label"`
+	CNAMEPath     []string           `json:"cnamePath,omitempty"`
+	ResolverError string             `json:"resolverError,omitempty"`
+	Records       []RecordDiagnostic `json:"records"`
+	Problem       string             `json:"problem,omitempty"`
+}
+
+// RecordDiagnostic describes one TXT record in a DomainDiagnostic
+type RecordDiagnostic struct {
+	Value     string `json:"value"`
+	Type      string `json:"type,omitempty"`
+	OwnerHash string `json:"ownerHash,omitempty"`
+	Rejected  string `json:"rejected,omitempty"` // unparseable, type, owner or group-id
+}
+
+// AttestDomain describes one attested domain in an AttestResponse
+type AttestDomain struct {
+	Hostname        string `json:"hostname"`
+	UnicodeHostname string `json:"unicodeHostname"`
+	IDNSafety       string `json:"idnSafety"`
+}
+
+// InvalidDomain describes a domain in an AttestRequest that is not a valid hostname
+type InvalidDomain struct {
+	Domain string `json:"domain"`
+	Error  string `json:"error"`
+}
+
+// InvalidDomainsResponse is the 400 response body for invalid requests, listing any invalid hostnames
+type InvalidDomainsResponse struct {
+	Error          string          `json:"error"`
+	InvalidDomains []InvalidDomain `json:"invalidDomains,omitempty"`
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+// BatchAttestResponse represents the JSON response for batch attestation
+type BatchAttestResponse struct {
+	Valid   int               `json:"valid"`   // Items that attested
+	Invalid int               `json:"invalid"` // Items that were checked and failed attestation
+	Errors  int               `json:"errors"`  // Items that were rejected or could not be checked
+	DryRun  bool              `json:"dryRun,omitempty"`
+	Results []BatchAttestItem `json:"results"`
+}
+
+// BatchAttestItem is the result for one group in a BatchAttestResponse, in request order
+type BatchAttestItem struct {
+	Index int `json:"index"`
+
+	// Status is the HTTP status the item would have had as its own /v1/attest request:
+	// 200 if it was checked (whether or not it is valid), 400 if it was invalid, or 500
+	Status int `json:"status"`
+
+	Error          string          `json:"error,omitempty"`
+	InvalidDomains []InvalidDomain `json:"invalidDomains,omitempty"`
+	Result         *AttestResponse `json:"result,omitempty"`
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+import "time"
+
+// GroupResponse represents the JSON response for an attested group
+type GroupResponse struct {
+	GroupID string        `json:"groupId"`
+	Owner   string        `json:"owner"`
+	Type    string        `json:"type"`
+	Records []GroupRecord `json:"records"`
+}
+
+// GroupRecord describes the check history of one domain in a GroupResponse
+type GroupRecord struct {
+	Hostname            string     `json:"hostname"`
+	UnicodeHostname     string     `json:"unicodeHostname"`
+	Validated           time.Time  `json:"validated"`
+	FirstAttested       *time.Time `json:"firstAttested,omitempty"`
+	LastChecked         *time.Time `json:"lastChecked,omitempty"`
+	LastFailure         *time.Time `json:"lastFailure,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastFailureReason   string     `json:"lastFailureReason,omitempty"`
+}
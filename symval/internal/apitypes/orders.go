@@ -0,0 +1,27 @@
+package apitypes
+
+import "time"
+
+// OrderResponse represents the JSON response for an attestation order
+type OrderResponse struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"` // pending, valid or expired
+	Owner      string          `json:"owner"`
+	Type       string          `json:"type"`
+	Domains    []string        `json:"domains"`
+	ExpectedID string          `json:"expectedId"`
+	Records    []OrderRecord   `json:"records"`
+	Created    time.Time       `json:"created"`
+	Expires    time.Time       `json:"expires"`
+	Finalized  *time.Time      `json:"finalized,omitempty"`
+	LastError  string          `json:"lastError,omitempty"` // Why the last finalize attempt failed
+	Attest     *AttestResponse `json:"attestation,omitempty"`
+}
+
+// OrderRecord is a TXT record to create for an order
+type OrderRecord struct {
+	Hostname string `json:"hostname"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+}
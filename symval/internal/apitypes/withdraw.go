@@ -0,0 +1,25 @@
+package apitypes
+
+// WithdrawRequest represents the expected JSON payload for withdrawal
+type WithdrawRequest struct {
+	GroupID string `json:"groupId"`
+}
+
+// WithdrawResponse represents the JSON response for withdrawal
+type WithdrawResponse struct {
+	GroupID      string           `json:"groupId"`
+	IsWithdrawn  bool             `json:"isWithdrawn"`
+	ErrorMessage string           `json:"errorMessage,omitempty"`
+	Message      string           `json:"message,omitempty"`
+	Deleted      int              `json:"deleted"`
+	Skipped      int              `json:"skipped"` // Records that changed while withdrawing, and were left in place
+	Domains      []WithdrawDomain `json:"domains"`
+}
+
+// WithdrawDomain describes whether one domain in a WithdrawResponse has withdrawn
+type WithdrawDomain struct {
+	Hostname  string `json:"hostname"`
+	Withdrawn bool   `json:"withdrawn"`
+	Marker    bool   `json:"marker,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
@@ -11,6 +11,9 @@ import (
 const (
 	// IDVersion is the current version of the group ID algorithm
 	IDVersion = "v1"
+
+	// WithdrawalPrefix starts a _suns TXT record that withdraws a group from membership
+	WithdrawalPrefix = "withdraw:"
 )
 
 // GroupIDV1 represents a parsed v1 group ID
@@ -27,6 +30,11 @@ func (g GroupIDV1) String() string {
 	return g.Raw
 }
 
+// WithdrawalMarker returns the TXT record value that withdraws groupID, like "withdraw:v1:p:...:..."
+func WithdrawalMarker(groupID string) string {
+	return WithdrawalPrefix + groupID
+}
+
 // ParseGroupIDv1 parses a raw group ID string into a GroupIDV1 struct.
 // The expected format is: v1:typecode:ownerhash:domainshash
 // Returns an error if the format is invalid or the version is not v1.
@@ -67,6 +75,12 @@ func ParseGroupIDv1Slice(records []string) ([]GroupIDV1, error) {
 	return groupIDs, nil
 }
 
+// OwnerHashV1 returns the owner hash used in v1 group IDs: base64(sha256(owner))
+func OwnerHashV1(owner string) string {
+	ownerHash := sha256.Sum256([]byte(owner))
+	return base64.StdEncoding.EncodeToString(ownerHash[:])
+}
+
 // CalculateV1 generates a group ID by hashing owner and hostnames separately
 // The result is formatted as: idversion:type:base64(sha256(owner)):base64(sha256(sort(hostnames))).
 func CalculateV1(owner, gtype string, hostnames []string) (string, error) {
@@ -86,8 +100,7 @@ func CalculateV1(owner, gtype string, hostnames []string) (string, error) {
 	sort.Strings(sorted)
 
 	// Hash the owner
-	ownerHash := sha256.Sum256([]byte(owner))
-	ownerEncoded := base64.StdEncoding.EncodeToString(ownerHash[:])
+	ownerEncoded := OwnerHashV1(owner)
 
 	// Build the string to hash: all sorted hostnames
 	var builder strings.Builder
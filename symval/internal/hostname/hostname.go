@@ -0,0 +1,153 @@
+// Package hostname parses and canonicalizes hostnames before they are hashed or looked up
+package hostname
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mrled/suns/symval/internal/idn"
+)
+
+const (
+	// MaxLabelLength is the maximum length of a single label in octets (RFC 1035 section 2.3.4)
+	MaxLabelLength = 63
+
+	// MaxLength is the maximum length of a hostname in octets, without the trailing dot
+	MaxLength = 253
+)
+
+// SyntaxError describes why a hostname could not be parsed
+type SyntaxError struct {
+	Input  string
+	Reason string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid hostname %q: %s", e.Input, e.Reason)
+}
+
+// Canonicalize parses a hostname and returns its canonical form:
+// UTS-46 normalized to A-labels, lowercase, with any single trailing dot removed.
+// The result follows RFC 1123 syntax: letters, digits and hyphens only,
+// labels of 1 to 63 octets that neither start nor end with a hyphen, and at most 253 octets overall.
+// Errors are *SyntaxError values that explain what is wrong with the input.
+func Canonicalize(input string) (string, error) {
+	fail := func(format string, args ...any) (string, error) {
+		return "", &SyntaxError{Input: input, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if input == "" {
+		return fail("hostname is empty")
+	}
+	if strings.Contains(input, "://") {
+		return fail("looks like a URL; pass only the hostname, without a scheme")
+	}
+	if i := strings.IndexAny(input, "/?#@"); i >= 0 {
+		return fail("contains %q; pass only the hostname, without a path, query or user", input[i])
+	}
+	if i := strings.IndexFunc(input, unicode.IsSpace); i >= 0 {
+		return fail("contains whitespace at offset %d", i)
+	}
+	if strings.Contains(input, ":") {
+		return fail("contains ':'; pass only the hostname, without a port")
+	}
+
+	name := strings.TrimSuffix(input, ".")
+	if name == "" {
+		return fail("hostname is empty")
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "" {
+			return fail("label %d is empty", i+1)
+		}
+		// Check ASCII labels here, where the reason can be more specific than the IDNA error would be
+		if isASCII(label) {
+			if reason := checkLabel(strings.ToLower(label)); reason != "" {
+				return fail("%s", reason)
+			}
+		}
+	}
+
+	ascii, err := idn.ToASCII(name)
+	if err != nil {
+		return fail("%v", err)
+	}
+	ascii = strings.ToLower(ascii)
+
+	if len(ascii) > MaxLength {
+		return fail("name is %d octets long, the maximum is %d", len(ascii), MaxLength)
+	}
+	for i, label := range strings.Split(ascii, ".") {
+		if len(label) > MaxLabelLength {
+			return fail("label %d is %d octets long, the maximum is %d", i+1, len(label), MaxLabelLength)
+		}
+		if reason := checkLabel(label); reason != "" {
+			return fail("%s", reason)
+		}
+	}
+
+	return ascii, nil
+}
+
+// checkLabel returns why a lowercase ASCII label is not a valid RFC 1123 label, or "" if it is
+func checkLabel(label string) string {
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Sprintf("label %q starts or ends with a hyphen", label)
+	}
+	for _, r := range label {
+		if !isLDH(r) {
+			return fmt.Sprintf("label %q contains %q; only letters, digits and hyphens are allowed", label, r)
+		}
+	}
+	return ""
+}
+
+// CanonicalizeAll canonicalizes each hostname in inputs.
+// It returns the canonical hostnames in order, or every syntax error found, one per invalid hostname.
+func CanonicalizeAll(inputs []string) ([]string, []error) {
+	canonical := make([]string, 0, len(inputs))
+	var errs []error
+	for _, input := range inputs {
+		name, err := Canonicalize(input)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		canonical = append(canonical, name)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return canonical, nil
+}
+
+// CheckCanonical returns a *SyntaxError for the first of names that is not already in canonical form,
+// for code that needs its callers to have canonicalized the names, such as to calculate a group ID
+func CheckCanonical(names []string) error {
+	for _, name := range names {
+		canonical, err := Canonicalize(name)
+		if err != nil {
+			return err
+		}
+		if canonical != name {
+			return &SyntaxError{Input: name, Reason: fmt.Sprintf("not in canonical form; use %q", canonical)}
+		}
+	}
+	return nil
+}
+
+// isLDH reports whether r is a letter, digit or hyphen
+func isLDH(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-'
+}
+
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
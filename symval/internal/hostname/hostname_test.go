@@ -0,0 +1,99 @@
+package hostname
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       string
+		wantReason string
+	}{
+		{"plain", "example.com", "example.com", ""},
+		{"uppercase", "Example.COM", "example.com", ""},
+		{"trailing dot", "example.com.", "example.com", ""},
+		{"single label", "sos", "sos", ""},
+		{"leading digit", "1password.com", "1password.com", ""},
+		{"unicode", "bücher.example", "xn--bcher-kva.example", ""},
+		{"punycode", "XN--BCHER-KVA.example", "xn--bcher-kva.example", ""},
+		{"empty", "", "", "empty"},
+		{"only a dot", ".", "", "empty"},
+		{"empty label", "example..com", "", "label 2 is empty"},
+		{"leading dot", ".example.com", "", "label 1 is empty"},
+		{"two trailing dots", "example.com..", "", "label 3 is empty"},
+		{"space", "exa mple.com", "", "whitespace"},
+		{"URL", "https://example.com", "", "URL"},
+		{"path", "example.com/index.html", "", "path"},
+		{"port", "example.com:443", "", "port"},
+		{"underscore", "_dmarc.example.com", "", "only letters, digits and hyphens"},
+		{"leading hyphen", "-example.com", "", "hyphen"},
+		{"trailing hyphen", "example-.com", "", "hyphen"},
+		{"long label", strings.Repeat("a", 64) + ".com", "", "label 1 is 64 octets"},
+		{"long name", strings.Repeat(strings.Repeat("a", 63)+".", 4) + "com", "", "maximum is 253"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize(tt.input)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("Canonicalize(%q) unexpected error: %v", tt.input, err)
+				}
+				if got != tt.want {
+					t.Errorf("Canonicalize(%q) = %q, want %q", tt.input, got, tt.want)
+				}
+				return
+			}
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Canonicalize(%q) error = %v, want a *SyntaxError", tt.input, err)
+			}
+			if !strings.Contains(syntaxErr.Reason, tt.wantReason) {
+				t.Errorf("Canonicalize(%q) reason = %q, want it to contain %q", tt.input, syntaxErr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeAll(t *testing.T) {
+	names, errs := CanonicalizeAll([]string{"Example.com.", "zq.su"})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if strings.Join(names, ",") != "example.com,zq.su" {
+		t.Errorf("CanonicalizeAll() = %v", names)
+	}
+
+	names, errs = CanonicalizeAll([]string{"ok.example", "bad..example", "http://bad.example"})
+	if names != nil {
+		t.Errorf("expected no names when any input is invalid, got %v", names)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected one error per invalid hostname, got %v", errs)
+	}
+}
+
+func TestCheckCanonical(t *testing.T) {
+	tests := []struct {
+		names   []string
+		wantErr bool
+	}{
+		{[]string{"example.com", "xn--bcher-kva.example"}, false},
+		{[]string{"example.com", "Example.com"}, true},
+		{[]string{"example.com."}, true},
+		{[]string{"bücher.example"}, true},
+		{[]string{"bad_label.example"}, true},
+	}
+
+	for _, tt := range tests {
+		err := CheckCanonical(tt.names)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckCanonical(%q) = %v, want error %v", tt.names, err, tt.wantErr)
+		}
+	}
+}
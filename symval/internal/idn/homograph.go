@@ -0,0 +1,184 @@
+package idn
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mtibben/confusables"
+)
+
+// Verdict is the outcome of a homograph check on a hostname
+type Verdict string
+
+const (
+	VerdictOK       Verdict = "ok"       // Safe to display in Unicode form
+	VerdictPunycode Verdict = "punycode" // Should be displayed in A-label (punycode) form
+	VerdictReject   Verdict = "reject"   // Looks like a spoof of another name and should not be listed
+)
+
+// severity orders verdicts from least to most severe
+func (v Verdict) severity() int {
+	switch v {
+	case VerdictPunycode:
+		return 1
+	case VerdictReject:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// RestrictionLevel is a Unicode TR39 restriction level (section 5.2), from most to least restrictive
+type RestrictionLevel int
+
+const (
+	ASCIIOnly RestrictionLevel = iota
+	SingleScript
+	HighlyRestrictive
+	ModeratelyRestrictive
+	MinimallyRestrictive
+)
+
+// String returns the TR39 name of the restriction level
+func (l RestrictionLevel) String() string {
+	switch l {
+	case ASCIIOnly:
+		return "ASCII-Only"
+	case SingleScript:
+		return "Single Script"
+	case HighlyRestrictive:
+		return "Highly Restrictive"
+	case ModeratelyRestrictive:
+		return "Moderately Restrictive"
+	default:
+		return "Minimally Restrictive"
+	}
+}
+
+// MarshalText encodes the restriction level by name
+func (l RestrictionLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a restriction level encoded by MarshalText
+func (l *RestrictionLevel) UnmarshalText(text []byte) error {
+	for level := ASCIIOnly; level <= MinimallyRestrictive; level++ {
+		if level.String() == string(text) {
+			*l = level
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown restriction level %q", text)
+}
+
+// highlyRestrictiveSets are the script combinations TR39 allows at the Highly Restrictive level:
+// Latin may be combined with the scripts that are routinely written alongside it in East Asia
+var highlyRestrictiveSets = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// Assessment is the result of a confusables and mixed-script analysis of a hostname
+type Assessment struct {
+	Verdict  Verdict          `json:"verdict"`
+	Level    RestrictionLevel `json:"restrictionLevel"`
+	Skeleton string           `json:"skeleton"`
+	Reasons  []string         `json:"reasons,omitempty"`
+}
+
+// Assess runs the Unicode TR39 confusables and mixed-script checks on each label of host.
+//
+// A label whose skeleton is entirely ASCII looks like an ASCII name.
+// If it is written in a single Latin script it is flagged for display as punycode;
+// if it mixes scripts or is written in another script, it is a likely spoof and is rejected.
+// Labels that are less than Highly Restrictive are flagged for display as punycode.
+func Assess(host string) *Assessment {
+	unicodeForm := ToUnicode(host)
+	assessment := &Assessment{Verdict: VerdictOK, Skeleton: confusables.Skeleton(unicodeForm)}
+
+	if _, err := ToASCII(host); err != nil {
+		assessment.Verdict = VerdictReject
+		assessment.Reasons = append(assessment.Reasons, fmt.Sprintf("invalid hostname: %v", err))
+		return assessment
+	}
+
+	for _, label := range strings.Split(unicodeForm, ".") {
+		level := restrictionLevel(label)
+		if level > assessment.Level {
+			assessment.Level = level
+		}
+		if level == ASCIIOnly {
+			continue
+		}
+
+		if skeleton := confusables.Skeleton(label); isASCII(skeleton) {
+			if level == SingleScript && strings.Join(Scripts(label), ",") == "Latin" {
+				assessment.flag(VerdictPunycode, fmt.Sprintf("label %q is confusable with ASCII %q", label, skeleton))
+			} else {
+				assessment.flag(VerdictReject, fmt.Sprintf("label %q spoofs ASCII %q", label, skeleton))
+			}
+			continue
+		}
+
+		if level > HighlyRestrictive {
+			assessment.flag(VerdictPunycode, fmt.Sprintf("label %q is %s: %s", label, level, strings.Join(Scripts(label), ", ")))
+		}
+	}
+
+	return assessment
+}
+
+// flag raises the verdict to at least v and records the reason
+func (a *Assessment) flag(v Verdict, reason string) {
+	if v.severity() > a.Verdict.severity() {
+		a.Verdict = v
+	}
+	a.Reasons = append(a.Reasons, reason)
+}
+
+// restrictionLevel returns the TR39 restriction level of a single label
+func restrictionLevel(label string) RestrictionLevel {
+	if isASCII(label) {
+		return ASCIIOnly
+	}
+
+	scripts := Scripts(label)
+	if len(scripts) <= 1 {
+		return SingleScript
+	}
+
+	for _, allowed := range highlyRestrictiveSets {
+		if subset(scripts, allowed) {
+			return HighlyRestrictive
+		}
+	}
+
+	// Latin plus any single other script, except the ones most confusable with it
+	if len(scripts) == 2 && slices.Contains(scripts, "Latin") && !slices.Contains(scripts, "Cyrillic") && !slices.Contains(scripts, "Greek") {
+		return ModeratelyRestrictive
+	}
+
+	return MinimallyRestrictive
+}
+
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// subset reports whether every item in list is also in of
+func subset(list, of []string) bool {
+	for _, item := range list {
+		if !slices.Contains(of, item) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package idn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		wantVerdict Verdict
+		wantLevel   RestrictionLevel
+	}{
+		{"plain ASCII", "example.com", VerdictOK, ASCIIOnly},
+		{"Latin with diacritics", "bücher.example", VerdictOK, SingleScript},
+		{"punycode input is assessed by its Unicode form", "xn--bcher-kva.example", VerdictOK, SingleScript},
+		{"single non-Latin script", "пример.example", VerdictOK, SingleScript},
+		{"Latin with Japanese", "sunsのテスト.example", VerdictOK, HighlyRestrictive},
+		{"Latin lookalike of ASCII", "ɯs.su", VerdictPunycode, SingleScript},
+		{"Latin mixed with Thai", "abcไทย.example", VerdictPunycode, ModeratelyRestrictive},
+		{"Cyrillic mixed into Latin", "pаypal.com", VerdictReject, MinimallyRestrictive},
+		{"whole-script Cyrillic spoof", "ѕсоре.example", VerdictReject, SingleScript},
+		{"invalid punycode", "xn--zz.example", VerdictReject, ASCIIOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assessment := Assess(tt.host)
+			if assessment.Verdict != tt.wantVerdict {
+				t.Errorf("Assess(%q) verdict = %q, want %q (reasons: %v)", tt.host, assessment.Verdict, tt.wantVerdict, assessment.Reasons)
+			}
+			if assessment.Level != tt.wantLevel {
+				t.Errorf("Assess(%q) level = %s, want %s", tt.host, assessment.Level, tt.wantLevel)
+			}
+			if assessment.Verdict != VerdictOK && len(assessment.Reasons) == 0 {
+				t.Errorf("Assess(%q) flagged the name without a reason", tt.host)
+			}
+		})
+	}
+}
+
+func TestRestrictionLevelJSON(t *testing.T) {
+	for level := ASCIIOnly; level <= MinimallyRestrictive; level++ {
+		data, err := json.Marshal(level)
+		if err != nil {
+			t.Fatalf("Marshal(%s) error = %v", level, err)
+		}
+		var decoded RestrictionLevel
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", data, err)
+		}
+		if decoded != level {
+			t.Errorf("round trip of %s gave %s", level, decoded)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package idn inspects internationalized domain names
+package idn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// ACEPrefix is the prefix of punycode-encoded (A-label) labels
+	ACEPrefix = "xn--"
+)
+
+// LabelReport describes a single label of an inspected hostname
+type LabelReport struct {
+	ALabel  string   `json:"aLabel"`
+	ULabel  string   `json:"uLabel"`
+	Scripts []string `json:"scripts,omitempty"`
+}
+
+// Report describes how a hostname is encoded, mapped and displayed
+type Report struct {
+	// Input is the hostname as given
+	Input string `json:"input"`
+
+	// ASCII is the A-label form, as sent on the wire
+	ASCII string `json:"ascii"`
+
+	// Unicode is the U-label form, as a browser would display it if it trusts the name
+	Unicode string `json:"unicode"`
+
+	// Mapped is the result of UTS-46 mapping the input for lookup (case folding, width folding, etc)
+	Mapped string `json:"mapped"`
+
+	// MappingChanged is true if UTS-46 mapping changed the input
+	MappingChanged bool `json:"mappingChanged"`
+
+	Labels   []LabelReport `json:"labels"`
+	Errors   []string      `json:"errors,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+
+	// Safety is the result of the TR39 confusables and mixed-script checks
+	Safety *Assessment `json:"safety"`
+}
+
+// Inspect reports the A-label, U-label and UTS-46 mapping of a hostname,
+// along with any IDNA errors and homograph warnings.
+// Inspect never fails; problems are reported in Errors.
+func Inspect(host string) *Report {
+	host = strings.TrimSuffix(host, ".")
+	report := &Report{Input: host}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("to ASCII: %v", err))
+	}
+	report.ASCII = ascii
+
+	mapped, err := idna.Lookup.ToUnicode(host)
+	if err != nil && len(report.Errors) == 0 {
+		report.Errors = append(report.Errors, fmt.Sprintf("UTS-46 mapping: %v", err))
+	}
+	report.Mapped = mapped
+
+	// Compare against a plain punycode decoding, so that decoding xn-- labels alone does not count as mapping
+	decoded, _ := idna.Punycode.ToUnicode(host)
+	report.MappingChanged = mapped != decoded
+
+	unicodeForm, err := idna.Display.ToUnicode(ascii)
+	if err != nil && len(report.Errors) == 0 {
+		report.Errors = append(report.Errors, fmt.Sprintf("to Unicode: %v", err))
+	}
+	report.Unicode = unicodeForm
+
+	aLabels := strings.Split(ascii, ".")
+	uLabels := strings.Split(unicodeForm, ".")
+	for i, aLabel := range aLabels {
+		label := LabelReport{ALabel: aLabel, ULabel: aLabel}
+		if i < len(uLabels) {
+			label.ULabel = uLabels[i]
+		}
+		label.Scripts = Scripts(label.ULabel)
+		report.Labels = append(report.Labels, label)
+
+		if len(label.Scripts) > 1 {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("label %q mixes scripts: %s", label.ULabel, strings.Join(label.Scripts, ", ")))
+		}
+	}
+
+	report.Safety = Assess(host)
+
+	return report
+}
+
+// ToASCII normalizes a hostname through UTS-46 and returns its A-label form:
+// lowercase, with every non-ASCII label punycode-encoded and any trailing dot removed.
+// This is the canonical form used for storage, group IDs and DNS lookups.
+func ToASCII(host string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return "", err
+	}
+	return ascii, nil
+}
+
+// ToUnicode returns the U-label form of a hostname, decoding any punycode labels.
+// If the hostname cannot be decoded, it is returned lowercased but otherwise unchanged.
+func ToUnicode(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	unicodeForm, err := idna.Display.ToUnicode(host)
+	if err != nil {
+		return strings.ToLower(host)
+	}
+	return unicodeForm
+}
+
+// Normalize returns both the A-label and U-label forms of a hostname
+func Normalize(host string) (string, string, error) {
+	ascii, err := ToASCII(host)
+	if err != nil {
+		return "", "", err
+	}
+	return ascii, ToUnicode(ascii), nil
+}
+
+// Displayable reports whether a browser would display host in its Unicode form
+// rather than falling back to punycode: it must be valid under UTS-46,
+// already be in its mapped form, raise no homograph warnings,
+// and pass the confusable checks of Assess.
+func Displayable(host string) bool {
+	report := Inspect(host)
+	return len(report.Errors) == 0 &&
+		len(report.Warnings) == 0 &&
+		!report.MappingChanged &&
+		report.Unicode == strings.TrimSuffix(host, ".") &&
+		Assess(host).Verdict == VerdictOK
+}
+
+// IsPunycodeLabel reports whether a label is an A-label (starts with "xn--")
+func IsPunycodeLabel(label string) bool {
+	return len(label) >= len(ACEPrefix) && strings.EqualFold(label[:len(ACEPrefix)], ACEPrefix)
+}
+
+// Scripts returns the sorted, distinct Unicode scripts used by s.
+// The Common and Inherited pseudo-scripts (digits, punctuation, combining marks) are ignored.
+func Scripts(s string) []string {
+	seen := make(map[string]bool)
+	for _, r := range s {
+		if name := scriptOf(r); name != "" {
+			seen[name] = true
+		}
+	}
+
+	scripts := make([]string, 0, len(seen))
+	for name := range seen {
+		scripts = append(scripts, name)
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// scriptOf returns the script name of r, or "" for Common, Inherited and unassigned runes
+func scriptOf(r rune) string {
+	if r < unicode.MaxASCII {
+		if unicode.IsLetter(r) {
+			return "Latin"
+		}
+		return ""
+	}
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return ""
+	}
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package idn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		wantASCII   string
+		wantUnicode string
+		wantMapped  bool
+		wantErr     bool
+		wantWarning bool
+	}{
+		{
+			name:        "plain ASCII",
+			host:        "example.com",
+			wantASCII:   "example.com",
+			wantUnicode: "example.com",
+		},
+		{
+			name:        "punycode input is decoded",
+			host:        "xn--bcher-kva.example",
+			wantASCII:   "xn--bcher-kva.example",
+			wantUnicode: "bücher.example",
+		},
+		{
+			name:        "unicode input is encoded",
+			host:        "bücher.example",
+			wantASCII:   "xn--bcher-kva.example",
+			wantUnicode: "bücher.example",
+		},
+		{
+			name:        "uppercase and fullwidth input is mapped",
+			host:        "ＥＸＡＭＰＬＥ.com",
+			wantASCII:   "example.com",
+			wantUnicode: "example.com",
+			wantMapped:  true,
+		},
+		{
+			name:        "mixed Latin and Cyrillic",
+			host:        "pаypal.com", // The second letter is Cyrillic
+			wantASCII:   "xn--pypal-4ve.com",
+			wantUnicode: "pаypal.com",
+			wantWarning: true,
+		},
+		{
+			name:    "invalid punycode",
+			host:    "xn--zz.example",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Inspect(tt.host)
+			if (len(report.Errors) > 0) != tt.wantErr {
+				t.Fatalf("Inspect(%q) errors = %v, wantErr %v", tt.host, report.Errors, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if report.ASCII != tt.wantASCII {
+				t.Errorf("ASCII = %q, want %q", report.ASCII, tt.wantASCII)
+			}
+			if report.Unicode != tt.wantUnicode {
+				t.Errorf("Unicode = %q, want %q", report.Unicode, tt.wantUnicode)
+			}
+			if report.MappingChanged != tt.wantMapped {
+				t.Errorf("MappingChanged = %v, want %v", report.MappingChanged, tt.wantMapped)
+			}
+			if (len(report.Warnings) > 0) != tt.wantWarning {
+				t.Errorf("Warnings = %v, wantWarning %v", report.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abc", "Latin"},
+		{"abc-123", "Latin"},
+		{"123", ""},
+		{"пример", "Cyrillic"},
+		{"pаypal", "Cyrillic,Latin"},
+		{"ελληνικά", "Greek"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(Scripts(tt.input), ","); got != tt.want {
+			t.Errorf("Scripts(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestIsPunycodeLabel(t *testing.T) {
+	if !IsPunycodeLabel("xn--bcher-kva") || !IsPunycodeLabel("XN--bcher-kva") {
+		t.Error("expected xn-- labels to be detected")
+	}
+	if IsPunycodeLabel("xn-") || IsPunycodeLabel("example") {
+		t.Error("expected non-ACE labels not to be detected")
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    string
+		wantErr bool
+	}{
+		{"example.com", "example.com", false},
+		{"Example.COM.", "example.com", false},
+		{"bücher.example", "xn--bcher-kva.example", false},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example", false},
+		{"xn--zz.example", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ToASCII(tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ToASCII(%q) error = %v, wantErr %v", tt.host, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ToASCII(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayable(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"bücher.example", true},
+		{"ɯoɔ.su", true},
+		{"ɯs.su", false},                 // Confusable with ASCII ws.su
+		{"xn--bcher-kva.example", false}, // Displayed as bücher.example, not as given
+		{"pаypal.com", false},            // Mixed scripts
+		{"EXAMPLE.com", false},           // Changed by mapping
+	}
+
+	for _, tt := range tests {
+		if got := Displayable(tt.host); got != tt.want {
+			t.Errorf("Displayable(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
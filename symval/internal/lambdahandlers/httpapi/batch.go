@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/logger"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+)
+
+// MaxBatchSize is the most groups accepted in one batch request, so that a batch finishes within the API timeout
+const MaxBatchSize = 25
+
+// handleAttestBatch attests an array of groups concurrently, returning a result for each
+func (h *Handler) handleAttestBatch(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	httpMethod := request.RequestContext.HTTP.Method
+	if httpMethod != "POST" {
+		return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only POST is supported for this endpoint (received: %s)", httpMethod))
+	}
+
+	dryRun, err := parseDryRun(request)
+	if err != nil {
+		return errorResponseV2(400, err.Error())
+	}
+
+	var attestReqs []apitypes.AttestRequest
+	if err := json.Unmarshal([]byte(request.Body), &attestReqs); err != nil {
+		return errorResponseV2(400, fmt.Sprintf("Invalid request body: expected an array of attestation requests: %v", err))
+	}
+	if len(attestReqs) < 1 {
+		return errorResponseV2(400, "at least one group is required")
+	}
+	if len(attestReqs) > MaxBatchSize {
+		return errorResponseV2(400, fmt.Sprintf("too many groups: %d, the limit is %d", len(attestReqs), MaxBatchSize))
+	}
+
+	// Reject invalid items up front, and attest the rest together
+	response := apitypes.BatchAttestResponse{DryRun: dryRun, Results: make([]apitypes.BatchAttestItem, len(attestReqs))}
+	var items []attestation.BatchItem
+	var itemIndexes []int
+	for i, attestReq := range attestReqs {
+		response.Results[i].Index = i
+		group, invalid := validateAttestRequest(attestReq)
+		if invalid != nil {
+			response.Results[i].Status = 400
+			response.Results[i].Error = invalid.Error
+			response.Results[i].InvalidDomains = invalid.InvalidDomains
+			continue
+		}
+		items = append(items, attestation.BatchItem{
+			Owner:   group.owner,
+			Type:    group.symmetryType,
+			Domains: group.domains,
+		})
+		itemIndexes = append(itemIndexes, i)
+	}
+
+	results := h.attestUseCase.AttestBatch(items, h.batchWorkers, dryRun)
+	for j, r := range results {
+		item := &response.Results[itemIndexes[j]]
+		if r.Err != nil {
+			requestLogger.Error("Attestation failed", slog.Int("index", item.Index), slog.String("error", r.Err.Error()))
+			item.Status = 500
+			item.Error = fmt.Sprintf("attestation failed: %v", r.Err)
+			continue
+		}
+		attestResponse := newAttestResponse(r.Result)
+		if dryRun {
+			attestResponse.DryRun = true
+			attestResponse.Message += " (dry run: nothing was stored)"
+		}
+		item.Status = 200
+		item.Result = &attestResponse
+	}
+
+	for _, item := range response.Results {
+		switch {
+		case item.Status != 200:
+			response.Errors++
+		case item.Result.IsValid:
+			response.Valid++
+		default:
+			response.Invalid++
+		}
+	}
+
+	requestLogger.Info("Batch attested",
+		slog.Int("valid", response.Valid),
+		slog.Int("invalid", response.Invalid),
+		slog.Int("errors", response.Errors))
+	return jsonResponseV2(200, response)
+}
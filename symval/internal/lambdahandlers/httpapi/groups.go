@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/logger"
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// handleGroups handles GET /v1/groups?id=<group-id>.
+// The group ID is a query parameter rather than part of the path, because group IDs contain slashes.
+func (h *Handler) handleGroups(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	httpMethod := request.RequestContext.HTTP.Method
+	if httpMethod != "GET" {
+		return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only GET is supported for this endpoint (received: %s)", httpMethod))
+	}
+
+	groupID := request.QueryStringParameters["id"]
+	if groupID == "" {
+		return errorResponseV2(400, "id query parameter is required")
+	}
+	return h.handleGetGroup(ctx, request, groupID)
+}
+
+// handleGetGroup reports the records of a group and the history of their checks
+func (h *Handler) handleGetGroup(ctx context.Context, request events.APIGatewayV2HTTPRequest, groupID string) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	records, err := h.repo.ListByGroupID(ctx, groupID)
+	if err != nil {
+		requestLogger.Error("Group lookup failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("group lookup failed: %v", err))
+	}
+	if len(records) == 0 {
+		return errorResponseV2(404, fmt.Sprintf("group %s not found", groupID))
+	}
+
+	return jsonResponseV2(200, newGroupResponse(groupID, records))
+}
+
+// newGroupResponse converts the records of a group to a GroupResponse, in hostname order
+func newGroupResponse(groupID string, records []*model.DomainRecord) apitypes.GroupResponse {
+	sort.Slice(records, func(i, j int) bool { return records[i].Hostname < records[j].Hostname })
+
+	response := apitypes.GroupResponse{
+		GroupID: groupID,
+		Owner:   records[0].Owner,
+		Type:    string(records[0].Type),
+		Records: make([]apitypes.GroupRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		response.Records = append(response.Records, apitypes.GroupRecord{
+			Hostname:            record.Hostname,
+			UnicodeHostname:     record.DisplayHostname(),
+			Validated:           record.ValidateTime,
+			FirstAttested:       optionalTime(record.FirstAttestedTime),
+			LastChecked:         optionalTime(record.LastCheckTime),
+			LastFailure:         optionalTime(record.LastFailureTime),
+			ConsecutiveFailures: record.ConsecutiveFailures,
+			LastFailureReason:   record.LastFailureReason,
+		})
+	}
+	return response
+}
+
+// optionalTime returns a pointer to t, or nil if t is zero, so that unset times are left out of responses
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -3,44 +3,39 @@ package httpapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/hostname"
 	"github.com/mrled/suns/symval/internal/logger"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/repository/dynamorepo"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
 	"github.com/mrled/suns/symval/internal/symgroup"
 	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/usecase/conflict"
+	"github.com/mrled/suns/symval/internal/usecase/order"
+	"github.com/mrled/suns/symval/internal/usecase/withdraw"
 )
 
 // Handler holds the dependencies for the httpapi Lambda handler
 type Handler struct {
-	repo          model.DomainRepository
-	dnsService    *dnsclaims.Service
-	attestUseCase *attestation.AttestationUseCase
-	log           *slog.Logger
-}
-
-// AttestRequest represents the expected JSON payload for attestation
-type AttestRequest struct {
-	Owner   string   `json:"owner"`
-	Type    string   `json:"type"`
-	Domains []string `json:"domains"`
-}
-
-// AttestResponse represents the JSON response for attestation
-type AttestResponse struct {
-	IsValid      bool   `json:"isValid"`
-	ExpectedID   string `json:"expectedId"`
-	GroupIDCount int    `json:"groupIdCount"`
-	ErrorMessage string `json:"errorMessage,omitempty"`
-	Message      string `json:"message,omitempty"`
+	repo            model.DomainRepository
+	dnsService      *dnsclaims.Service
+	attestUseCase   *attestation.AttestationUseCase
+	orderUseCase    *order.OrderUseCase // nil if ORDERS_TABLE is not set
+	withdrawUseCase *withdraw.WithdrawUseCase
+	batchWorkers    int // Groups attested at once in a batch request
+	log             *slog.Logger
 }
 
 // NewHandler creates a new httpapi handler with initialized dependencies
@@ -105,6 +100,56 @@ func NewHandler() (*Handler, error) {
 	attestUseCase := attestation.NewAttestationUseCase(dnsService, repo)
 	log.Info("Attestation use case initialized")
 
+	// Optional limit on groups per registrable domain (eTLD+1)
+	if limitStr := os.Getenv("MAX_GROUPS_PER_REGISTRABLE_DOMAIN"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid MAX_GROUPS_PER_REGISTRABLE_DOMAIN %q: must be a non-negative integer", limitStr)
+		}
+		attestUseCase.SetRegistrableDomainLimit(limit)
+		log.Info("Registrable domain limit configured", slog.Int("limit", limit))
+	}
+
+	// Optional ownership conflict policy, defaulting to first-come
+	conflictPolicy, err := conflict.ParsePolicy(os.Getenv("CONFLICT_POLICY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONFLICT_POLICY: %w", err)
+	}
+	attestUseCase.SetConflictPolicy(conflictPolicy)
+	log.Info("Conflict policy configured", slog.String("policy", string(conflictPolicy)))
+
+	// Optional number of groups attested at once in a batch request
+	batchWorkers := attestation.DefaultBatchWorkers
+	if workersStr := os.Getenv("BATCH_WORKERS"); workersStr != "" {
+		workers, err := strconv.Atoi(workersStr)
+		if err != nil || workers < 1 {
+			return nil, fmt.Errorf("invalid BATCH_WORKERS %q: must be a positive integer", workersStr)
+		}
+		batchWorkers = workers
+	}
+	log.Info("Batch workers configured", slog.Int("workers", batchWorkers))
+
+	// Initialize withdraw use case, which removes groups without waiting for the reattest grace period
+	withdrawUseCase := withdraw.NewWithdrawUseCase(dnsService, repo)
+	log.Info("Withdraw use case initialized")
+
+	// Optional orders table, which enables the /v1/orders endpoints
+	var orderUseCase *order.OrderUseCase
+	if ordersTable := os.Getenv("ORDERS_TABLE"); ordersTable != "" {
+		orders := dynamorepo.NewDynamoOrderRepository(client, ordersTable)
+		orderUseCase = order.NewOrderUseCase(dnsService, attestUseCase, orders)
+		if ttlStr := os.Getenv("ORDER_TTL"); ttlStr != "" {
+			ttl, err := time.ParseDuration(ttlStr)
+			if err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("invalid ORDER_TTL %q: must be a positive duration like 24h", ttlStr)
+			}
+			orderUseCase.SetTTL(ttl)
+		}
+		log.Info("Orders enabled", slog.String("table", ordersTable))
+	} else {
+		log.Info("Orders disabled; set ORDERS_TABLE to enable them")
+	}
+
 	// Verify DynamoDB connection
 	records, err := repo.List(ctx)
 	if err != nil {
@@ -114,10 +159,13 @@ func NewHandler() (*Handler, error) {
 	}
 
 	return &Handler{
-		repo:          repo,
-		dnsService:    dnsService,
-		attestUseCase: attestUseCase,
-		log:           log,
+		repo:            repo,
+		dnsService:      dnsService,
+		attestUseCase:   attestUseCase,
+		orderUseCase:    orderUseCase,
+		withdrawUseCase: withdrawUseCase,
+		batchWorkers:    batchWorkers,
+		log:             log,
 	}, nil
 }
 
@@ -150,8 +198,16 @@ func (h *Handler) Handle(ctx context.Context, request events.APIGatewayV2HTTPReq
 	// Route based on the path
 	// The path should be something like /v1/attest after removing /api prefix
 	switch {
+	case strings.HasSuffix(path, "/v1/attest:batch") || path == "/v1/attest:batch":
+		return h.handleAttestBatch(ctx, request)
 	case strings.HasSuffix(path, "/v1/attest") || path == "/v1/attest":
 		return h.handleAttest(ctx, request)
+	case strings.HasSuffix(path, "/v1/withdraw") || path == "/v1/withdraw":
+		return h.handleWithdraw(ctx, request)
+	case path == "/v1/orders" || strings.HasPrefix(path, "/v1/orders/"):
+		return h.handleOrders(ctx, request, strings.TrimPrefix(path, "/v1/orders"))
+	case strings.HasSuffix(path, "/v1/groups") || path == "/v1/groups":
+		return h.handleGroups(ctx, request)
 	// Add more endpoints here as needed, for example:
 	// case strings.HasSuffix(path, "/v1/verify") || path == "/v1/verify":
 	//	return h.handleVerify(ctx, request)
@@ -182,50 +238,141 @@ func (h *Handler) handleAttest(ctx context.Context, request events.APIGatewayV2H
 		return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only POST is supported for this endpoint (received: %s)", httpMethod))
 	}
 
-	// Parse the request body
-	var attestReq AttestRequest
-	if err := json.Unmarshal([]byte(request.Body), &attestReq); err != nil {
-		return errorResponseV2(400, fmt.Sprintf("Invalid request body: %v", err))
+	group, errResponse := parseGroupRequest(request.Body)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	dryRun, err := parseDryRun(request)
+	if err != nil {
+		return errorResponseV2(400, err.Error())
+	}
+
+	// Perform attestation
+	var result *attestation.AttestResult
+	if dryRun {
+		result, err = h.attestUseCase.Check(group.owner, group.symmetryType, group.domains)
+	} else {
+		result, err = h.attestUseCase.Attest(group.owner, group.symmetryType, group.domains)
+	}
+	if err != nil {
+		requestLogger.Error("Attestation failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("attestation failed: %v", err))
+	}
+
+	response := newAttestResponse(result)
+	if dryRun {
+		response.DryRun = true
+		response.Message += " (dry run: nothing was stored)"
+	}
+
+	// Marshal response to JSON
+	responseBody, err := json.Marshal(response)
+	if err != nil {
+		requestLogger.Error("Failed to marshal response", slog.String("error", err.Error()))
+		return errorResponseV2(500, "failed to generate response")
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 200,
+		Body:       string(responseBody),
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+	}, nil
+}
+
+// groupRequest is the owner, symmetry type and canonical domains of a valid AttestRequest
+type groupRequest struct {
+	owner        string
+	symmetryType symgroup.SymmetryType
+	domains      []string
+}
+
+// parseGroupRequest parses and validates an AttestRequest body.
+// If the request is invalid, it returns the 400 response to send instead.
+func parseGroupRequest(body string) (*groupRequest, *events.APIGatewayV2HTTPResponse) {
+	var attestReq apitypes.AttestRequest
+	if err := json.Unmarshal([]byte(body), &attestReq); err != nil {
+		response, _ := errorResponseV2(400, fmt.Sprintf("Invalid request body: %v", err))
+		return nil, &response
+	}
+
+	group, invalid := validateAttestRequest(attestReq)
+	if invalid != nil {
+		response, _ := jsonResponseV2(400, invalid)
+		return nil, &response
 	}
+	return group, nil
+}
 
+// validateAttestRequest checks the fields of an AttestRequest and canonicalizes its domains.
+// If the request is invalid, it returns the reason, listing every invalid hostname.
+func validateAttestRequest(attestReq apitypes.AttestRequest) (*groupRequest, *apitypes.InvalidDomainsResponse) {
 	// Validate required fields
 	if attestReq.Owner == "" {
-		return errorResponseV2(400, "owner field is required")
+		return nil, &apitypes.InvalidDomainsResponse{Error: "owner field is required"}
 	}
 	if attestReq.Type == "" {
-		return errorResponseV2(400, "type field is required")
+		return nil, &apitypes.InvalidDomainsResponse{Error: "type field is required"}
 	}
 	if len(attestReq.Domains) < 1 {
-		return errorResponseV2(400, "at least one domain is required")
+		return nil, &apitypes.InvalidDomainsResponse{Error: "at least one domain is required"}
 	}
 
-	// Convert type name to type code (similar to attest command)
-	typeName := strings.ToLower(attestReq.Type)
-	typeCode, ok := symgroup.TypeNameToCode[typeName]
+	// Accept type names and type codes (similar to attest command)
+	symmetryType, ok := symgroup.ParseType(attestReq.Type)
 	if !ok {
-		// Check if it's already a valid type code
-		if _, codeExists := symgroup.TypeCodeToName[typeName]; codeExists {
-			typeCode = typeName
-		} else {
-			return errorResponseV2(400, "invalid symmetry type. "+symgroup.ValidSymmetryTypesText())
-		}
+		return nil, &apitypes.InvalidDomainsResponse{Error: "invalid symmetry type. " + symgroup.ValidSymmetryTypesText()}
 	}
 
-	symmetryType := symgroup.SymmetryType(typeCode)
+	// Canonicalize hostnames before any DNS lookups, reporting every invalid one
+	domains, errs := hostname.CanonicalizeAll(attestReq.Domains)
+	if len(errs) > 0 {
+		return nil, newInvalidDomainsResponse(errs)
+	}
 
-	// Perform attestation
-	result, err := h.attestUseCase.Attest(attestReq.Owner, symmetryType, attestReq.Domains)
+	return &groupRequest{
+		owner:        attestReq.Owner,
+		symmetryType: symmetryType,
+		domains:      domains,
+	}, nil
+}
+
+// parseDryRun reads the dryRun query parameter; ?dryRun=true runs every check without storing anything
+func parseDryRun(request events.APIGatewayV2HTTPRequest) (bool, error) {
+	value, ok := request.QueryStringParameters["dryRun"]
+	if !ok {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
 	if err != nil {
-		requestLogger.Error("Attestation failed", slog.String("error", err.Error()))
-		return errorResponseV2(500, fmt.Sprintf("attestation failed: %v", err))
+		return false, fmt.Errorf("invalid dryRun value %q: must be true or false", value)
 	}
+	return dryRun, nil
+}
 
-	// Build response
-	response := AttestResponse{
+// newAttestResponse converts an attestation result to its response form
+func newAttestResponse(result *attestation.AttestResult) apitypes.AttestResponse {
+	response := apitypes.AttestResponse{
 		IsValid:      result.IsValid,
 		ExpectedID:   result.ExpectedID,
 		GroupIDCount: len(result.GroupIDs),
 		ErrorMessage: result.ErrorMessage,
+		Persisted:    result.Persisted,
+	}
+	if result.Conflicts != nil {
+		response.ConflictReport = result.Conflicts.String()
+	}
+
+	response.Diagnostics = newDomainDiagnostics(result.Diagnostics)
+
+	for _, record := range result.DomainRecords {
+		response.Domains = append(response.Domains, apitypes.AttestDomain{
+			Hostname:        record.Hostname,
+			UnicodeHostname: record.DisplayHostname(),
+			IDNSafety:       string(record.Safety()),
+		})
 	}
 
 	if result.IsValid {
@@ -233,17 +380,59 @@ func (h *Handler) handleAttest(ctx context.Context, request events.APIGatewayV2H
 	} else {
 		response.Message = "Attestation FAILED"
 	}
+	return response
+}
 
-	// Marshal response to JSON
-	responseBody, err := json.Marshal(response)
+// newDomainDiagnostics converts attestation diagnostics to their response form
+func newDomainDiagnostics(diagnostics []attestation.DomainDiagnostic) []apitypes.DomainDiagnostic {
+	var converted []apitypes.DomainDiagnostic
+	for _, d := range diagnostics {
+		domain := apitypes.DomainDiagnostic{
+			Hostname:      d.Hostname,
+			Label:         d.Label,
+			CNAMEPath:     d.CNAMEPath,
+			ResolverError: d.ResolverError,
+			Records:       []apitypes.RecordDiagnostic{},
+			Problem:       d.Problem,
+		}
+		for _, r := range d.Records {
+			record := apitypes.RecordDiagnostic{Value: r.Value, Rejected: string(r.Rejected)}
+			if r.GroupID != nil {
+				record.Type = r.GroupID.TypeCode
+				record.OwnerHash = r.GroupID.OwnerHash
+			}
+			domain.Records = append(domain.Records, record)
+		}
+		converted = append(converted, domain)
+	}
+	return converted
+}
+
+// newInvalidDomainsResponse lists each invalid hostname and why it is invalid
+func newInvalidDomainsResponse(errs []error) *apitypes.InvalidDomainsResponse {
+	response := &apitypes.InvalidDomainsResponse{Error: fmt.Sprintf("%d invalid domain(s)", len(errs))}
+	for _, err := range errs {
+		invalid := apitypes.InvalidDomain{Error: err.Error()}
+		var syntaxErr *hostname.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			invalid.Domain = syntaxErr.Input
+			invalid.Error = syntaxErr.Reason
+		}
+		response.InvalidDomains = append(response.InvalidDomains, invalid)
+	}
+	return response
+}
+
+// jsonResponseV2 creates a JSON response for API Gateway v2
+func jsonResponseV2(statusCode int, response any) (events.APIGatewayV2HTTPResponse, error) {
+	body, err := json.Marshal(response)
 	if err != nil {
-		requestLogger.Error("Failed to marshal response", slog.String("error", err.Error()))
 		return errorResponseV2(500, "failed to generate response")
 	}
 
 	return events.APIGatewayV2HTTPResponse{
-		StatusCode: 200,
-		Body:       string(responseBody),
+		StatusCode: statusCode,
+		Body:       string(body),
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
@@ -0,0 +1,145 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/logger"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/usecase/order"
+)
+
+// handleOrders routes /v1/orders requests; subpath is the path after /v1/orders
+func (h *Handler) handleOrders(ctx context.Context, request events.APIGatewayV2HTTPRequest, subpath string) (events.APIGatewayV2HTTPResponse, error) {
+	if h.orderUseCase == nil {
+		return errorResponseV2(404, "Orders are not enabled on this server")
+	}
+
+	httpMethod := request.RequestContext.HTTP.Method
+	parts := strings.Split(strings.Trim(subpath, "/"), "/")
+
+	switch {
+	case subpath == "" || subpath == "/":
+		if httpMethod != "POST" {
+			return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only POST is supported for this endpoint (received: %s)", httpMethod))
+		}
+		return h.handleCreateOrder(ctx, request)
+	case len(parts) == 1:
+		if httpMethod != "GET" {
+			return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only GET is supported for this endpoint (received: %s)", httpMethod))
+		}
+		return h.handleGetOrder(ctx, request, parts[0])
+	case len(parts) == 2 && parts[1] == "finalize":
+		if httpMethod != "POST" {
+			return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only POST is supported for this endpoint (received: %s)", httpMethod))
+		}
+		return h.handleFinalizeOrder(ctx, request, parts[0])
+	default:
+		return errorResponseV2(404, fmt.Sprintf("Unknown endpoint: /v1/orders%s", subpath))
+	}
+}
+
+// handleCreateOrder validates the group and creates a pending order listing the records to create
+func (h *Handler) handleCreateOrder(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	group, errResponse := parseGroupRequest(request.Body)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	created, err := h.orderUseCase.Create(ctx, group.owner, group.symmetryType, group.domains)
+	if errors.Is(err, order.ErrInvalidGroup) {
+		return errorResponseV2(400, err.Error())
+	} else if err != nil {
+		requestLogger.Error("Order creation failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("order creation failed: %v", err))
+	}
+
+	requestLogger.Info("Order created", slog.String("order", created.ID), slog.String("group_id", created.ExpectedID))
+	return jsonResponseV2(201, newOrderResponse(created))
+}
+
+// handleGetOrder reports the status of an order
+func (h *Handler) handleGetOrder(ctx context.Context, request events.APIGatewayV2HTTPRequest, id string) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	found, err := h.orderUseCase.Get(ctx, id)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return errorResponseV2(404, fmt.Sprintf("order %s not found", id))
+	} else if err != nil {
+		requestLogger.Error("Order lookup failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("order lookup failed: %v", err))
+	}
+
+	return jsonResponseV2(200, newOrderResponse(found))
+}
+
+// handleFinalizeOrder attests the group of an order, including the attestation result in the response
+func (h *Handler) handleFinalizeOrder(ctx context.Context, request events.APIGatewayV2HTTPRequest, id string) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	finalized, result, err := h.orderUseCase.Finalize(ctx, id)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return errorResponseV2(404, fmt.Sprintf("order %s not found", id))
+	} else if errors.Is(err, order.ErrExpired) {
+		return errorResponseV2(410, fmt.Sprintf("order %s expired at %s; create a new order", id, finalized.ExpireTime.Format(time.RFC3339)))
+	} else if err != nil {
+		requestLogger.Error("Order finalization failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("order finalization failed: %v", err))
+	}
+
+	response := newOrderResponse(finalized)
+	if result != nil {
+		attestResponse := newAttestResponse(result)
+		response.Attest = &attestResponse
+	}
+
+	requestLogger.Info("Order finalized", slog.String("order", finalized.ID), slog.String("status", string(finalized.Status)))
+	return jsonResponseV2(200, response)
+}
+
+// newOrderResponse converts an order to its response form
+func newOrderResponse(o *model.Order) apitypes.OrderResponse {
+	response := apitypes.OrderResponse{
+		ID:         o.ID,
+		Status:     string(o.Status),
+		Owner:      o.Owner,
+		Type:       string(o.Type),
+		Domains:    o.Domains,
+		ExpectedID: o.ExpectedID,
+		Records:    []apitypes.OrderRecord{},
+		Created:    o.CreateTime,
+		Expires:    o.ExpireTime,
+		LastError:  o.LastError,
+	}
+	if !o.FinalizeTime.IsZero() {
+		finalized := o.FinalizeTime
+		response.Finalized = &finalized
+	}
+	for _, r := range o.Records {
+		response.Records = append(response.Records, apitypes.OrderRecord{
+			Hostname: r.Hostname,
+			Name:     r.Name,
+			Type:     "TXT",
+			Value:    r.Value,
+		})
+	}
+	return response
+}
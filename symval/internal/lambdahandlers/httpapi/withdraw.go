@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mrled/suns/symval/internal/apitypes"
+	"github.com/mrled/suns/symval/internal/logger"
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// handleWithdraw removes a group whose _suns records have been removed or carry the withdrawal marker
+func (h *Handler) handleWithdraw(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	requestLogger := logger.WithLambda(h.log,
+		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		request.RequestContext.RequestID)
+
+	httpMethod := request.RequestContext.HTTP.Method
+	if httpMethod != "POST" {
+		return errorResponseV2(405, fmt.Sprintf("Method not allowed. Only POST is supported for this endpoint (received: %s)", httpMethod))
+	}
+
+	var withdrawReq apitypes.WithdrawRequest
+	if err := json.Unmarshal([]byte(request.Body), &withdrawReq); err != nil {
+		return errorResponseV2(400, fmt.Sprintf("Invalid request body: %v", err))
+	}
+	if withdrawReq.GroupID == "" {
+		return errorResponseV2(400, "groupId field is required")
+	}
+
+	result, err := h.withdrawUseCase.Withdraw(ctx, withdrawReq.GroupID)
+	if errors.Is(err, model.ErrNotFound) {
+		return errorResponseV2(404, fmt.Sprintf("group %s not found", withdrawReq.GroupID))
+	} else if err != nil {
+		requestLogger.Error("Withdrawal failed", slog.String("error", err.Error()))
+		return errorResponseV2(500, fmt.Sprintf("withdrawal failed: %v", err))
+	}
+
+	response := apitypes.WithdrawResponse{
+		GroupID:      result.GroupID,
+		IsWithdrawn:  result.IsWithdrawn,
+		ErrorMessage: result.ErrorMessage,
+		Deleted:      result.Deleted,
+		Skipped:      result.Skipped,
+	}
+	for _, d := range result.Domains {
+		response.Domains = append(response.Domains, apitypes.WithdrawDomain{
+			Hostname:  d.Hostname,
+			Withdrawn: d.Withdrawn,
+			Marker:    d.Marker,
+			Reason:    d.Reason,
+		})
+	}
+
+	// The group is only removed if every record was deleted;
+	// records that changed during the withdrawal are left for reattestation
+	switch {
+	case !result.IsWithdrawn:
+		response.Message = "Withdrawal FAILED"
+	case result.Skipped == 0:
+		response.Message = "Withdrawal PASSED: The group has been removed"
+	case result.Deleted == 0:
+		response.Message = fmt.Sprintf("Withdrawal PASSED, but the group was not removed: all %d record(s) changed during withdrawal and were left for reattestation", result.Skipped)
+	default:
+		response.Message = fmt.Sprintf("Withdrawal PASSED, but the group was only partly removed: %d record(s) removed, %d changed during withdrawal and were left for reattestation", result.Deleted, result.Skipped)
+	}
+	if result.IsWithdrawn {
+		requestLogger.Info("Group withdrawn", slog.String("group_id", result.GroupID), slog.Int("deleted", result.Deleted), slog.Int("skipped", result.Skipped))
+	}
+
+	responseBody, err := json.Marshal(response)
+	if err != nil {
+		requestLogger.Error("Failed to marshal response", slog.String("error", err.Error()))
+		return errorResponseV2(500, "failed to generate response")
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 200,
+		Body:       string(responseBody),
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+	}, nil
+}
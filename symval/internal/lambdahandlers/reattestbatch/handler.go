@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -13,8 +14,11 @@ import (
 
 	"github.com/mrled/suns/symval/internal/adapter/s3materializedview"
 	"github.com/mrled/suns/symval/internal/logger"
+	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/repository/dynamorepo"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
 	"github.com/mrled/suns/symval/internal/usecase/reattest"
 )
 
@@ -24,9 +28,18 @@ type Handler struct {
 	dynamoRepo       *dynamorepo.DynamoRepository
 	s3View           *s3materializedview.S3MaterializedView
 	dynamoTable      string
+	archiveTable     string        // Where removed groups are archived; empty to delete them outright
+	archiveRetention time.Duration // How long removed groups are kept in the archive
 	s3BucketName     string
 	s3DataKey        string
-	gracePeriodHours int
+	s3StateKey       string        // Where the resume cursor is kept between runs
+	deadlineMargin   time.Duration // Time left before the Lambda deadline when no more groups are started
+	schedule         *reattest.Schedule
+	breakerThreshold float64 // Fraction of indeterminate groups that stops a run; 0 disables the breaker
+	retention        *reattest.RetentionPolicy
+	workers          int              // Groups re-attested at once
+	lookupWorkers    int              // Domains of one group looked up at once
+	dnsLimits        dnsclaims.Limits // Bounds on all lookups made in one run
 }
 
 // NewHandler creates a new reattestbatch handler with initialized dependencies
@@ -49,6 +62,18 @@ func NewHandler() (*Handler, error) {
 	}
 	log.Info("Using S3 bucket", slog.String("bucket", s3BucketName))
 
+	// Optional archive table, where removed groups are kept so that they can be restored
+	archiveTable := os.Getenv("ARCHIVE_TABLE")
+	archiveRetention, err := durationEnv("REATTEST_ARCHIVE_RETENTION", reattest.DefaultArchiveRetention)
+	if err != nil {
+		return nil, err
+	}
+	if archiveTable != "" {
+		log.Info("Using DynamoDB archive table",
+			slog.String("table", archiveTable),
+			slog.Duration("retention", archiveRetention))
+	}
+
 	// Use S3_DATA_KEY from environment or default to records/domains.json
 	s3DataKey := os.Getenv("S3_DATA_KEY")
 	if s3DataKey == "" {
@@ -56,26 +81,194 @@ func NewHandler() (*Handler, error) {
 	}
 	log.Info("Using S3 key", slog.String("key", s3DataKey))
 
-	gracePeriodHours := 72
+	// Use S3_STATE_KEY from environment or default to the state prefix, apart from the public records
+	s3StateKey := os.Getenv("S3_STATE_KEY")
+	if s3StateKey == "" {
+		s3StateKey = s3materializedview.DefaultStateKey
+	}
+	log.Info("Using S3 state key", slog.String("key", s3StateKey))
+
+	// Optional margin before the Lambda deadline, to finish the groups in progress and save the cursor
+	deadlineMargin, err := durationEnv("REATTEST_DEADLINE_MARGIN", reattest.DefaultDeadlineMargin)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Deadline margin configured", slog.Duration("margin", deadlineMargin))
+
+	// Optional schedule settings; the function runs often, and only re-attests the groups that are due
+	schedule := reattest.DefaultSchedule()
+	if schedule.HealthyInterval, err = durationEnv("REATTEST_HEALTHY_INTERVAL", schedule.HealthyInterval); err != nil {
+		return nil, err
+	}
+	if schedule.RetryInterval, err = durationEnv("REATTEST_RETRY_INTERVAL", schedule.RetryInterval); err != nil {
+		return nil, err
+	}
+	if schedule.MaxBackoff, err = durationEnv("REATTEST_MAX_BACKOFF", schedule.MaxBackoff); err != nil {
+		return nil, err
+	}
+	if jitterStr := os.Getenv("REATTEST_JITTER"); jitterStr != "" {
+		jitter, err := strconv.ParseFloat(jitterStr, 64)
+		if err != nil || jitter < 0 || jitter >= 1 {
+			return nil, fmt.Errorf("invalid REATTEST_JITTER %q: must be a fraction from 0 to less than 1", jitterStr)
+		}
+		schedule.Jitter = jitter
+	}
+	// Optional circuit breaker threshold, so that a resolver outage cannot look like every group failing
+	breakerThreshold := reattest.DefaultBreakerThreshold
+	if thresholdStr := os.Getenv("REATTEST_BREAKER_THRESHOLD"); thresholdStr != "" {
+		threshold, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("invalid REATTEST_BREAKER_THRESHOLD %q: must be a fraction from 0 to 1", thresholdStr)
+		}
+		breakerThreshold = threshold
+	}
+	log.Info("Circuit breaker configured", slog.Float64("threshold", breakerThreshold))
+
+	log.Info("Schedule configured",
+		slog.Duration("healthy_interval", schedule.HealthyInterval),
+		slog.Duration("retry_interval", schedule.RetryInterval),
+		slog.Duration("max_backoff", schedule.MaxBackoff),
+		slog.Float64("jitter", schedule.Jitter))
+
+	// Optional retention policy, such as "hours=72" or "hours=72;mirrornames:hours=24,failures=3"
+	retention, err := reattest.ParseRetentionPolicy(os.Getenv("REATTEST_RETENTION"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REATTEST_RETENTION: %w", err)
+	}
+	log.Info("Retention policy configured", slog.String("retention", retention.String()))
+
+	// Optional concurrency settings
+	workers, err := positiveIntEnv("REATTEST_WORKERS", reattest.DefaultWorkers)
+	if err != nil {
+		return nil, err
+	}
+	lookupWorkers, err := positiveIntEnv("REATTEST_LOOKUP_WORKERS", attestation.DefaultLookupWorkers)
+	if err != nil {
+		return nil, err
+	}
+	dnsLimits := dnsclaims.DefaultLimits()
+	if dnsLimits.Concurrency, err = positiveIntEnv("DNS_MAX_CONCURRENCY", dnsLimits.Concurrency); err != nil {
+		return nil, err
+	}
+	if rateStr := os.Getenv("DNS_NAMESERVER_RATE"); rateStr != "" {
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid DNS_NAMESERVER_RATE %q: must be a positive number", rateStr)
+		}
+		dnsLimits.NameserverRate = rate
+	}
+	log.Info("Concurrency configured",
+		slog.Int("workers", workers),
+		slog.Int("lookup_workers", lookupWorkers),
+		slog.Int("dns_max_concurrency", dnsLimits.Concurrency),
+		slog.Float64("dns_nameserver_rate", dnsLimits.NameserverRate))
 
 	return &Handler{
 		log:              log,
 		dynamoTable:      dynamoTable,
+		archiveTable:     archiveTable,
+		archiveRetention: archiveRetention,
 		s3BucketName:     s3BucketName,
 		s3DataKey:        s3DataKey,
-		gracePeriodHours: gracePeriodHours,
+		s3StateKey:       s3StateKey,
+		deadlineMargin:   deadlineMargin,
+		schedule:         schedule,
+		breakerThreshold: breakerThreshold,
+		retention:        retention,
+		workers:          workers,
+		lookupWorkers:    lookupWorkers,
+		dnsLimits:        dnsLimits,
 	}, nil
 }
 
-// Handle processes scheduled Lambda events for batch re-attestation
-func (h *Handler) Handle(ctx context.Context, event map[string]interface{}) error {
+// dryRun re-attests the groups selected by the use case's filter and logs the results, without changing anything
+func (h *Handler) dryRun(ctx context.Context, requestLogger *slog.Logger, reattestUC *reattest.ReattestUseCase) error {
+	results, err := reattestUC.ReattestAll(ctx)
+	if err != nil {
+		requestLogger.Error("Failed to re-attest groups", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to re-attest groups: %w", err)
+	}
+
+	counts := map[reattest.Outcome]int{}
+	for _, result := range results {
+		counts[result.Outcome]++
+		requestLogger.Info("Group re-attested (dry run)",
+			slog.String("group_id", result.GroupID),
+			slog.String("owner", result.Owner),
+			slog.String("type", result.Type),
+			slog.String("outcome", string(result.Outcome)),
+			slog.String("error", result.ErrorMessage))
+	}
+	requestLogger.Info("Re-attestation dry run completed",
+		slog.Int("groups_processed", len(results)),
+		slog.Int("groups_valid", counts[reattest.OutcomeValid]),
+		slog.Int("groups_invalid", counts[reattest.OutcomeInvalid]),
+		slog.Int("groups_indeterminate", counts[reattest.OutcomeIndeterminate]))
+	return nil
+}
+
+// durationEnv returns the non-negative duration in the environment variable name, or def if it is unset
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	str := os.Getenv(name)
+	if str == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a duration like 30s or 24h", name, str)
+	}
+	return d, nil
+}
+
+// positiveIntEnv returns the positive integer in the environment variable name, or def if it is unset
+func positiveIntEnv(name string, def int) (int, error) {
+	str := os.Getenv(name)
+	if str == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", name, str)
+	}
+	return n, nil
+}
+
+// Event is the payload of a reattestbatch invocation.
+// Scheduled invocations leave every field empty and re-attest the groups that are due.
+// An operator can invoke the Lambda with filters to re-attest only some groups right away,
+// with the same expansion as symval reattest: a group is re-attested whole if any of its records match.
+type Event struct {
+	Owners   []string `json:"owners,omitempty"`
+	Domains  []string `json:"domains,omitempty"`
+	GroupIDs []string `json:"groupIds,omitempty"`
+
+	// DryRun re-attests the groups and logs the results without changing anything
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// GracePeriodHours overrides the grace period of the default retention rule for this invocation
+	GracePeriodHours *int `json:"gracePeriodHours,omitempty"`
+}
+
+// Handle processes scheduled and manual Lambda events for batch re-attestation
+func (h *Handler) Handle(ctx context.Context, event Event) error {
 	// Create a logger with Lambda context
 	requestLogger := logger.WithLambda(h.log,
 		os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
 		os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
 		"") // No request ID for scheduled events
 
-	requestLogger.Info("Scheduled Lambda triggered", slog.Any("event", event))
+	requestLogger.Info("Lambda triggered", slog.Any("event", event))
+
+	// Apply any grace period override to a copy of the policy, which is shared by all invocations
+	retention := h.retention
+	if event.GracePeriodHours != nil {
+		if *event.GracePeriodHours < 0 {
+			return fmt.Errorf("invalid gracePeriodHours %d: must not be negative", *event.GracePeriodHours)
+		}
+		override := *h.retention
+		override.Default.GracePeriod = time.Duration(*event.GracePeriodHours) * time.Hour
+		retention = &override
+	}
 
 	// Initialize AWS clients
 	cfg, err := config.LoadDefaultConfig(ctx)
@@ -101,12 +294,29 @@ func (h *Handler) Handle(ctx context.Context, event map[string]interface{}) erro
 		return fmt.Errorf("failed to load data from S3: %w", err)
 	}
 
-	// Create DNS service for attestation
-	dnsService := dnsclaims.NewService()
+	// Create DNS service for attestation, limited so that concurrent groups do not flood any nameserver
+	dnsService := dnsclaims.NewLimitedService(h.dnsLimits)
 
 	// Create reattest use case with DynamoDB support
 	reattestUC := reattest.NewReattestUseCaseWithDynamo(dnsService, memRepo, h.dynamoRepo)
-	reattestUC.SetGracePeriod(h.gracePeriodHours)
+	reattestUC.SetRetentionPolicy(retention)
+	reattestUC.SetWorkers(h.workers, h.lookupWorkers)
+	reattestUC.SetStateStore(s3materializedview.NewStateStore(s3Client, h.s3BucketName, h.s3StateKey))
+	reattestUC.SetDeadlineMargin(h.deadlineMargin)
+	reattestUC.SetSchedule(h.schedule)
+	reattestUC.SetCircuitBreaker(h.breakerThreshold, reattest.DefaultBreakerMinGroups)
+	if h.archiveTable != "" {
+		reattestUC.SetArchive(dynamorepo.NewDynamoArchiveRepository(dynamoClient, h.archiveTable), h.archiveRetention)
+	}
+	reattestUC.SetFilter(model.RecordFilter{
+		Owners:   event.Owners,
+		Domains:  event.Domains,
+		GroupIDs: event.GroupIDs,
+	})
+
+	if event.DryRun {
+		return h.dryRun(ctx, requestLogger, reattestUC)
+	}
 
 	// Perform re-attestation and update/delete as needed
 	results, stats, err := reattestUC.ReattestAllAndUpdate(ctx)
@@ -125,36 +335,63 @@ func (h *Handler) Handle(ctx context.Context, event map[string]interface{}) erro
 			slog.String("type", result.Type),
 			slog.Int("record_count", len(result.Records)))
 
-		if result.IsValid {
-			groupLogger.Info("Group attestation succeeded")
-		} else {
-			// Check if group was within grace period or deleted
-			var oldestValidation time.Time
-			for _, record := range result.Records {
-				if oldestValidation.IsZero() || record.ValidateTime.Before(oldestValidation) {
-					oldestValidation = record.ValidateTime
-				}
-			}
-			hoursSinceValidation := time.Since(oldestValidation).Hours()
-
-			if hoursSinceValidation > float64(h.gracePeriodHours) {
-				groupLogger.Warn("Group attestation failed, grace period exceeded (deleted)",
-					slog.String("error", result.ErrorMessage),
-					slog.Float64("hours_since_validation", hoursSinceValidation),
-					slog.Int("grace_period_hours", h.gracePeriodHours))
-			} else {
-				groupLogger.Info("Group attestation failed, within grace period (skipped)",
-					slog.String("error", result.ErrorMessage),
-					slog.Float64("hours_since_validation", hoursSinceValidation),
-					slog.Int("grace_period_hours", h.gracePeriodHours))
+		// Get the oldest validation time from the group, for context on failures
+		var oldestValidation time.Time
+		for _, record := range result.Records {
+			if oldestValidation.IsZero() || record.ValidateTime.Before(oldestValidation) {
+				oldestValidation = record.ValidateTime
 			}
 		}
+		hoursSinceValidation := time.Since(oldestValidation).Hours()
+
+		switch {
+		case result.Outcome == reattest.OutcomeValid:
+			groupLogger.Info("Group attestation succeeded")
+		case result.Outcome == reattest.OutcomeIndeterminate:
+			groupLogger.Warn("Group attestation indeterminate, DNS lookups failed (skipped)",
+				slog.String("error", result.ErrorMessage),
+				slog.Float64("hours_since_validation", hoursSinceValidation))
+		case result.Removed:
+			groupLogger.Warn("Group attestation failed, retention expired (deleted)",
+				slog.String("error", result.ErrorMessage),
+				slog.Float64("hours_since_validation", hoursSinceValidation),
+				slog.String("retention_rule", retention.Rule(symgroup.SymmetryType(result.Type)).String()))
+		default:
+			groupLogger.Info("Group attestation failed, not removed (skipped)",
+				slog.String("error", result.ErrorMessage),
+				slog.Float64("hours_since_validation", hoursSinceValidation),
+				slog.String("retention_rule", retention.Rule(symgroup.SymmetryType(result.Type)).String()))
+		}
 	}
 
+	if stats.CircuitOpen {
+		requestLogger.Error("Re-attestation stopped by circuit breaker; no groups were removed",
+			slog.Bool("notify", true),
+			slog.String("reason", stats.StopReason),
+			slog.Int("groups_indeterminate", stats.GroupsIndeterminate),
+			slog.Int("groups_processed", stats.GroupsProcessed))
+	}
+
+	pass := "complete"
+	if !stats.Complete {
+		pass = "partial"
+	}
 	requestLogger.Info("Re-attestation completed",
+		slog.String("pass", pass),
+		slog.String("resumed_from", stats.ResumedFrom),
+		slog.String("cursor", stats.Cursor),
+		slog.Int("groups_due", stats.GroupsDue),
+		slog.Int("groups_not_due", stats.GroupsNotDue),
 		slog.Int("groups_processed", stats.GroupsProcessed),
+		slog.Int("groups_valid", stats.GroupsValid),
+		slog.Int("groups_invalid", stats.GroupsInvalid),
+		slog.Int("groups_indeterminate", stats.GroupsIndeterminate),
+		slog.Int("groups_remaining", stats.GroupsRemaining),
+		slog.String("stop_reason", stats.StopReason),
 		slog.Int("records_updated", stats.RecordsUpdated),
+		slog.Int("records_failed", stats.RecordsFailed),
 		slog.Int("records_deleted", stats.RecordsDeleted),
+		slog.Int("records_archived", stats.RecordsArchived),
 		slog.Int("records_skipped", stats.RecordsSkipped),
 		slog.Int("errors", stats.Errors))
 
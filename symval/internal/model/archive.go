@@ -0,0 +1,39 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrArchiveNotFound = errors.New("archived group not found")
+
+// ArchivedRecord is a domain record removed by reattestation, kept so that its group can be restored
+// with its original history if its owner fixes DNS
+type ArchivedRecord struct {
+	Record        *DomainRecord
+	ArchiveTime   time.Time // When the record was removed
+	ExpireTime    time.Time // The archived record is deleted after this time
+	ArchiveReason string    `json:",omitempty"` // Why the record was removed
+}
+
+// ArchiveRepository defines the interface for storing and retrieving archived domain records.
+// Archived records are kept apart from the domain records, so that they never reach the public view.
+type ArchiveRepository interface {
+	// ArchiveRecord saves an archived record, replacing any archived copy of the same group ID and hostname
+	ArchiveRecord(ctx context.Context, archived *ArchivedRecord) error
+
+	// ListArchived retrieves all archived records that have not expired
+	ListArchived(ctx context.Context) ([]*ArchivedRecord, error)
+
+	// ListArchivedByGroupID retrieves the archived records of a group that have not expired
+	ListArchivedByGroupID(ctx context.Context, groupID string) ([]*ArchivedRecord, error)
+
+	// DeleteArchived removes an archived record by group ID and hostname
+	DeleteArchived(ctx context.Context, groupID, hostname string) error
+}
+
+// Expired reports whether the archived record is at or past its expiry time
+func (a *ArchivedRecord) Expired(now time.Time) bool {
+	return !a.ExpireTime.IsZero() && !now.Before(a.ExpireTime)
+}
@@ -2,9 +2,13 @@ package model
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/mrled/suns/symval/internal/idn"
+	"github.com/mrled/suns/symval/internal/psl"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
 
@@ -20,9 +24,10 @@ type DomainRepository interface {
 	UnconditionalStore(ctx context.Context, data *DomainRecord) (int64, error)
 
 	// Upsert uses UpdateItem + rev = if_not_exists(rev,0)+1. This is called by the webhook/API in response to user requests. Returns new rev.
+	// An existing record keeps its FirstAttestedTime, LastFailureTime and LastFailureReason.
 	Upsert(ctx context.Context, data *DomainRecord) (int64, error)
 
-	// SetValidationIfUnchanged uses ConditionExpression rev = :snapshotRev to ensure that it only updates items that have not changed. Should only set a new validation date. Returns new rev.
+	// SetValidationIfUnchanged uses ConditionExpression rev = :snapshotRev to ensure that it only updates items that have not changed. Should only set the validation date and check history (all but FirstAttestedTime, which is only set if missing). Returns new rev.
 	SetValidationIfUnchanged(ctx context.Context, data *DomainRecord, snapshotRev int64) (int64, error)
 
 	// Get retrieves domain data by group ID and domain name (the composite key)
@@ -31,6 +36,12 @@ type DomainRepository interface {
 	// List retrieves all domain data
 	List(ctx context.Context) ([]*DomainRecord, error)
 
+	// ListByHostname retrieves domain data for a hostname across all groups
+	ListByHostname(ctx context.Context, hostname string) ([]*DomainRecord, error)
+
+	// ListByGroupID retrieves domain data for every hostname in a group
+	ListByGroupID(ctx context.Context, groupID string) ([]*DomainRecord, error)
+
 	// UnconditionalDelete removes domain data by group ID and domain name (the composite key) (new name for existing Delete method)
 	UnconditionalDelete(ctx context.Context, groupID, domain string) error
 
@@ -40,12 +51,141 @@ type DomainRepository interface {
 
 // DomainRecord represents domain validation information
 type DomainRecord struct {
-	Owner        string
-	Type         symgroup.SymmetryType
-	Hostname     string
-	GroupID      string
-	ValidateTime time.Time
-	Rev          int64 // Monotonically increasing revision number
+	Owner             string
+	Type              symgroup.SymmetryType
+	Hostname          string      // A-label (punycode) form; the key used for storage, group IDs and DNS lookups
+	UnicodeHostname   string      `json:",omitempty"` // U-label form of Hostname, for display and symmetry checks
+	IDNSafety         idn.Verdict `json:",omitempty"` // Homograph check result; "punycode" records should be displayed by Hostname
+	RegistrableDomain string      `json:",omitempty"` // eTLD+1 of Hostname, from the Public Suffix List
+	GroupID           string
+	ValidateTime      time.Time // When the record last passed attestation
+	Rev               int64     // Monotonically increasing revision number
+
+	// Check history, kept up to date by attestation and reattestation.
+	// Times are zero if the event has not happened, or happened before they were tracked.
+	FirstAttestedTime   time.Time // When the record was first attested; kept when the group is attested again
+	LastCheckTime       time.Time // When the record was last checked conclusively (valid or definitively invalid)
+	LastFailureTime     time.Time // When the record last failed a check
+	ConsecutiveFailures int       `json:",omitempty"` // Failed checks since the record last passed
+	LastFailureReason   string    `json:",omitempty"` // Why the record last failed a check
+}
+
+// MarshalJSON leaves out check history times that are zero, so that records stored before they were tracked,
+// and events that have not happened, do not show up as the year 1
+func (r DomainRecord) MarshalJSON() ([]byte, error) {
+	type plain DomainRecord
+	return json.Marshal(struct {
+		plain
+		FirstAttestedTime *time.Time `json:",omitempty"`
+		LastCheckTime     *time.Time `json:",omitempty"`
+		LastFailureTime   *time.Time `json:",omitempty"`
+	}{
+		plain:             plain(r),
+		FirstAttestedTime: nonZeroTime(r.FirstAttestedTime),
+		LastCheckTime:     nonZeroTime(r.LastCheckTime),
+		LastFailureTime:   nonZeroTime(r.LastFailureTime),
+	})
+}
+
+// nonZeroTime returns a pointer to t, or nil if t is zero
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// NormalizeHostname canonicalizes Hostname (RFC 1123 syntax, UTS-46 normalized),
+// setting Hostname to its A-label form and UnicodeHostname to its U-label form
+func (r *DomainRecord) NormalizeHostname() error {
+	ascii, err := hostname.Canonicalize(r.Hostname)
+	if err != nil {
+		return err
+	}
+	r.Hostname = ascii
+	r.UnicodeHostname = idn.ToUnicode(ascii)
+	return nil
+}
+
+// DisplayHostname returns the U-label form of the hostname,
+// decoding Hostname for records stored before UnicodeHostname existed
+func (r *DomainRecord) DisplayHostname() string {
+	if r.UnicodeHostname != "" {
+		return r.UnicodeHostname
+	}
+	return idn.ToUnicode(r.Hostname)
+}
+
+// Safety returns the homograph check result for the hostname,
+// assessing Hostname for records stored before IDNSafety existed
+func (r *DomainRecord) Safety() idn.Verdict {
+	if r.IDNSafety != "" {
+		return r.IDNSafety
+	}
+	return idn.Assess(r.Hostname).Verdict
+}
+
+// Registrable returns the registrable domain (eTLD+1) of the hostname,
+// computing it for records stored before RegistrableDomain existed
+func (r *DomainRecord) Registrable() string {
+	if r.RegistrableDomain != "" {
+		return r.RegistrableDomain
+	}
+	return psl.RegistrableDomain(r.Hostname)
+}
+
+// ListedHostname returns the form of the hostname that should be shown in listings:
+// the U-label form, unless the homograph check says it should be displayed as punycode
+func (r *DomainRecord) ListedHostname() string {
+	if r.Safety() != idn.VerdictOK {
+		return r.Hostname
+	}
+	return r.DisplayHostname()
+}
+
+// MaxFailureReasonLength is the longest LastFailureReason kept; longer reasons are truncated
+const MaxFailureReasonLength = 500
+
+// RecordPass notes that the record passed a check at t
+func (r *DomainRecord) RecordPass(t time.Time) {
+	r.ValidateTime = t
+	r.LastCheckTime = t
+	r.ConsecutiveFailures = 0
+}
+
+// RecordFailure notes that the record definitively failed a check at t, for reason
+func (r *DomainRecord) RecordFailure(t time.Time, reason string) {
+	if len(reason) > MaxFailureReasonLength {
+		reason = reason[:MaxFailureReasonLength-3] + "..."
+	}
+	r.LastCheckTime = t
+	r.LastFailureTime = t
+	r.ConsecutiveFailures++
+	r.LastFailureReason = reason
+}
+
+// GroupByRegistrableDomain groups domain records by their registrable domain (eTLD+1)
+func GroupByRegistrableDomain(records []*DomainRecord) map[string][]*DomainRecord {
+	grouped := make(map[string][]*DomainRecord)
+
+	for _, record := range records {
+		registrable := record.Registrable()
+		grouped[registrable] = append(grouped[registrable], record)
+	}
+
+	return grouped
+}
+
+// GroupByHostname groups domain records by their Hostname.
+// A hostname can belong to several groups, so each hostname may map to more than one record.
+func GroupByHostname(records []*DomainRecord) map[string][]*DomainRecord {
+	grouped := make(map[string][]*DomainRecord)
+
+	for _, record := range records {
+		grouped[record.Hostname] = append(grouped[record.Hostname], record)
+	}
+
+	return grouped
 }
 
 // GroupByGroupID groups domain records by their GroupID
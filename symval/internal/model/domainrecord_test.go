@@ -1,6 +1,11 @@
 package model
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestGroupByGroupID(t *testing.T) {
 	records := []*DomainRecord{
@@ -20,3 +25,88 @@ func TestGroupByGroupID(t *testing.T) {
 		t.Errorf("expected 1 record in group2, got %d", len(grouped["group2"]))
 	}
 }
+
+func TestGroupByRegistrableDomain(t *testing.T) {
+	records := []*DomainRecord{
+		{GroupID: "group1", Hostname: "zq.suns.bz"},
+		{GroupID: "group2", Hostname: "www.suns.bz"},
+		{GroupID: "group3", Hostname: "a.example.co.uk", RegistrableDomain: "example.co.uk"},
+		{GroupID: "group3", Hostname: "b.example.co.uk"},
+	}
+
+	grouped := GroupByRegistrableDomain(records)
+	if len(grouped) != 2 {
+		t.Errorf("expected 2 registrable domains, got %d", len(grouped))
+	}
+	if len(grouped["suns.bz"]) != 2 {
+		t.Errorf("expected 2 records under suns.bz, got %d", len(grouped["suns.bz"]))
+	}
+	if len(grouped["example.co.uk"]) != 2 {
+		t.Errorf("expected 2 records under example.co.uk, got %d", len(grouped["example.co.uk"]))
+	}
+}
+
+func TestGroupByHostname(t *testing.T) {
+	records := []*DomainRecord{
+		{GroupID: "group1", Hostname: "example.com"},
+		{GroupID: "group2", Hostname: "example.com"},
+		{GroupID: "group2", Hostname: "com.example"},
+	}
+
+	grouped := GroupByHostname(records)
+	if len(grouped) != 2 {
+		t.Errorf("expected 2 hostnames, got %d", len(grouped))
+	}
+	if len(grouped["example.com"]) != 2 {
+		t.Errorf("expected example.com in 2 groups, got %d", len(grouped["example.com"]))
+	}
+	if len(grouped["com.example"]) != 1 {
+		t.Errorf("expected com.example in 1 group, got %d", len(grouped["com.example"]))
+	}
+}
+
+func TestDomainRecordCheckHistory(t *testing.T) {
+	start := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	record := &DomainRecord{ValidateTime: start, FirstAttestedTime: start}
+
+	record.RecordFailure(start.Add(time.Hour), "no record")
+	record.RecordFailure(start.Add(2*time.Hour), strings.Repeat("x", 2*MaxFailureReasonLength))
+	if record.ConsecutiveFailures != 2 || !record.LastFailureTime.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("after 2 failures: %d consecutive, last at %v", record.ConsecutiveFailures, record.LastFailureTime)
+	}
+	if len(record.LastFailureReason) != MaxFailureReasonLength || !strings.HasSuffix(record.LastFailureReason, "...") {
+		t.Errorf("expected the reason truncated to %d bytes, got %d", MaxFailureReasonLength, len(record.LastFailureReason))
+	}
+	if !record.ValidateTime.Equal(start) {
+		t.Errorf("a failure changed ValidateTime to %v", record.ValidateTime)
+	}
+
+	record.RecordPass(start.Add(3 * time.Hour))
+	if record.ConsecutiveFailures != 0 || !record.ValidateTime.Equal(start.Add(3*time.Hour)) || !record.LastCheckTime.Equal(record.ValidateTime) {
+		t.Errorf("after a pass: %+v", record)
+	}
+	if record.LastFailureTime.IsZero() || !record.FirstAttestedTime.Equal(start) {
+		t.Errorf("a pass should keep the first attestation and last failure: %+v", record)
+	}
+}
+
+func TestDomainRecordJSONOmitsZeroTimes(t *testing.T) {
+	record := DomainRecord{Hostname: "example.com", ValidateTime: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)}
+	record.LastCheckTime = record.ValidateTime
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "FirstAttestedTime") || strings.Contains(string(data), "LastFailureTime") {
+		t.Errorf("expected zero times to be left out, got %s", data)
+	}
+
+	var decoded DomainRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.LastCheckTime.Equal(record.LastCheckTime) || !decoded.FirstAttestedTime.IsZero() {
+		t.Errorf("round trip: got %+v, want %+v", decoded, record)
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/idn"
+)
 
 // RecordFilter contains criteria for filtering domain records with multiple values per field.
 // All criteria are optional; only non-empty slices are applied.
@@ -13,7 +17,7 @@ type RecordFilter struct {
 	// GroupIDs filters by exact group ID matches (OR within list)
 	GroupIDs []string
 
-	// Domains filters by hostnames (case-insensitive, OR within list)
+	// Domains filters by hostnames (A-label or U-label form, case-insensitive, OR within list)
 	Domains []string
 
 	// Types filters by symmetry types (OR within list)
@@ -37,7 +41,7 @@ func FilterRecords(records []*DomainRecord, filter RecordFilter) []*DomainRecord
 
 	domainMap := make(map[string]bool)
 	for _, domain := range filter.Domains {
-		domainMap[strings.ToLower(domain)] = true
+		domainMap[filterHostname(domain)] = true
 	}
 
 	groupIDMap := make(map[string]bool)
@@ -59,7 +63,7 @@ func FilterRecords(records []*DomainRecord, filter RecordFilter) []*DomainRecord
 		}
 
 		// Apply domain filter (case-insensitive)
-		if len(filter.Domains) > 0 && !domainMap[strings.ToLower(record.Hostname)] {
+		if len(filter.Domains) > 0 && !domainMap[filterHostname(record.Hostname)] {
 			continue
 		}
 
@@ -78,3 +82,12 @@ func FilterRecords(records []*DomainRecord, filter RecordFilter) []*DomainRecord
 
 	return filtered
 }
+
+// filterHostname returns the A-label form of a hostname for comparison,
+// falling back to the lowercased input if it is not a valid IDNA name
+func filterHostname(hostname string) string {
+	if ascii, err := idn.ToASCII(hostname); err == nil {
+		return ascii
+	}
+	return strings.ToLower(hostname)
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+// OrderStatus is the state of an attestation order
+type OrderStatus string
+
+const (
+	// OrderPending orders are waiting for their TXT records to be published and the order finalized
+	OrderPending OrderStatus = "pending"
+
+	// OrderValid orders were finalized and the group attested
+	OrderValid OrderStatus = "valid"
+
+	// OrderExpired orders were not finalized before their expiry time.
+	// This status is never stored; pending orders are reported as expired once their time has passed.
+	OrderExpired OrderStatus = "expired"
+)
+
+// OrderRepository defines the interface for storing and retrieving attestation orders
+type OrderRepository interface {
+	// StoreOrder saves an order, replacing any order with the same ID
+	StoreOrder(ctx context.Context, order *Order) error
+
+	// GetOrder retrieves an order by ID, returning ErrOrderNotFound if there is none
+	GetOrder(ctx context.Context, id string) (*Order, error)
+}
+
+// OrderRecord is a TXT record that must be published for an order to be finalized
+type OrderRecord struct {
+	Hostname string // The domain in the group
+	Name     string // Where to publish the record: the _suns label, or its CNAME target
+	Value    string // The expected group ID
+}
+
+// Order is a request to attest a group, created before its TXT records are published
+type Order struct {
+	ID           string
+	Owner        string
+	Type         symgroup.SymmetryType
+	Domains      []string // Canonical hostnames
+	ExpectedID   string
+	Records      []OrderRecord
+	Status       OrderStatus
+	CreateTime   time.Time
+	ExpireTime   time.Time // Pending orders can no longer be finalized after this time
+	FinalizeTime time.Time `json:",omitempty"` // When the order became valid
+	LastError    string    `json:",omitempty"` // Why the last finalize attempt failed, if it did
+}
+
+// Expired reports whether the order is still pending at or after its expiry time
+func (o *Order) Expired(now time.Time) bool {
+	return o.Status == OrderPending && !now.Before(o.ExpireTime)
+}
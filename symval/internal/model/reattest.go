@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// ReattestState records how far a reattest pass has got, so that a pass too long for one run
+// can be resumed by the next run instead of starting over, and when each group is next due
+type ReattestState struct {
+	Cursor            string                   // Last group ID processed in the current pass; empty at the start of a pass
+	PassStartTime     time.Time                // When the current pass began
+	LastCompletedTime time.Time                // When the last complete pass finished
+	UpdateTime        time.Time                // When the state was last saved
+	Groups            map[string]GroupSchedule `json:",omitempty"` // Schedule of each group by group ID
+}
+
+// GroupSchedule is when a group is next due to be re-attested.
+// How many times in a row the group has failed is kept on its records (DomainRecord.ConsecutiveFailures), not here.
+type GroupSchedule struct {
+	NextCheck time.Time
+}
+
+// Clone returns a copy of the state that shares nothing with it
+func (s *ReattestState) Clone() *ReattestState {
+	clone := *s
+	if s.Groups != nil {
+		clone.Groups = make(map[string]GroupSchedule, len(s.Groups))
+		for groupID, schedule := range s.Groups {
+			clone.Groups[groupID] = schedule
+		}
+	}
+	return &clone
+}
+
+// ReattestStateStore defines the interface for saving and loading reattest state
+type ReattestStateStore interface {
+	// LoadReattestState retrieves the saved state, returning an empty state if none has been saved
+	LoadReattestState(ctx context.Context) (*ReattestState, error)
+
+	// SaveReattestState saves the state, replacing any saved state
+	SaveReattestState(ctx context.Context, state *ReattestState) error
+}
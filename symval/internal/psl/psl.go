@@ -0,0 +1,48 @@
+// Package psl answers Public Suffix List questions about hostnames.
+// The list is compiled into the binary by golang.org/x/net/publicsuffix,
+// so lookups need no network access and are consistent across the CLI and the Lambdas.
+package psl
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// PublicSuffix returns the public suffix of host, like "co.uk" for "www.example.co.uk".
+// listed is false if no rule on the list matched and the suffix is just the last label.
+func PublicSuffix(host string) (suffix string, listed bool) {
+	host = canonical(host)
+	suffix, icann := publicsuffix.PublicSuffix(host)
+
+	// Rules in the private section always have more than one label,
+	// so a single-label suffix that is not ICANN-managed came from the implicit "*" rule
+	listed = icann || strings.Contains(suffix, ".")
+	return suffix, listed
+}
+
+// IsPublicSuffix reports whether host is itself on the Public Suffix List,
+// like "com", "co.uk" or "github.io". Names that no one can register below are never claimable.
+func IsPublicSuffix(host string) bool {
+	host = canonical(host)
+	suffix, listed := PublicSuffix(host)
+	return listed && suffix == host
+}
+
+// RegistrableDomain returns the registrable domain (eTLD+1) of host,
+// like "example.co.uk" for "www.example.co.uk".
+// If host has no registrable domain, because it is itself a public suffix
+// or has a single label, host is returned unchanged.
+func RegistrableDomain(host string) string {
+	host = canonical(host)
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return registrable
+}
+
+// canonical lowercases host and removes any trailing dot
+func canonical(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
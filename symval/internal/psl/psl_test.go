@@ -0,0 +1,66 @@
+package psl
+
+import "testing"
+
+func TestPublicSuffix(t *testing.T) {
+	tests := []struct {
+		host       string
+		wantSuffix string
+		wantListed bool
+	}{
+		{"www.example.com", "com", true},
+		{"www.example.co.uk", "co.uk", true},
+		{"alice.github.io", "github.io", true},
+		{"abc.notatld", "notatld", false},
+		{"aba", "aba", false},
+	}
+
+	for _, tt := range tests {
+		suffix, listed := PublicSuffix(tt.host)
+		if suffix != tt.wantSuffix || listed != tt.wantListed {
+			t.Errorf("PublicSuffix(%q) = %q, %v, want %q, %v", tt.host, suffix, listed, tt.wantSuffix, tt.wantListed)
+		}
+	}
+}
+
+func TestIsPublicSuffix(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"com", true},
+		{"co.uk", true},
+		{"CO.UK.", true},
+		{"github.io", true},
+		{"example.com", false},
+		{"example.co.uk", false},
+		{"aba", false}, // Unlisted single labels only match the implicit rule
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicSuffix(tt.host); got != tt.want {
+			t.Errorf("IsPublicSuffix(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"a.b.example.co.uk", "example.co.uk"},
+		{"alice.github.io", "alice.github.io"},
+		{"zq.suns.bz", "suns.bz"},
+		{"co.uk", "co.uk"},
+		{"aba", "aba"},
+	}
+
+	for _, tt := range tests {
+		if got := RegistrableDomain(tt.host); got != tt.want {
+			t.Errorf("RegistrableDomain(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
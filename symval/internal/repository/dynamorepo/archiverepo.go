@@ -0,0 +1,155 @@
+package dynamorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// ArchiveDTO represents an archived domain record in the DynamoDB archive table.
+// The table has the same keys as the domain table, and uses ExpiresAt as its TTL attribute.
+type ArchiveDTO struct {
+	DynamoDTO
+	ArchiveTime   time.Time `dynamodbav:"ArchiveTime"`
+	ExpireTime    time.Time `dynamodbav:"ExpireTime"`
+	ArchiveReason string    `dynamodbav:"ArchiveReason,omitempty"`
+
+	// ExpiresAt is ExpireTime in Unix seconds, for DynamoDB TTL.
+	// DynamoDB deletes expired items lazily, so readers must still check ExpireTime.
+	ExpiresAt int64 `dynamodbav:"ExpiresAt,omitempty"`
+}
+
+// ToArchived converts an ArchiveDTO to a domain model ArchivedRecord
+func (dto *ArchiveDTO) ToArchived() *model.ArchivedRecord {
+	return &model.ArchivedRecord{
+		Record:        dto.DynamoDTO.ToDomain(),
+		ArchiveTime:   dto.ArchiveTime,
+		ExpireTime:    dto.ExpireTime,
+		ArchiveReason: dto.ArchiveReason,
+	}
+}
+
+// FromArchived creates an ArchiveDTO from a domain model ArchivedRecord
+func FromArchived(archived *model.ArchivedRecord) *ArchiveDTO {
+	dto := &ArchiveDTO{
+		DynamoDTO:     *FromDomain(archived.Record),
+		ArchiveTime:   archived.ArchiveTime,
+		ExpireTime:    archived.ExpireTime,
+		ArchiveReason: archived.ArchiveReason,
+	}
+	if !archived.ExpireTime.IsZero() {
+		dto.ExpiresAt = archived.ExpireTime.Unix()
+	}
+	return dto
+}
+
+// DynamoArchiveRepository is a DynamoDB implementation of ArchiveRepository
+type DynamoArchiveRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoArchiveRepository creates a new DynamoDB-backed archive repository.
+// Archived records are kept in their own table so that they never reach the domain record stream or the public view.
+func NewDynamoArchiveRepository(client *dynamodb.Client, tableName string) *DynamoArchiveRepository {
+	return &DynamoArchiveRepository{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// ArchiveRecord saves an archived record to DynamoDB, replacing any archived copy of the same record
+func (r *DynamoArchiveRepository) ArchiveRecord(ctx context.Context, archived *model.ArchivedRecord) error {
+	if archived == nil || archived.Record == nil {
+		return fmt.Errorf("archived record cannot be nil")
+	}
+
+	item, err := attributevalue.MarshalMap(FromArchived(archived))
+	if err != nil {
+		return fmt.Errorf("failed to marshal archived record: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive record: %w", err)
+	}
+
+	return nil
+}
+
+// ListArchived retrieves all archived records that have not expired from DynamoDB
+func (r *DynamoArchiveRepository) ListArchived(ctx context.Context) ([]*model.ArchivedRecord, error) {
+	// Use Scan to retrieve all items
+	items, err := scanItems(ctx, r.client, &dynamodb.ScanInput{
+		TableName: aws.String(r.tableName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan archived records: %w", err)
+	}
+
+	return unmarshalArchived(items)
+}
+
+// ListArchivedByGroupID retrieves the archived records of a group that have not expired from DynamoDB
+func (r *DynamoArchiveRepository) ListArchivedByGroupID(ctx context.Context, groupID string) ([]*model.ArchivedRecord, error) {
+	// The group ID is the partition key, so this is a Query
+	items, err := queryItems(ctx, r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("pk = :groupID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":groupID": &types.AttributeValueMemberS{Value: groupID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived records for %s: %w", groupID, err)
+	}
+
+	return unmarshalArchived(items)
+}
+
+// DeleteArchived removes an archived record by group ID and hostname from DynamoDB
+func (r *DynamoArchiveRepository) DeleteArchived(ctx context.Context, groupID, hostname string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: groupID},
+			"sk": &types.AttributeValueMemberS{Value: hostname},
+		},
+		ConditionExpression: aws.String("attribute_exists(pk) AND attribute_exists(sk)"),
+	})
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return model.ErrArchiveNotFound
+		}
+		return fmt.Errorf("failed to delete archived record: %w", err)
+	}
+
+	return nil
+}
+
+// unmarshalArchived converts archive table items to archived records, leaving out those that have expired
+func unmarshalArchived(items []map[string]types.AttributeValue) ([]*model.ArchivedRecord, error) {
+	now := time.Now()
+	var archived []*model.ArchivedRecord
+	for _, item := range items {
+		var dto ArchiveDTO
+		if err := attributevalue.UnmarshalMap(item, &dto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal archived record: %w", err)
+		}
+		if a := dto.ToArchived(); !a.Expired(now) {
+			archived = append(archived, a)
+		}
+	}
+	return archived, nil
+}
@@ -3,6 +3,7 @@ package dynamorepo
 import (
 	"time"
 
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
@@ -12,36 +13,80 @@ import (
 // - PK (partition key) is the GroupID
 // - SK (sort key) is the Hostname
 type DynamoDTO struct {
-	PK           string                `dynamodbav:"pk"` // Partition Key - maps from GroupID
-	SK           string                `dynamodbav:"sk"` // Sort Key - maps from Hostname
-	Owner        string                `dynamodbav:"Owner"`
-	Type         symgroup.SymmetryType `dynamodbav:"Type"`
-	ValidateTime time.Time             `dynamodbav:"ValidateTime"`
-	Rev          int64                 `dynamodbav:"Rev"` // Monotonically increasing revision number
+	PK                string                `dynamodbav:"pk"` // Partition Key - maps from GroupID
+	SK                string                `dynamodbav:"sk"` // Sort Key - maps from Hostname (A-label form)
+	UnicodeHostname   string                `dynamodbav:"UnicodeHostname,omitempty"`
+	IDNSafety         idn.Verdict           `dynamodbav:"IDNSafety,omitempty"`
+	RegistrableDomain string                `dynamodbav:"RegistrableDomain,omitempty"`
+	Owner             string                `dynamodbav:"Owner"`
+	Type              symgroup.SymmetryType `dynamodbav:"Type"`
+	ValidateTime      time.Time             `dynamodbav:"ValidateTime"`
+	Rev               int64                 `dynamodbav:"Rev"` // Monotonically increasing revision number
+
+	// Check history; pointers so that unset times are left out of the item
+	FirstAttestedTime   *time.Time `dynamodbav:"FirstAttestedTime,omitempty"`
+	LastCheckTime       *time.Time `dynamodbav:"LastCheckTime,omitempty"`
+	LastFailureTime     *time.Time `dynamodbav:"LastFailureTime,omitempty"`
+	ConsecutiveFailures int        `dynamodbav:"ConsecutiveFailures,omitempty"`
+	LastFailureReason   string     `dynamodbav:"LastFailureReason,omitempty"`
 }
 
 // ToDomain converts a DynamoDTO to a domain model DomainRecord
 func (dto *DynamoDTO) ToDomain() *model.DomainRecord {
 	return &model.DomainRecord{
-		Owner:        dto.Owner,
-		Type:         dto.Type,
-		Hostname:     dto.SK,
-		GroupID:      dto.PK,
-		ValidateTime: dto.ValidateTime,
-		Rev:          dto.Rev,
+		Owner:             dto.Owner,
+		Type:              dto.Type,
+		Hostname:          dto.SK,
+		UnicodeHostname:   dto.UnicodeHostname,
+		IDNSafety:         dto.IDNSafety,
+		RegistrableDomain: dto.RegistrableDomain,
+		GroupID:           dto.PK,
+		ValidateTime:      dto.ValidateTime,
+		Rev:               dto.Rev,
+
+		FirstAttestedTime:   timeValue(dto.FirstAttestedTime),
+		LastCheckTime:       timeValue(dto.LastCheckTime),
+		LastFailureTime:     timeValue(dto.LastFailureTime),
+		ConsecutiveFailures: dto.ConsecutiveFailures,
+		LastFailureReason:   dto.LastFailureReason,
 	}
 }
 
 // FromDomain creates a DynamoDTO from a domain model DomainRecord
 func FromDomain(record *model.DomainRecord) *DynamoDTO {
 	return &DynamoDTO{
-		PK:           record.GroupID,
-		SK:           record.Hostname,
-		Owner:        record.Owner,
-		Type:         record.Type,
-		ValidateTime: record.ValidateTime,
-		Rev:          record.Rev,
+		PK:                record.GroupID,
+		SK:                record.Hostname,
+		UnicodeHostname:   record.UnicodeHostname,
+		IDNSafety:         record.IDNSafety,
+		RegistrableDomain: record.RegistrableDomain,
+		Owner:             record.Owner,
+		Type:              record.Type,
+		ValidateTime:      record.ValidateTime,
+		Rev:               record.Rev,
+
+		FirstAttestedTime:   timePointer(record.FirstAttestedTime),
+		LastCheckTime:       timePointer(record.LastCheckTime),
+		LastFailureTime:     timePointer(record.LastFailureTime),
+		ConsecutiveFailures: record.ConsecutiveFailures,
+		LastFailureReason:   record.LastFailureReason,
+	}
+}
+
+// timePointer returns a pointer to t, or nil if t is zero
+func timePointer(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// timeValue returns the time t points to, or the zero time if t is nil
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
 	}
+	return *t
 }
 
 // ToDomainList converts a slice of DynamoDTOs to domain model DomainRecords
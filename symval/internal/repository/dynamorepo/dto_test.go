@@ -1,9 +1,13 @@
 package dynamorepo
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
@@ -78,11 +82,14 @@ func TestRoundTripConversion(t *testing.T) {
 	testTime := time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC)
 
 	originalRecord := &model.DomainRecord{
-		Owner:        "charlie@example.com",
-		Type:         symgroup.MirrorText,
-		Hostname:     "mirror.example.com",
-		GroupID:      "mirror-group",
-		ValidateTime: testTime,
+		Owner:             "charlie@example.com",
+		Type:              symgroup.MirrorText,
+		Hostname:          "xn--mirror-cwb.example.com",
+		UnicodeHostname:   "mirrorɐ.example.com",
+		IDNSafety:         idn.VerdictOK,
+		RegistrableDomain: "example.com",
+		GroupID:           "mirror-group",
+		ValidateTime:      testTime,
 	}
 
 	// Convert to DTO and back
@@ -99,6 +106,15 @@ func TestRoundTripConversion(t *testing.T) {
 	if reconstructedRecord.Hostname != originalRecord.Hostname {
 		t.Errorf("Hostname mismatch: expected '%s', got '%s'", originalRecord.Hostname, reconstructedRecord.Hostname)
 	}
+	if reconstructedRecord.UnicodeHostname != originalRecord.UnicodeHostname {
+		t.Errorf("UnicodeHostname mismatch: expected '%s', got '%s'", originalRecord.UnicodeHostname, reconstructedRecord.UnicodeHostname)
+	}
+	if reconstructedRecord.IDNSafety != originalRecord.IDNSafety {
+		t.Errorf("IDNSafety mismatch: expected '%s', got '%s'", originalRecord.IDNSafety, reconstructedRecord.IDNSafety)
+	}
+	if reconstructedRecord.RegistrableDomain != originalRecord.RegistrableDomain {
+		t.Errorf("RegistrableDomain mismatch: expected '%s', got '%s'", originalRecord.RegistrableDomain, reconstructedRecord.RegistrableDomain)
+	}
 	if reconstructedRecord.GroupID != originalRecord.GroupID {
 		t.Errorf("GroupID mismatch: expected '%s', got '%s'", originalRecord.GroupID, reconstructedRecord.GroupID)
 	}
@@ -236,3 +252,152 @@ func TestEmptyListConversions(t *testing.T) {
 		t.Errorf("Expected empty record list, got %d items", len(convertedRecords))
 	}
 }
+
+func TestOrderDTORoundTrip(t *testing.T) {
+	expireTime := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	order := &model.Order{
+		ID:         "0123abcd",
+		Owner:      "alice@example.com",
+		Type:       symgroup.Palindrome,
+		Domains:    []string{"zb.snus.suns.bz"},
+		ExpectedID: "v1:p:owner:domains",
+		Records:    []model.OrderRecord{{Hostname: "zb.snus.suns.bz", Name: "_suns.zb.snus.suns.bz", Value: "v1:p:owner:domains"}},
+		Status:     model.OrderPending,
+		CreateTime: expireTime.Add(-24 * time.Hour),
+		ExpireTime: expireTime,
+	}
+
+	dto := FromOrder(order)
+	if dto.PK != order.ID {
+		t.Errorf("Expected PK to be '%s', got '%s'", order.ID, dto.PK)
+	}
+	if want := expireTime.Add(OrderTTLGrace).Unix(); dto.ExpiresAt != want {
+		t.Errorf("Expected ExpiresAt to be %d, got %d", want, dto.ExpiresAt)
+	}
+
+	roundTripped := dto.ToOrder()
+	if roundTripped.ID != order.ID || roundTripped.Status != order.Status || !roundTripped.ExpireTime.Equal(order.ExpireTime) {
+		t.Errorf("Expected %+v, got %+v", order, roundTripped)
+	}
+	if len(roundTripped.Records) != 1 || roundTripped.Records[0] != order.Records[0] {
+		t.Errorf("Expected records %v, got %v", order.Records, roundTripped.Records)
+	}
+}
+
+func TestOrderDTOExpiresAt(t *testing.T) {
+	expireTime := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status        model.OrderStatus
+		wantExpiresAt int64 // 0 for no TTL attribute
+	}{
+		// Orders that were not finalized are kept past their expiry, so that finalizing them reports that they expired
+		{model.OrderPending, expireTime.Add(OrderTTLGrace).Unix()},
+		{model.OrderExpired, expireTime.Add(OrderTTLGrace).Unix()},
+		// Valid orders do not expire
+		{model.OrderValid, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			order := &model.Order{ID: "0123abcd", Status: tt.status, ExpireTime: expireTime}
+			item, err := attributevalue.MarshalMap(FromOrder(order))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+
+			attr, ok := item["ExpiresAt"]
+			if tt.wantExpiresAt == 0 {
+				if ok {
+					t.Errorf("Expected no ExpiresAt attribute, got %v", attr)
+				}
+				return
+			}
+			n, isNumber := attr.(*types.AttributeValueMemberN)
+			if !isNumber || n.Value != strconv.FormatInt(tt.wantExpiresAt, 10) {
+				t.Errorf("Expected ExpiresAt %d, got %v", tt.wantExpiresAt, attr)
+			}
+		})
+	}
+}
+
+func TestDTOCheckHistory(t *testing.T) {
+	validated := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// Records stored before check history was tracked have no history attributes
+	item, err := attributevalue.MarshalMap(FromDomain(&model.DomainRecord{GroupID: "group-123", Hostname: "example.com", ValidateTime: validated}))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	for _, name := range []string{"FirstAttestedTime", "LastCheckTime", "LastFailureTime", "ConsecutiveFailures", "LastFailureReason"} {
+		if _, ok := item[name]; ok {
+			t.Errorf("Expected no %s attribute for a record without check history", name)
+		}
+	}
+
+	record := &model.DomainRecord{
+		GroupID:             "group-123",
+		Hostname:            "example.com",
+		ValidateTime:        validated,
+		FirstAttestedTime:   validated.Add(-48 * time.Hour),
+		LastCheckTime:       validated.Add(time.Hour),
+		LastFailureTime:     validated.Add(time.Hour),
+		ConsecutiveFailures: 1,
+		LastFailureReason:   "no record",
+	}
+	item, err = attributevalue.MarshalMap(FromDomain(record))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var dto DynamoDTO
+	if err := attributevalue.UnmarshalMap(item, &dto); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	roundTripped := dto.ToDomain()
+	if !roundTripped.FirstAttestedTime.Equal(record.FirstAttestedTime) || !roundTripped.LastCheckTime.Equal(record.LastCheckTime) ||
+		!roundTripped.LastFailureTime.Equal(record.LastFailureTime) || roundTripped.ConsecutiveFailures != 1 || roundTripped.LastFailureReason != "no record" {
+		t.Errorf("Expected %+v, got %+v", record, roundTripped)
+	}
+}
+
+func TestArchiveDTORoundTrip(t *testing.T) {
+	archiveTime := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	archived := &model.ArchivedRecord{
+		Record: &model.DomainRecord{
+			Owner:             "alice@example.com",
+			Type:              symgroup.Palindrome,
+			Hostname:          "zb.snus.suns.bz",
+			GroupID:           "v1:a:owner:domains",
+			ValidateTime:      archiveTime.Add(-96 * time.Hour),
+			FirstAttestedTime: archiveTime.Add(-30 * 24 * time.Hour),
+		},
+		ArchiveTime:   archiveTime,
+		ExpireTime:    archiveTime.Add(365 * 24 * time.Hour),
+		ArchiveReason: "no record",
+	}
+
+	item, err := attributevalue.MarshalMap(FromArchived(archived))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	for _, name := range []string{"pk", "sk", "Owner", "ArchiveTime", "ExpiresAt"} {
+		if _, ok := item[name]; !ok {
+			t.Errorf("Expected a %s attribute", name)
+		}
+	}
+
+	var dto ArchiveDTO
+	if err := attributevalue.UnmarshalMap(item, &dto); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if dto.ExpiresAt != archived.ExpireTime.Unix() {
+		t.Errorf("Expected ExpiresAt to be %d, got %d", archived.ExpireTime.Unix(), dto.ExpiresAt)
+	}
+	roundTripped := dto.ToArchived()
+	if roundTripped.Record.GroupID != archived.Record.GroupID || roundTripped.Record.Hostname != archived.Record.Hostname ||
+		!roundTripped.Record.FirstAttestedTime.Equal(archived.Record.FirstAttestedTime) ||
+		!roundTripped.ArchiveTime.Equal(archiveTime) || roundTripped.ArchiveReason != "no record" {
+		t.Errorf("Expected %+v, got %+v", archived, roundTripped)
+	}
+}
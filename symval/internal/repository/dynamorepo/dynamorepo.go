@@ -14,6 +14,10 @@ import (
 	"github.com/mrled/suns/symval/internal/model"
 )
 
+// HostnameIndex is the name of the table's global secondary index keyed on hostname (sk), projecting all attributes.
+// It must match the index defined in the DynamoDB stack.
+const HostnameIndex = "hostname-index"
+
 // DynamoRepository is a DynamoDB implementation of DomainRepository
 type DynamoRepository struct {
 	client    *dynamodb.Client
@@ -70,6 +74,7 @@ func (r *DynamoRepository) UnconditionalStore(ctx context.Context, data *model.D
 }
 
 // Upsert saves domain data with automatic revision increment using UpdateItem. Returns new rev.
+// An existing record keeps its FirstAttestedTime and last failure.
 func (r *DynamoRepository) Upsert(ctx context.Context, data *model.DomainRecord) (int64, error) {
 	if data == nil {
 		return 0, fmt.Errorf("domain data cannot be nil")
@@ -82,19 +87,31 @@ func (r *DynamoRepository) Upsert(ctx context.Context, data *model.DomainRecord)
 			"pk": &types.AttributeValueMemberS{Value: data.GroupID},
 			"sk": &types.AttributeValueMemberS{Value: data.Hostname},
 		},
-		UpdateExpression: aws.String("SET #owner = :owner, #type = :type, #validateTime = :validateTime, #rev = if_not_exists(#rev, :zero) + :one"),
+		UpdateExpression: aws.String("SET #owner = :owner, #type = :type, #unicodeHostname = :unicodeHostname, #idnSafety = :idnSafety, #registrableDomain = :registrableDomain, #validateTime = :validateTime, #firstAttestedTime = if_not_exists(#firstAttestedTime, :firstAttestedTime), #lastCheckTime = :lastCheckTime, #consecutiveFailures = :consecutiveFailures, #rev = if_not_exists(#rev, :zero) + :one"),
 		ExpressionAttributeNames: map[string]string{
-			"#owner":        "Owner",
-			"#type":         "Type",
-			"#validateTime": "ValidateTime",
-			"#rev":          "Rev",
+			"#owner":               "Owner",
+			"#type":                "Type",
+			"#unicodeHostname":     "UnicodeHostname",
+			"#idnSafety":           "IDNSafety",
+			"#registrableDomain":   "RegistrableDomain",
+			"#validateTime":        "ValidateTime",
+			"#firstAttestedTime":   "FirstAttestedTime",
+			"#lastCheckTime":       "LastCheckTime",
+			"#consecutiveFailures": "ConsecutiveFailures",
+			"#rev":                 "Rev",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":owner":        &types.AttributeValueMemberS{Value: data.Owner},
-			":type":         &types.AttributeValueMemberS{Value: string(data.Type)},
-			":validateTime": &types.AttributeValueMemberS{Value: data.ValidateTime.Format(time.RFC3339Nano)},
-			":zero":         &types.AttributeValueMemberN{Value: "0"},
-			":one":          &types.AttributeValueMemberN{Value: "1"},
+			":owner":               &types.AttributeValueMemberS{Value: data.Owner},
+			":type":                &types.AttributeValueMemberS{Value: string(data.Type)},
+			":unicodeHostname":     &types.AttributeValueMemberS{Value: data.DisplayHostname()},
+			":idnSafety":           &types.AttributeValueMemberS{Value: string(data.Safety())},
+			":registrableDomain":   &types.AttributeValueMemberS{Value: data.Registrable()},
+			":validateTime":        &types.AttributeValueMemberS{Value: data.ValidateTime.Format(time.RFC3339Nano)},
+			":firstAttestedTime":   &types.AttributeValueMemberS{Value: firstAttestedTime(data).Format(time.RFC3339Nano)},
+			":lastCheckTime":       &types.AttributeValueMemberS{Value: lastCheckTime(data).Format(time.RFC3339Nano)},
+			":consecutiveFailures": &types.AttributeValueMemberN{Value: strconv.Itoa(data.ConsecutiveFailures)},
+			":zero":                &types.AttributeValueMemberN{Value: "0"},
+			":one":                 &types.AttributeValueMemberN{Value: "1"},
 		},
 		ReturnValues: types.ReturnValueUpdatedNew,
 	})
@@ -117,12 +134,37 @@ func (r *DynamoRepository) Upsert(ctx context.Context, data *model.DomainRecord)
 	return 0, fmt.Errorf("failed to get revision from response")
 }
 
-// SetValidationIfUnchanged updates validation time only if revision matches. Returns new rev.
+// SetValidationIfUnchanged updates validation time and check history only if revision matches. Returns new rev.
 func (r *DynamoRepository) SetValidationIfUnchanged(ctx context.Context, data *model.DomainRecord, snapshotRev int64) (int64, error) {
 	if data == nil {
 		return 0, fmt.Errorf("domain data cannot be nil")
 	}
 
+	updateExpression := "SET #validateTime = :validateTime, #firstAttestedTime = if_not_exists(#firstAttestedTime, :firstAttestedTime), #lastCheckTime = :lastCheckTime, #consecutiveFailures = :consecutiveFailures, #rev = if_not_exists(#rev, :zero) + :one"
+	names := map[string]string{
+		"#validateTime":        "ValidateTime",
+		"#firstAttestedTime":   "FirstAttestedTime",
+		"#lastCheckTime":       "LastCheckTime",
+		"#consecutiveFailures": "ConsecutiveFailures",
+		"#rev":                 "Rev",
+	}
+	values := map[string]types.AttributeValue{
+		":validateTime":        &types.AttributeValueMemberS{Value: data.ValidateTime.Format(time.RFC3339Nano)},
+		":firstAttestedTime":   &types.AttributeValueMemberS{Value: firstAttestedTime(data).Format(time.RFC3339Nano)},
+		":lastCheckTime":       &types.AttributeValueMemberS{Value: lastCheckTime(data).Format(time.RFC3339Nano)},
+		":consecutiveFailures": &types.AttributeValueMemberN{Value: strconv.Itoa(data.ConsecutiveFailures)},
+		":snapshotRev":         &types.AttributeValueMemberN{Value: strconv.FormatInt(snapshotRev, 10)},
+		":zero":                &types.AttributeValueMemberN{Value: "0"},
+		":one":                 &types.AttributeValueMemberN{Value: "1"},
+	}
+	if !data.LastFailureTime.IsZero() {
+		updateExpression += ", #lastFailureTime = :lastFailureTime, #lastFailureReason = :lastFailureReason"
+		names["#lastFailureTime"] = "LastFailureTime"
+		names["#lastFailureReason"] = "LastFailureReason"
+		values[":lastFailureTime"] = &types.AttributeValueMemberS{Value: data.LastFailureTime.Format(time.RFC3339Nano)}
+		values[":lastFailureReason"] = &types.AttributeValueMemberS{Value: data.LastFailureReason}
+	}
+
 	// Use UpdateItem with condition expression to check revision
 	// Handle missing Rev attribute by treating it as rev 0 for backward compatibility
 	result, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
@@ -131,19 +173,11 @@ func (r *DynamoRepository) SetValidationIfUnchanged(ctx context.Context, data *m
 			"pk": &types.AttributeValueMemberS{Value: data.GroupID},
 			"sk": &types.AttributeValueMemberS{Value: data.Hostname},
 		},
-		UpdateExpression:    aws.String("SET #validateTime = :validateTime, #rev = if_not_exists(#rev, :zero) + :one"),
-		ConditionExpression: aws.String("(attribute_not_exists(#rev) AND :snapshotRev = :zero) OR (#rev = :snapshotRev)"),
-		ExpressionAttributeNames: map[string]string{
-			"#validateTime": "ValidateTime",
-			"#rev":          "Rev",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":validateTime": &types.AttributeValueMemberS{Value: data.ValidateTime.Format(time.RFC3339Nano)},
-			":snapshotRev":  &types.AttributeValueMemberN{Value: strconv.FormatInt(snapshotRev, 10)},
-			":zero":         &types.AttributeValueMemberN{Value: "0"},
-			":one":          &types.AttributeValueMemberN{Value: "1"},
-		},
-		ReturnValues: types.ReturnValueAllNew,
+		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String("(attribute_not_exists(#rev) AND :snapshotRev = :zero) OR (#rev = :snapshotRev)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
 	})
 
 	if err != nil {
@@ -219,6 +253,61 @@ func (r *DynamoRepository) List(ctx context.Context) ([]*model.DomainRecord, err
 	return ToDomainList(dtos), nil
 }
 
+// ListByHostname retrieves domain data for a hostname across all groups from DynamoDB
+func (r *DynamoRepository) ListByHostname(ctx context.Context, hostname string) ([]*model.DomainRecord, error) {
+	// The hostname is the sort key, so this queries the index keyed on it
+	items, err := queryItems(ctx, r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(HostnameIndex),
+		KeyConditionExpression: aws.String("sk = :hostname"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hostname": &types.AttributeValueMemberS{Value: hostname},
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domain records for %s: %w", hostname, err)
+	}
+
+	var dtos []*DynamoDTO
+	for _, item := range items {
+		var dto DynamoDTO
+		if err := attributevalue.UnmarshalMap(item, &dto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal domain record: %w", err)
+		}
+		dtos = append(dtos, &dto)
+	}
+
+	return ToDomainList(dtos), nil
+}
+
+// ListByGroupID retrieves domain data for every hostname in a group from DynamoDB
+func (r *DynamoRepository) ListByGroupID(ctx context.Context, groupID string) ([]*model.DomainRecord, error) {
+	// The group ID is the partition key, so this is a Query
+	items, err := queryItems(ctx, r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("pk = :groupID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":groupID": &types.AttributeValueMemberS{Value: groupID},
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domain records for %s: %w", groupID, err)
+	}
+
+	var dtos []*DynamoDTO
+	for _, item := range items {
+		var dto DynamoDTO
+		if err := attributevalue.UnmarshalMap(item, &dto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal domain record: %w", err)
+		}
+		dtos = append(dtos, &dto)
+	}
+
+	return ToDomainList(dtos), nil
+}
+
 // UnconditionalDelete removes domain data by group ID and hostname from DynamoDB unconditionally
 func (r *DynamoRepository) UnconditionalDelete(ctx context.Context, groupID, hostname string) error {
 	// Use ConditionExpression to ensure the item exists before deleting
@@ -285,3 +374,19 @@ func (r *DynamoRepository) DeleteIfUnchanged(ctx context.Context, groupID, hostn
 
 	return nil
 }
+
+// firstAttestedTime returns when a record was first attested, for records that do not have one yet
+func firstAttestedTime(data *model.DomainRecord) time.Time {
+	if data.FirstAttestedTime.IsZero() {
+		return data.ValidateTime
+	}
+	return data.FirstAttestedTime
+}
+
+// lastCheckTime returns when a record was last checked, defaulting to its validation time
+func lastCheckTime(data *model.DomainRecord) time.Time {
+	if data.LastCheckTime.IsZero() {
+		return data.ValidateTime
+	}
+	return data.LastCheckTime
+}
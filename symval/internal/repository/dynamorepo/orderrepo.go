@@ -0,0 +1,138 @@
+package dynamorepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+// OrderTTLGrace is how long after an unfinalized order expires DynamoDB may delete it,
+// so that for a while, finalizing it reports that it expired rather than that it does not exist
+const OrderTTLGrace = 7 * 24 * time.Hour
+
+// OrderDTO represents an attestation order in the DynamoDB orders table.
+// The table has only a partition key, the order ID, and uses ExpiresAt as its TTL attribute.
+type OrderDTO struct {
+	PK           string                `dynamodbav:"pk"` // Partition Key - maps from ID
+	Owner        string                `dynamodbav:"Owner"`
+	Type         symgroup.SymmetryType `dynamodbav:"Type"`
+	Domains      []string              `dynamodbav:"Domains"`
+	ExpectedID   string                `dynamodbav:"ExpectedID"`
+	Records      []model.OrderRecord   `dynamodbav:"Records"`
+	Status       model.OrderStatus     `dynamodbav:"Status"`
+	CreateTime   time.Time             `dynamodbav:"CreateTime"`
+	ExpireTime   time.Time             `dynamodbav:"ExpireTime"`
+	FinalizeTime time.Time             `dynamodbav:"FinalizeTime"`
+	LastError    string                `dynamodbav:"LastError,omitempty"`
+
+	// ExpiresAt is when DynamoDB TTL may delete the order, in Unix seconds:
+	// OrderTTLGrace after ExpireTime for orders that were not finalized, and unset for valid orders,
+	// which do not expire. DynamoDB deletes expired items lazily, so readers must still check ExpireTime.
+	ExpiresAt int64 `dynamodbav:"ExpiresAt,omitempty"`
+}
+
+// ToOrder converts an OrderDTO to a domain model Order
+func (dto *OrderDTO) ToOrder() *model.Order {
+	return &model.Order{
+		ID:           dto.PK,
+		Owner:        dto.Owner,
+		Type:         dto.Type,
+		Domains:      dto.Domains,
+		ExpectedID:   dto.ExpectedID,
+		Records:      dto.Records,
+		Status:       dto.Status,
+		CreateTime:   dto.CreateTime,
+		ExpireTime:   dto.ExpireTime,
+		FinalizeTime: dto.FinalizeTime,
+		LastError:    dto.LastError,
+	}
+}
+
+// FromOrder creates an OrderDTO from a domain model Order
+func FromOrder(order *model.Order) *OrderDTO {
+	dto := &OrderDTO{
+		PK:           order.ID,
+		Owner:        order.Owner,
+		Type:         order.Type,
+		Domains:      order.Domains,
+		ExpectedID:   order.ExpectedID,
+		Records:      order.Records,
+		Status:       order.Status,
+		CreateTime:   order.CreateTime,
+		ExpireTime:   order.ExpireTime,
+		FinalizeTime: order.FinalizeTime,
+		LastError:    order.LastError,
+	}
+	if order.Status != model.OrderValid && !order.ExpireTime.IsZero() {
+		dto.ExpiresAt = order.ExpireTime.Add(OrderTTLGrace).Unix()
+	}
+	return dto
+}
+
+// DynamoOrderRepository is a DynamoDB implementation of OrderRepository
+type DynamoOrderRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoOrderRepository creates a new DynamoDB-backed order repository.
+// Orders are kept in their own table so that they never reach the domain record stream.
+func NewDynamoOrderRepository(client *dynamodb.Client, tableName string) *DynamoOrderRepository {
+	return &DynamoOrderRepository{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// StoreOrder saves an order to DynamoDB, replacing any order with the same ID
+func (r *DynamoOrderRepository) StoreOrder(ctx context.Context, order *model.Order) error {
+	if order == nil {
+		return fmt.Errorf("order cannot be nil")
+	}
+
+	item, err := attributevalue.MarshalMap(FromOrder(order))
+	if err != nil {
+		return fmt.Errorf("failed to marshal order: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store order: %w", err)
+	}
+
+	return nil
+}
+
+// GetOrder retrieves an order by ID from DynamoDB
+func (r *DynamoOrderRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, model.ErrOrderNotFound
+	}
+
+	var dto OrderDTO
+	if err := attributevalue.UnmarshalMap(result.Item, &dto); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order: %w", err)
+	}
+
+	return dto.ToOrder(), nil
+}
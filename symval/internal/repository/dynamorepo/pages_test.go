@@ -0,0 +1,52 @@
+package dynamorepo
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pagedQueryClient answers a Query with pages of one item each,
+// continuing from the page named by ExclusiveStartKey
+type pagedQueryClient struct {
+	pages int
+	calls int
+}
+
+func (c *pagedQueryClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.calls++
+	page := 0
+	if key, ok := input.ExclusiveStartKey["page"].(*types.AttributeValueMemberN); ok {
+		page, _ = strconv.Atoi(key.Value)
+	}
+	output := &dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{
+			{"sk": &types.AttributeValueMemberS{Value: "host" + strconv.Itoa(page)}},
+		},
+	}
+	if page+1 < c.pages {
+		output.LastEvaluatedKey = map[string]types.AttributeValue{
+			"page": &types.AttributeValueMemberN{Value: strconv.Itoa(page + 1)},
+		}
+	}
+	return output, nil
+}
+
+func TestQueryItemsReadsEveryPage(t *testing.T) {
+	client := &pagedQueryClient{pages: 3}
+	items, err := queryItems(context.Background(), client, &dynamodb.QueryInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 3 || client.calls != 3 {
+		t.Fatalf("got %d items in %d calls, want 3 items in 3 calls", len(items), client.calls)
+	}
+	for i, item := range items {
+		if got := item["sk"].(*types.AttributeValueMemberS).Value; got != "host"+strconv.Itoa(i) {
+			t.Errorf("item %d is %s, want host%d", i, got, i)
+		}
+	}
+}
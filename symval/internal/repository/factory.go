@@ -32,22 +32,9 @@ type RepositoryConfig struct {
 func NewRepository(ctx context.Context, cfg RepositoryConfig) (model.DomainRepository, error) {
 	if cfg.DynamoTable != "" {
 		// Use DynamoDB persistence
-		awsCfg, err := config.LoadDefaultConfig(ctx)
+		client, err := newDynamoClient(ctx, cfg.DynamoEndpoint)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load AWS config: %w", err)
-		}
-
-		// Create DynamoDB client
-		var client *dynamodb.Client
-		if cfg.DynamoEndpoint != "" {
-			// Use custom endpoint if specified
-			client = dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
-				o.BaseEndpoint = &cfg.DynamoEndpoint
-			})
-			fmt.Printf("Using DynamoDB endpoint: %s\n", cfg.DynamoEndpoint)
-		} else {
-			// Use default endpoint discovery
-			client = dynamodb.NewFromConfig(awsCfg)
+			return nil, err
 		}
 
 		repo := dynamorepo.NewDynamoRepository(client, cfg.DynamoTable)
@@ -67,3 +54,58 @@ func NewRepository(ctx context.Context, cfg RepositoryConfig) (model.DomainRepos
 
 	return nil, fmt.Errorf("must specify either FilePath or DynamoTable in repository configuration")
 }
+
+// ArchiveConfig holds configuration for creating an archive repository
+type ArchiveConfig struct {
+	// FilePath for JSON file persistence (mutually exclusive with DynamoDB options)
+	FilePath string
+
+	// DynamoTable is the DynamoDB archive table name
+	DynamoTable string
+
+	// DynamoEndpoint is an optional custom DynamoDB endpoint URL
+	DynamoEndpoint string
+}
+
+// NewArchiveRepository creates an ArchiveRepository based on the provided configuration,
+// in the same way as NewRepository.
+func NewArchiveRepository(ctx context.Context, cfg ArchiveConfig) (model.ArchiveRepository, error) {
+	if cfg.DynamoTable != "" {
+		client, err := newDynamoClient(ctx, cfg.DynamoEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Using DynamoDB archive table: %s\n", cfg.DynamoTable)
+		return dynamorepo.NewDynamoArchiveRepository(client, cfg.DynamoTable), nil
+	}
+
+	if cfg.FilePath != "" {
+		archive, err := memrepo.NewMemoryArchiveRepositoryWithPersistence(cfg.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create archive repository: %w", err)
+		}
+		fmt.Printf("Using archive file: %s\n", cfg.FilePath)
+		return archive, nil
+	}
+
+	return nil, fmt.Errorf("must specify either FilePath or DynamoTable in archive configuration")
+}
+
+// newDynamoClient creates a DynamoDB client, using a custom endpoint if one is given
+func newDynamoClient(ctx context.Context, endpoint string) (*dynamodb.Client, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if endpoint != "" {
+		// Use custom endpoint if specified
+		fmt.Printf("Using DynamoDB endpoint: %s\n", endpoint)
+		return dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+			o.BaseEndpoint = &endpoint
+		}), nil
+	}
+
+	// Use default endpoint discovery
+	return dynamodb.NewFromConfig(awsCfg), nil
+}
@@ -0,0 +1,143 @@
+package memrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// MemoryArchiveRepository is an in-memory implementation of ArchiveRepository, optionally backed by a JSON file.
+// The file is kept apart from the domain records file, so that archived records never reach the public view.
+type MemoryArchiveRepository struct {
+	mu       sync.RWMutex
+	data     map[string]*model.ArchivedRecord
+	filePath string
+}
+
+// NewMemoryArchiveRepository creates a new in-memory archive repository without persistence
+func NewMemoryArchiveRepository() *MemoryArchiveRepository {
+	return &MemoryArchiveRepository{data: make(map[string]*model.ArchivedRecord)}
+}
+
+// NewMemoryArchiveRepositoryWithPersistence creates a new archive repository backed by a JSON file,
+// loading the archived records from it if it exists
+func NewMemoryArchiveRepositoryWithPersistence(filePath string) (*MemoryArchiveRepository, error) {
+	repo := &MemoryArchiveRepository{data: make(map[string]*model.ArchivedRecord), filePath: filePath}
+
+	// Create parent directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+
+	// Try to load existing archived records from file
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	} else if err == nil && len(data) > 0 {
+		var archived []*model.ArchivedRecord
+		if err := json.Unmarshal(data, &archived); err != nil {
+			return nil, err
+		}
+		for _, a := range archived {
+			repo.data[makeKey(a.Record.GroupID, a.Record.Hostname)] = a
+		}
+	}
+
+	return repo, nil
+}
+
+// save writes the archived records to the JSON file in group ID and hostname order.
+// If filePath is empty, this is a no-op
+func (r *MemoryArchiveRepository) save() error {
+	if r.filePath == "" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(r.data))
+	for key := range r.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	archived := make([]*model.ArchivedRecord, 0, len(keys))
+	for _, key := range keys {
+		archived = append(archived, r.data[key])
+	}
+
+	data, err := json.MarshalIndent(archived, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
+
+// ArchiveRecord saves a copy of an archived record, replacing any archived copy of the same record
+func (r *MemoryArchiveRepository) ArchiveRecord(ctx context.Context, archived *model.ArchivedRecord) error {
+	if archived == nil || archived.Record == nil {
+		return errors.New("archived record cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data[makeKey(archived.Record.GroupID, archived.Record.Hostname)] = copyArchived(archived)
+	return r.save()
+}
+
+// ListArchived retrieves copies of all archived records that have not expired
+func (r *MemoryArchiveRepository) ListArchived(ctx context.Context) ([]*model.ArchivedRecord, error) {
+	return r.list(func(*model.ArchivedRecord) bool { return true }), nil
+}
+
+// ListArchivedByGroupID retrieves copies of the archived records of a group that have not expired
+func (r *MemoryArchiveRepository) ListArchivedByGroupID(ctx context.Context, groupID string) ([]*model.ArchivedRecord, error) {
+	return r.list(func(a *model.ArchivedRecord) bool { return a.Record.GroupID == groupID }), nil
+}
+
+// DeleteArchived removes an archived record by group ID and hostname
+func (r *MemoryArchiveRepository) DeleteArchived(ctx context.Context, groupID, hostname string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := makeKey(groupID, hostname)
+	if _, exists := r.data[key]; !exists {
+		return model.ErrArchiveNotFound
+	}
+	delete(r.data, key)
+	return r.save()
+}
+
+// list returns copies of the unexpired archived records that match, in group ID and hostname order
+func (r *MemoryArchiveRepository) list(match func(*model.ArchivedRecord) bool) []*model.ArchivedRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var archived []*model.ArchivedRecord
+	for _, a := range r.data {
+		if match(a) && !a.Expired(now) {
+			archived = append(archived, copyArchived(a))
+		}
+	}
+	sort.Slice(archived, func(i, j int) bool {
+		if archived[i].Record.GroupID != archived[j].Record.GroupID {
+			return archived[i].Record.GroupID < archived[j].Record.GroupID
+		}
+		return archived[i].Record.Hostname < archived[j].Record.Hostname
+	})
+	return archived
+}
+
+// copyArchived returns a copy of an archived record and the domain record it holds
+func copyArchived(archived *model.ArchivedRecord) *model.ArchivedRecord {
+	c := *archived
+	record := *archived.Record
+	c.Record = &record
+	return &c
+}
@@ -0,0 +1,79 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestMemoryArchiveRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data.archive.json")
+	now := time.Now()
+	firstAttested := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	archived := func(groupID, hostname string, expire time.Time) *model.ArchivedRecord {
+		return &model.ArchivedRecord{
+			Record: &model.DomainRecord{
+				Owner:             "alice@example.com",
+				Type:              symgroup.Palindrome,
+				Hostname:          hostname,
+				GroupID:           groupID,
+				FirstAttestedTime: firstAttested,
+			},
+			ArchiveTime:   now.Add(-time.Hour),
+			ExpireTime:    expire,
+			ArchiveReason: "no record",
+		}
+	}
+
+	repo, err := NewMemoryArchiveRepositoryWithPersistence(path)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	for _, a := range []*model.ArchivedRecord{
+		archived("group1", "example.com", now.Add(time.Hour)),
+		archived("group1", "com.example", now.Add(time.Hour)),
+		archived("group2", "expired.example.com", now.Add(-time.Minute)),
+	} {
+		if err := repo.ArchiveRecord(ctx, a); err != nil {
+			t.Fatalf("failed to archive record: %v", err)
+		}
+	}
+
+	// Reload from the file, which must keep the archived history
+	repo, err = NewMemoryArchiveRepositoryWithPersistence(path)
+	if err != nil {
+		t.Fatalf("failed to reload repository: %v", err)
+	}
+
+	all, err := repo.ListArchived(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d archived records, want 2 (expired records are left out)", len(all))
+	}
+	if all[0].Record.Hostname != "com.example" || !all[0].Record.FirstAttestedTime.Equal(firstAttested) || all[0].ArchiveReason != "no record" {
+		t.Errorf("got %+v, want com.example first, with its history", all[0])
+	}
+
+	if expired, _ := repo.ListArchivedByGroupID(ctx, "group2"); len(expired) != 0 {
+		t.Errorf("got %d records of an expired group, want none", len(expired))
+	}
+
+	if err := repo.DeleteArchived(ctx, "group1", "example.com"); err != nil {
+		t.Fatalf("failed to delete archived record: %v", err)
+	}
+	if err := repo.DeleteArchived(ctx, "group1", "example.com"); !errors.Is(err, model.ErrArchiveNotFound) {
+		t.Errorf("second delete: error = %v, want ErrArchiveNotFound", err)
+	}
+	if group1, _ := repo.ListArchivedByGroupID(ctx, "group1"); len(group1) != 1 || group1[0].Record.Hostname != "com.example" {
+		t.Errorf("got %d records of group1 after delete, want only com.example", len(group1))
+	}
+}
@@ -178,12 +178,23 @@ func (r *MemoryRepository) Upsert(ctx context.Context, data *model.DomainRecord)
 	defer r.mu.Unlock()
 
 	key := makeKey(data.GroupID, data.Hostname)
-	// Increment revision
+	// Increment revision, keeping the history of an existing record
 	if existing, exists := r.data[key]; exists {
 		data.Rev = existing.Rev + 1
+		if !existing.FirstAttestedTime.IsZero() {
+			data.FirstAttestedTime = existing.FirstAttestedTime
+		}
+		data.LastFailureTime = existing.LastFailureTime
+		data.LastFailureReason = existing.LastFailureReason
 	} else {
 		data.Rev = 1
 	}
+	if data.FirstAttestedTime.IsZero() {
+		data.FirstAttestedTime = data.ValidateTime
+	}
+	if data.LastCheckTime.IsZero() {
+		data.LastCheckTime = data.ValidateTime
+	}
 
 	// Store the record
 	r.data[key] = data
@@ -193,7 +204,7 @@ func (r *MemoryRepository) Upsert(ctx context.Context, data *model.DomainRecord)
 	return data.Rev, nil
 }
 
-// SetValidationIfUnchanged updates validation time only if revision matches. Returns new rev.
+// SetValidationIfUnchanged updates validation time and check history only if revision matches. Returns new rev.
 func (r *MemoryRepository) SetValidationIfUnchanged(ctx context.Context, data *model.DomainRecord, snapshotRev int64) (int64, error) {
 	if data == nil {
 		return 0, errors.New("domain data cannot be nil")
@@ -213,8 +224,23 @@ func (r *MemoryRepository) SetValidationIfUnchanged(ctx context.Context, data *m
 		return 0, model.ErrRevConflict
 	}
 
-	// Update only validation time and increment revision
+	// Update only validation time and check history, and increment revision
 	existing.ValidateTime = data.ValidateTime
+	existing.LastCheckTime = data.LastCheckTime
+	existing.ConsecutiveFailures = data.ConsecutiveFailures
+	if !data.LastFailureTime.IsZero() {
+		existing.LastFailureTime = data.LastFailureTime
+		existing.LastFailureReason = data.LastFailureReason
+	}
+	if existing.FirstAttestedTime.IsZero() {
+		existing.FirstAttestedTime = data.FirstAttestedTime
+	}
+	if existing.FirstAttestedTime.IsZero() {
+		existing.FirstAttestedTime = data.ValidateTime
+	}
+	if existing.LastCheckTime.IsZero() {
+		existing.LastCheckTime = data.ValidateTime
+	}
 	existing.Rev = snapshotRev + 1
 
 	if err := r.save(); err != nil {
@@ -250,6 +276,36 @@ func (r *MemoryRepository) List(ctx context.Context) ([]*model.DomainRecord, err
 	return result, nil
 }
 
+// ListByHostname retrieves domain data for a hostname across all groups
+func (r *MemoryRepository) ListByHostname(ctx context.Context, hostname string) ([]*model.DomainRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*model.DomainRecord
+	for _, data := range r.data {
+		if data.Hostname == hostname {
+			result = append(result, data)
+		}
+	}
+
+	return result, nil
+}
+
+// ListByGroupID retrieves domain data for every hostname in a group
+func (r *MemoryRepository) ListByGroupID(ctx context.Context, groupID string) ([]*model.DomainRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*model.DomainRecord
+	for _, data := range r.data {
+		if data.GroupID == groupID {
+			result = append(result, data)
+		}
+	}
+
+	return result, nil
+}
+
 // UnconditionalDelete removes domain data by group ID and domain name unconditionally
 func (r *MemoryRepository) UnconditionalDelete(ctx context.Context, groupID, domain string) error {
 	r.mu.Lock()
@@ -270,3 +270,45 @@ func TestMemoryRepository_FromJsonString_Invalid(t *testing.T) {
 		t.Error("Expected error for invalid JSON, got nil")
 	}
 }
+
+func TestMemoryRepository_CheckHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	first := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	newRecord := func(validateTime time.Time) *model.DomainRecord {
+		return &model.DomainRecord{Owner: "alice@example.com", Type: symgroup.Palindrome, Hostname: "example.com", GroupID: "group-123", ValidateTime: validateTime}
+	}
+
+	// A new record is first attested and last checked when it is validated
+	rev, err := repo.Upsert(ctx, newRecord(first))
+	if err != nil {
+		t.Fatalf("Failed to upsert: %v", err)
+	}
+	stored, _ := repo.Get(ctx, "group-123", "example.com")
+	if !stored.FirstAttestedTime.Equal(first) || !stored.LastCheckTime.Equal(first) {
+		t.Errorf("Expected first attested and last checked at %v, got %+v", first, stored)
+	}
+
+	// A failed check is recorded conditionally
+	failed := *stored
+	failed.RecordFailure(first.Add(time.Hour), "no record")
+	if _, err := repo.SetValidationIfUnchanged(ctx, &failed, rev); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+
+	// Attesting again keeps the first attestation and the last failure, and resets the failure count
+	if _, err := repo.Upsert(ctx, newRecord(first.Add(2*time.Hour))); err != nil {
+		t.Fatalf("Failed to upsert: %v", err)
+	}
+	stored, _ = repo.Get(ctx, "group-123", "example.com")
+	if !stored.FirstAttestedTime.Equal(first) {
+		t.Errorf("Expected FirstAttestedTime %v to be kept, got %v", first, stored.FirstAttestedTime)
+	}
+	if !stored.LastFailureTime.Equal(first.Add(time.Hour)) || stored.LastFailureReason != "no record" {
+		t.Errorf("Expected the last failure to be kept, got %v %q", stored.LastFailureTime, stored.LastFailureReason)
+	}
+	if stored.ConsecutiveFailures != 0 || !stored.LastCheckTime.Equal(first.Add(2*time.Hour)) {
+		t.Errorf("Expected the failure count reset and last checked at %v, got %d and %v", first.Add(2*time.Hour), stored.ConsecutiveFailures, stored.LastCheckTime)
+	}
+}
@@ -0,0 +1,51 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// MemoryOrderRepository is an in-memory implementation of OrderRepository.
+// Orders are short-lived, so it is never backed by a file.
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]*model.Order
+}
+
+// NewMemoryOrderRepository creates a new in-memory order repository
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders: make(map[string]*model.Order),
+	}
+}
+
+// StoreOrder saves a copy of the order, replacing any order with the same ID
+func (r *MemoryOrderRepository) StoreOrder(ctx context.Context, order *model.Order) error {
+	if order == nil {
+		return errors.New("order cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *order
+	r.orders[order.ID] = &stored
+	return nil
+}
+
+// GetOrder retrieves a copy of an order by ID
+func (r *MemoryOrderRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, model.ErrOrderNotFound
+	}
+
+	found := *order
+	return &found, nil
+}
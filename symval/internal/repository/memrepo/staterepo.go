@@ -0,0 +1,76 @@
+package memrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+// MemoryStateStore is an in-memory implementation of ReattestStateStore, optionally backed by a JSON file
+type MemoryStateStore struct {
+	mu       sync.RWMutex
+	state    *model.ReattestState
+	filePath string
+}
+
+// NewMemoryStateStore creates a new in-memory state store holding an empty state
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{state: &model.ReattestState{}}
+}
+
+// NewMemoryStateStoreWithPersistence creates a new state store backed by a JSON file,
+// loading the state from it if it exists
+func NewMemoryStateStoreWithPersistence(filePath string) (*MemoryStateStore, error) {
+	store := &MemoryStateStore{state: &model.ReattestState{}, filePath: filePath}
+
+	// Create parent directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+
+	// Try to load existing state from file
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	} else if err == nil {
+		if err := json.Unmarshal(data, store.state); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// LoadReattestState retrieves a copy of the saved state
+func (s *MemoryStateStore) LoadReattestState(ctx context.Context) (*model.ReattestState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state.Clone(), nil
+}
+
+// SaveReattestState saves a copy of the state, writing it to the file if there is one
+func (s *MemoryStateStore) SaveReattestState(ctx context.Context, state *model.ReattestState) error {
+	if state == nil {
+		return errors.New("state cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state.Clone()
+	if s.filePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filePath, data, 0644)
+}
@@ -105,6 +105,19 @@ func NewServiceWithResolver(resolver Resolver) *Service {
 	}
 }
 
+// LookupTrace records how Lookup resolved a domain, for diagnostics
+type LookupTrace struct {
+	// Label is the TXT record label that was queried, like "_suns.example.com"
+	Label string
+
+	// CNAMEPath lists the names queried for TXT records in order:
+	// Label, followed by the CNAME target if a CNAME hop was taken
+	CNAMEPath []string
+
+	// Records holds the TXT values found; it is empty if none exist
+	Records []string
+}
+
 // Lookup performs a TXT record lookup for the SUNS verification records of the given domain.
 // It computes the label as "_suns.domain" and attempts to fetch all TXT records at that label.
 // If no TXT records are found, it checks for a CNAME record at that label and performs one
@@ -121,17 +134,29 @@ func NewServiceWithResolver(resolver Resolver) *Service {
 //   - An empty slice if no records exist after checking CNAME
 //   - Other errors for DNS lookup failures (timeouts, temporary failures, etc.)
 func (s *Service) Lookup(domain string) ([]string, error) {
+	trace, err := s.Trace(domain)
+	if err != nil {
+		return nil, err
+	}
+	return trace.Records, nil
+}
+
+// Trace performs the same lookup as Lookup, and also reports the label queried and any CNAME hop taken.
+// The returned trace is never nil, so callers can report how far the lookup got even when it fails.
+func (s *Service) Trace(domain string) (*LookupTrace, error) {
 	if domain == "" {
-		return nil, fmt.Errorf("domain cannot be empty")
+		return &LookupTrace{}, fmt.Errorf("domain cannot be empty")
 	}
 
 	// Compute the label: _suns.INPUT
 	label := fmt.Sprintf("%s.%s", RecordName, domain)
+	trace := &LookupTrace{Label: label, CNAMEPath: []string{label}}
 
 	// First attempt: try to fetch TXT records directly
 	txtRecords, err := s.resolver.LookupTXT(label)
 	if err == nil && len(txtRecords) > 0 {
-		return txtRecords, nil
+		trace.Records = txtRecords
+		return trace, nil
 	}
 
 	// Store the original error to determine if it's a "not found" case
@@ -142,25 +167,29 @@ func (s *Service) Lookup(domain string) ([]string, error) {
 	if cnameErr != nil {
 		// No CNAME found, return empty list if not found, or error for other issues
 		if isNotFoundError(originalErr) {
-			return []string{}, nil
+			trace.Records = []string{}
+			return trace, nil
 		}
-		return nil, fmt.Errorf("failed to lookup TXT or CNAME for %s: %w", label, originalErr)
+		return trace, fmt.Errorf("failed to lookup TXT or CNAME for %s: %w", label, originalErr)
 	}
 
 	// If CNAME exists and points to a different domain, try TXT lookup there
 	if cname != "" && cname != label && cname != label+"." {
+		trace.CNAMEPath = append(trace.CNAMEPath, cname)
 		txtRecords, err = s.resolver.LookupTXT(cname)
 		if err == nil && len(txtRecords) > 0 {
-			return txtRecords, nil
+			trace.Records = txtRecords
+			return trace, nil
 		}
 	}
 
 	// After CNAME hop, still no TXT record found
 	if isNotFoundError(err) || isNotFoundError(originalErr) {
-		return []string{}, nil
+		trace.Records = []string{}
+		return trace, nil
 	}
 
-	return nil, fmt.Errorf("failed to lookup TXT after CNAME hop: %w", err)
+	return trace, fmt.Errorf("failed to lookup TXT after CNAME hop: %w", err)
 }
 
 // isNotFoundError checks if the error indicates a DNS record was not found
@@ -414,3 +414,48 @@ func TestLookup_MultipleVerificationRecordsRealistic(t *testing.T) {
 		t.Error("missing org-wide verification record")
 	}
 }
+
+func TestTrace_CNAMEPath(t *testing.T) {
+	mock := &MockResolver{
+		TXTRecords: map[string][]string{
+			"delegation.example.net": {"v1:delegated-data"},
+		},
+		CNAMERecords: map[string]string{
+			"_suns.example.com": "delegation.example.net",
+		},
+	}
+
+	service := NewServiceWithResolver(mock)
+	trace, err := service.Trace("example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if trace.Label != "_suns.example.com" {
+		t.Errorf("expected label '_suns.example.com', got '%s'", trace.Label)
+	}
+	if len(trace.CNAMEPath) != 2 || trace.CNAMEPath[1] != "delegation.example.net" {
+		t.Errorf("expected CNAME path through delegation.example.net, got %v", trace.CNAMEPath)
+	}
+	if len(trace.Records) != 1 || trace.Records[0] != "v1:delegated-data" {
+		t.Errorf("expected the delegated record, got %v", trace.Records)
+	}
+}
+
+func TestTrace_ResolverError(t *testing.T) {
+	mock := &MockResolver{
+		TXTError:   &net.DNSError{Err: "server misbehaving", Name: "_suns.example.com", IsTemporary: true},
+		CNAMEError: &net.DNSError{Err: "server misbehaving", Name: "_suns.example.com", IsTemporary: true},
+	}
+
+	service := NewServiceWithResolver(mock)
+	trace, err := service.Trace("example.com")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// The trace still reports what was queried
+	if trace == nil || trace.Label != "_suns.example.com" {
+		t.Errorf("expected a trace for '_suns.example.com', got %+v", trace)
+	}
+}
@@ -0,0 +1,45 @@
+// Package dnsclaimstest provides a fake resolver for testing code that looks up _suns records
+package dnsclaimstest
+
+import (
+	"net"
+
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+// Resolver is a dnsclaims.Resolver that answers from maps.
+// Names that are in none of the maps are not found.
+type Resolver struct {
+	TXTRecords map[string][]string
+	CNAMEs     map[string]string
+	Errors     map[string]error // Returned for both TXT and CNAME lookups of a name
+}
+
+var _ dnsclaims.Resolver = (*Resolver)(nil)
+
+// LookupTXT returns the error or TXT records for domain, or a "not found" error
+func (r *Resolver) LookupTXT(domain string) ([]string, error) {
+	if err, ok := r.Errors[domain]; ok {
+		return nil, err
+	}
+	if records, ok := r.TXTRecords[domain]; ok {
+		return records, nil
+	}
+	return nil, NotFound(domain)
+}
+
+// LookupCNAME returns the error or CNAME target for domain, or a "not found" error
+func (r *Resolver) LookupCNAME(domain string) (string, error) {
+	if err, ok := r.Errors[domain]; ok {
+		return "", err
+	}
+	if cname, ok := r.CNAMEs[domain]; ok {
+		return cname, nil
+	}
+	return "", NotFound(domain)
+}
+
+// NotFound is the error a resolver returns when a name does not exist
+func NotFound(domain string) error {
+	return &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+}
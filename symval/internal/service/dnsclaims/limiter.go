@@ -0,0 +1,120 @@
+package dnsclaims
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/psl"
+)
+
+const (
+	// DefaultConcurrency is the most lookups a LimitedResolver has in flight at once by default
+	DefaultConcurrency = 16
+
+	// DefaultNameserverRate is the most lookups per second a LimitedResolver sends toward one nameserver by default
+	DefaultNameserverRate = 20
+)
+
+// Limits bounds the DNS lookups made through a LimitedResolver
+type Limits struct {
+	// Concurrency is the most lookups in flight at once, across all nameservers; 0 means unlimited
+	Concurrency int
+
+	// NameserverRate is the most lookups per second toward one nameserver; 0 means unlimited
+	NameserverRate float64
+}
+
+// DefaultLimits returns the default lookup limits
+func DefaultLimits() Limits {
+	return Limits{
+		Concurrency:    DefaultConcurrency,
+		NameserverRate: DefaultNameserverRate,
+	}
+}
+
+// LimitedResolver wraps a Resolver, bounding the lookups in flight and spacing out lookups toward each nameserver.
+// It is safe for concurrent use, and is meant to be shared by everything that looks up records in one process,
+// so that the limits hold however many groups and domains are checked in parallel.
+//
+// Lookups through a CustomResolver all go to its server, so they share one rate limit.
+// Other resolvers are recursive, and the queries they send on a cache miss reach the zone's authoritative nameservers,
+// so lookups are rate limited per zone (the registrable domain of the queried name).
+type LimitedResolver struct {
+	resolver Resolver
+	limits   Limits
+	slots    chan struct{} // Holds a token for each lookup in flight; nil if unlimited
+
+	mu   sync.Mutex
+	next map[string]time.Time // When the next lookup toward each nameserver may start
+}
+
+// NewLimitedResolver creates a resolver that applies limits to lookups through resolver
+func NewLimitedResolver(resolver Resolver, limits Limits) *LimitedResolver {
+	r := &LimitedResolver{
+		resolver: resolver,
+		limits:   limits,
+		next:     make(map[string]time.Time),
+	}
+	if limits.Concurrency > 0 {
+		r.slots = make(chan struct{}, limits.Concurrency)
+	}
+	return r
+}
+
+// NewLimitedService creates a new TXT lookup service with the default resolver and the given limits
+func NewLimitedService(limits Limits) *Service {
+	return NewServiceWithResolver(NewLimitedResolver(&DefaultResolver{}, limits))
+}
+
+// LookupTXT implements Resolver.LookupTXT within the limits
+func (r *LimitedResolver) LookupTXT(domain string) ([]string, error) {
+	defer r.acquire(domain)()
+	return r.resolver.LookupTXT(domain)
+}
+
+// LookupCNAME implements Resolver.LookupCNAME within the limits
+func (r *LimitedResolver) LookupCNAME(domain string) (string, error) {
+	defer r.acquire(domain)()
+	return r.resolver.LookupCNAME(domain)
+}
+
+// acquire waits until a lookup of domain is allowed, and returns a function that releases it
+func (r *LimitedResolver) acquire(domain string) func() {
+	if r.limits.NameserverRate > 0 {
+		time.Sleep(r.reserve(r.nameserver(domain)))
+	}
+	if r.slots == nil {
+		return func() {}
+	}
+	r.slots <- struct{}{}
+	return func() { <-r.slots }
+}
+
+// reserve books the next start time for a lookup toward nameserver, returning how long to wait for it
+func (r *LimitedResolver) reserve(nameserver string) time.Duration {
+	interval := time.Duration(float64(time.Second) / r.limits.NameserverRate)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	start := r.next[nameserver]
+	if start.Before(now) {
+		start = now
+	}
+	r.next[nameserver] = start.Add(interval)
+	return start.Sub(now)
+}
+
+// nameserver returns the key that lookups of domain are rate limited by
+func (r *LimitedResolver) nameserver(domain string) string {
+	if custom, ok := r.resolver.(*CustomResolver); ok {
+		return custom.server
+	}
+	name := strings.TrimSuffix(strings.ToLower(domain), ".")
+	if zone := psl.RegistrableDomain(name); zone != "" {
+		return zone
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package dnsclaims
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowResolver answers every lookup after a delay, recording how many lookups were in flight at once
+type slowResolver struct {
+	delay   time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *slowResolver) lookup() {
+	now := s.running.Add(1)
+	for {
+		old := s.peak.Load()
+		if now <= old || s.peak.CompareAndSwap(old, now) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	s.running.Add(-1)
+}
+
+func (s *slowResolver) LookupTXT(domain string) ([]string, error) {
+	s.lookup()
+	return []string{"v1:example-data"}, nil
+}
+
+func (s *slowResolver) LookupCNAME(domain string) (string, error) {
+	s.lookup()
+	return domain, nil
+}
+
+func TestLimitedResolver_Concurrency(t *testing.T) {
+	slow := &slowResolver{delay: 5 * time.Millisecond}
+	resolver := NewLimitedResolver(slow, Limits{Concurrency: 3})
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := resolver.LookupTXT("_suns.example.com"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := slow.peak.Load(); peak > 3 {
+		t.Errorf("%d lookups ran at once, want at most 3", peak)
+	}
+}
+
+func TestLimitedResolver_NameserverRate(t *testing.T) {
+	resolver := NewLimitedResolver(&slowResolver{}, Limits{NameserverRate: 100})
+
+	// Lookups toward one zone are spaced 10ms apart, so the fifth starts no sooner than 40ms in
+	start := time.Now()
+	for range 5 {
+		resolver.LookupTXT("_suns.a.example.com")
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 lookups toward one zone took %v, want at least 40ms", elapsed)
+	}
+
+	// Lookups toward other zones are not held back by them
+	start = time.Now()
+	for _, domain := range []string{"_suns.example.net", "_suns.example.org", "_suns.example.io"} {
+		resolver.LookupTXT(domain)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("lookups toward 3 new zones took %v, want no wait", elapsed)
+	}
+}
+
+func TestLimitedResolver_NameserverKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver Resolver
+		domain   string
+		want     string
+	}{
+		{"registrable domain", &DefaultResolver{}, "_suns.www.example.com", "example.com"},
+		{"public suffix", &DefaultResolver{}, "_suns.a.example.co.uk.", "example.co.uk"},
+		{"custom server", NewCustomResolver("192.0.2.1:53"), "_suns.example.com", "192.0.2.1:53"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewLimitedResolver(tt.resolver, DefaultLimits())
+			if got := r.nameserver(tt.domain); got != tt.want {
+				t.Errorf("nameserver(%q) = %q, want %q", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
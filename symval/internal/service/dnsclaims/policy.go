@@ -0,0 +1,94 @@
+package dnsclaims
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/psl"
+)
+
+const (
+	// PolicyRecordName is the TXT record label for zone-owner policies
+	PolicyRecordName = "_suns-policy"
+
+	// policyAllowPrefix prefixes each policy TXT value, which is followed by an owner hash
+	policyAllowPrefix = "v1:allow:"
+)
+
+// Policy is a zone owner's restriction on who may claim names at or below the zone.
+// It is published as one or more TXT records at _suns-policy.<zone>, each like
+// "v1:allow:<base64(sha256(owner))>", using the same owner hash as v1 group IDs.
+type Policy struct {
+	Zone        string   // The zone the policy was published for
+	OwnerHashes []string // Owner hashes allowed to claim names at or below Zone
+}
+
+// RecordName returns the name the policy was published at, like "_suns-policy.example.com"
+func (p *Policy) RecordName() string {
+	return PolicyRecordName + "." + p.Zone
+}
+
+// Allows reports whether owner may claim names covered by the policy
+func (p *Policy) Allows(owner string) bool {
+	ownerHash := groupid.OwnerHashV1(owner)
+	for _, allowed := range p.OwnerHashes {
+		if allowed == ownerHash {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePolicy parses the TXT records found at _suns-policy.<zone>.
+// Values that are not "v1:allow:" entries are ignored, so that a zone can publish other TXT data there.
+// Returns nil if no entries were found.
+func ParsePolicy(zone string, records []string) *Policy {
+	var ownerHashes []string
+	for _, record := range records {
+		record = strings.TrimSpace(record)
+		if ownerHash, found := strings.CutPrefix(record, policyAllowPrefix); found && ownerHash != "" {
+			ownerHashes = append(ownerHashes, ownerHash)
+		}
+	}
+	if len(ownerHashes) == 0 {
+		return nil
+	}
+	return &Policy{Zone: zone, OwnerHashes: ownerHashes}
+}
+
+// PolicyZones returns the zones whose policies cover hostname, closest first:
+// hostname itself, then each parent up to and including its registrable domain.
+// Public suffixes above the registrable domain are not consulted.
+func PolicyZones(hostname string) []string {
+	registrable := psl.RegistrableDomain(hostname)
+	zones := []string{hostname}
+	for zone := hostname; zone != registrable; {
+		_, parent, found := strings.Cut(zone, ".")
+		if !found {
+			break
+		}
+		zone = parent
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+// FindPolicy walks up from hostname to its registrable domain and returns the closest policy, like CAA.
+// Returns nil if no zone in the walk publishes a policy.
+func (s *Service) FindPolicy(hostname string) (*Policy, error) {
+	for _, zone := range PolicyZones(hostname) {
+		label := PolicyRecordName + "." + zone
+		records, err := s.resolver.LookupTXT(label)
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to lookup policy at %s: %w", label, err)
+		}
+		if policy := ParsePolicy(zone, records); policy != nil {
+			return policy, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+package dnsclaims
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+)
+
+func TestParsePolicy(t *testing.T) {
+	alice := groupid.OwnerHashV1("alice@example.com")
+
+	policy := ParsePolicy("example.com", []string{"v=spf1 -all", "v1:allow:" + alice, "v1:allow:"})
+	if policy == nil {
+		t.Fatal("expected a policy")
+	}
+	if len(policy.OwnerHashes) != 1 || policy.OwnerHashes[0] != alice {
+		t.Errorf("OwnerHashes = %v, want [%s]", policy.OwnerHashes, alice)
+	}
+	if !policy.Allows("alice@example.com") {
+		t.Error("expected alice to be allowed")
+	}
+	if policy.Allows("mallory@example.com") {
+		t.Error("expected mallory not to be allowed")
+	}
+	if policy.RecordName() != "_suns-policy.example.com" {
+		t.Errorf("RecordName() = %q", policy.RecordName())
+	}
+
+	if ParsePolicy("example.com", []string{"unrelated"}) != nil {
+		t.Error("expected no policy when no entries are present")
+	}
+}
+
+func TestPolicyZones(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     string
+	}{
+		{"example.com", "example.com"},
+		{"a.b.example.com", "a.b.example.com,b.example.com,example.com"},
+		{"www.example.co.uk", "www.example.co.uk,example.co.uk"},
+		{"aba", "aba"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(PolicyZones(tt.hostname), ","); got != tt.want {
+			t.Errorf("PolicyZones(%q) = %q, want %q", tt.hostname, got, tt.want)
+		}
+	}
+}
+
+func TestFindPolicy(t *testing.T) {
+	alice := groupid.OwnerHashV1("alice@example.com")
+	bob := groupid.OwnerHashV1("bob@example.com")
+
+	mock := &MockResolver{
+		TXTRecords: map[string][]string{
+			"_suns-policy.example.com":       {"v1:allow:" + alice},
+			"_suns-policy.team.example.com":  {"v1:allow:" + bob},
+			"_suns-policy.other.example.com": {"not a policy"},
+		},
+	}
+	service := NewServiceWithResolver(mock)
+
+	tests := []struct {
+		hostname string
+		wantZone string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"a.team.example.com", "team.example.com"},
+		{"other.example.com", "example.com"}, // Unparseable records do not stop the walk
+		{"example.org", ""},
+	}
+
+	for _, tt := range tests {
+		policy, err := service.FindPolicy(tt.hostname)
+		if err != nil {
+			t.Fatalf("FindPolicy(%q) unexpected error: %v", tt.hostname, err)
+		}
+		gotZone := ""
+		if policy != nil {
+			gotZone = policy.Zone
+		}
+		if gotZone != tt.wantZone {
+			t.Errorf("FindPolicy(%q) zone = %q, want %q", tt.hostname, gotZone, tt.wantZone)
+		}
+	}
+}
+
+func TestFindPolicy_LookupError(t *testing.T) {
+	service := NewServiceWithResolver(&MockResolver{TXTError: errors.New("timeout")})
+	if _, err := service.FindPolicy("example.com"); err == nil {
+		t.Error("expected an error when the policy lookup fails")
+	}
+}
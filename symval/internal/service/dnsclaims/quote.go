@@ -0,0 +1,45 @@
+package dnsclaims
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCharacterString is the longest string a TXT record can hold in one piece (RFC 1035 section 3.3)
+const maxCharacterString = 255
+
+// QuoteTXT returns value as the quoted text of a TXT record in zone file form (RFC 1035 section 5.1),
+// as accepted by zone files, nsupdate and Route 53.
+// Quotes and backslashes are escaped with a backslash, and other bytes that are not printable ASCII as \DDD.
+// Values longer than 255 bytes are split into several quoted strings, which resolvers join back together.
+func QuoteTXT(value string) string {
+	var pieces []string
+	for {
+		piece := value[:min(len(value), maxCharacterString)]
+		pieces = append(pieces, quoteCharacterString(piece))
+		value = value[len(piece):]
+		if value == "" {
+			return strings.Join(pieces, " ")
+		}
+	}
+}
+
+// quoteCharacterString quotes one TXT character-string
+func quoteCharacterString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
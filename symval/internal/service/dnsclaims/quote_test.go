@@ -0,0 +1,27 @@
+package dnsclaims
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteTXT(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"v1:a:owner:domains", `"v1:a:owner:domains"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"tab\there", `"tab\009here"`},
+		{"ü", `"\195\188"`},
+		{strings.Repeat("a", 256), `"` + strings.Repeat("a", 255) + `" "a"`},
+	}
+
+	for _, tt := range tests {
+		if got := QuoteTXT(tt.value); got != tt.want {
+			t.Errorf("QuoteTXT(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package punyzone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/adapter/dnsserver"
+	"github.com/mrled/suns/symval/internal/idn"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Config holds the fixed records served for every name in the zone
+type Config struct {
+	// IPv4 is returned for A queries; nil means no A records
+	IPv4 net.IP
+
+	// IPv6 is returned for AAAA queries; nil means no AAAA records
+	IPv6 net.IP
+
+	// TTL for all answers, in seconds
+	TTL uint32
+}
+
+// NewDNSHandler returns a DNS handler that answers any name in the zone,
+// including xn-- labels, with the fixed records from cfg
+func NewDNSHandler(cfg Config) dnsserver.Handler {
+	return dnsserver.HandlerFunc(func(ctx context.Context, q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+		switch {
+		case q.Type == dnsmessage.TypeA && cfg.IPv4 != nil:
+			return dnsmessage.RCodeSuccess, []dnsmessage.Resource{dnsserver.AResource(q.Name, cfg.TTL, cfg.IPv4)}
+		case q.Type == dnsmessage.TypeAAAA && cfg.IPv6 != nil:
+			return dnsmessage.RCodeSuccess, []dnsmessage.Resource{dnsserver.AAAAResource(q.Name, cfg.TTL, cfg.IPv6)}
+		default:
+			return dnsmessage.RCodeSuccess, nil
+		}
+	})
+}
+
+// NewHTTPHandler returns an HTTP handler that describes the requested Host:
+// its decoded Unicode form, its UTS-46 mapping, and any homograph warnings.
+// It responds with JSON if the client accepts application/json, and plain text otherwise.
+func NewHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		report := idn.Inspect(host)
+
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, FormatReport(report))
+	})
+}
+
+// FormatReport renders a report as human-readable text
+func FormatReport(report *idn.Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Host:       %s\n", report.Input)
+	fmt.Fprintf(&b, "A-label:    %s\n", report.ASCII)
+	fmt.Fprintf(&b, "U-label:    %s\n", report.Unicode)
+	if report.MappingChanged {
+		fmt.Fprintf(&b, "UTS-46:     %s (mapped from input)\n", report.Mapped)
+	} else {
+		fmt.Fprintf(&b, "UTS-46:     %s (unchanged)\n", report.Mapped)
+	}
+
+	fmt.Fprintf(&b, "\nLabels:\n")
+	for _, label := range report.Labels {
+		scripts := "-"
+		if len(label.Scripts) > 0 {
+			scripts = strings.Join(label.Scripts, ", ")
+		}
+		fmt.Fprintf(&b, "  %-30s %-20s %s\n", label.ALabel, label.ULabel, scripts)
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(&b, "\nErrors:\n")
+		for _, e := range report.Errors {
+			fmt.Fprintf(&b, "  - %s\n", e)
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		fmt.Fprintf(&b, "\nHomograph warnings:\n")
+		for _, w := range report.Warnings {
+			fmt.Fprintf(&b, "  - %s\n", w)
+		}
+	} else {
+		fmt.Fprintf(&b, "\nNo homograph warnings\n")
+	}
+
+	if report.Safety != nil {
+		fmt.Fprintf(&b, "\nTR39:       %s (%s)\n", report.Safety.Verdict, report.Safety.Level)
+		fmt.Fprintf(&b, "Skeleton:   %s\n", report.Safety.Skeleton)
+		for _, reason := range report.Safety.Reasons {
+			fmt.Fprintf(&b, "  - %s\n", reason)
+		}
+	}
+
+	return b.String()
+}
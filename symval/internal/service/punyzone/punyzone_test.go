@@ -0,0 +1,91 @@
+package punyzone
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrled/suns/symval/internal/idn"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSHandler(t *testing.T) {
+	handler := NewDNSHandler(Config{
+		IPv4: net.ParseIP("192.0.2.1").To4(),
+		IPv6: net.ParseIP("2001:db8::1"),
+		TTL:  60,
+	})
+	name := dnsmessage.MustNewName("xn--80ak6aa92e.test.example.")
+
+	tests := []struct {
+		qtype    dnsmessage.Type
+		wantType dnsmessage.Type
+		wantLen  int
+	}{
+		{dnsmessage.TypeA, dnsmessage.TypeA, 1},
+		{dnsmessage.TypeAAAA, dnsmessage.TypeAAAA, 1},
+		{dnsmessage.TypeTXT, 0, 0},
+	}
+
+	for _, tt := range tests {
+		rcode, answers := handler.ServeDNS(context.Background(), dnsmessage.Question{Name: name, Type: tt.qtype, Class: dnsmessage.ClassINET})
+		if rcode != dnsmessage.RCodeSuccess {
+			t.Errorf("%v: rcode = %v, want success", tt.qtype, rcode)
+		}
+		if len(answers) != tt.wantLen {
+			t.Fatalf("%v: got %d answers, want %d", tt.qtype, len(answers), tt.wantLen)
+		}
+		if tt.wantLen > 0 && (answers[0].Header.Type != tt.wantType || answers[0].Header.Name != name) {
+			t.Errorf("%v: unexpected answer header %+v", tt.qtype, answers[0].Header)
+		}
+	}
+}
+
+func TestDNSHandlerWithoutIPv6(t *testing.T) {
+	handler := NewDNSHandler(Config{IPv4: net.ParseIP("192.0.2.1").To4()})
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName("a.test.example."), Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET}
+	if _, answers := handler.ServeDNS(context.Background(), q); len(answers) != 0 {
+		t.Errorf("got %d AAAA answers, want none", len(answers))
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	handler := NewHTTPHandler()
+
+	t.Run("plain text", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = "xn--bcher-kva.test.example:8053"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := rec.Body.String()
+		if !strings.Contains(body, "bücher.test.example") {
+			t.Errorf("expected decoded hostname in body, got:\n%s", body)
+		}
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+			t.Errorf("Content-Type = %q", rec.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = "pаypal.test.example"
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var report idn.Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to decode JSON report: %v", err)
+		}
+		if report.ASCII != "xn--pypal-4ve.test.example" {
+			t.Errorf("ASCII = %q", report.ASCII)
+		}
+		if len(report.Warnings) == 0 {
+			t.Error("expected a mixed-script warning")
+		}
+	})
+}
@@ -38,3 +38,15 @@ func ValidSymmetryTypesText() string {
 	}
 	return "Valid types: " + strings.Join(validTypes, ", ")
 }
+
+// ParseType converts a type name like "palindrome" or a type code like "a" to a SymmetryType, ignoring case
+func ParseType(s string) (SymmetryType, bool) {
+	s = strings.ToLower(s)
+	if code, ok := TypeNameToCode[s]; ok {
+		return SymmetryType(code), true
+	}
+	if _, ok := TypeCodeToName[s]; ok {
+		return SymmetryType(s), true
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+)
+
+// ErrStillInvalid is returned when restoring a group whose records still fail attestation
+var ErrStillInvalid = errors.New("group still fails attestation")
+
+// ArchiveUseCase lists groups that reattestation removed and restores them once their owners fix DNS
+type ArchiveUseCase struct {
+	dnsService *dnsclaims.Service
+	repository model.DomainRepository
+	archive    model.ArchiveRepository
+}
+
+// NewArchiveUseCase creates a new archive use case
+func NewArchiveUseCase(dnsService *dnsclaims.Service, repo model.DomainRepository, archive model.ArchiveRepository) *ArchiveUseCase {
+	return &ArchiveUseCase{
+		dnsService: dnsService,
+		repository: repo,
+		archive:    archive,
+	}
+}
+
+// ArchivedGroup is a group whose records were archived
+type ArchivedGroup struct {
+	GroupID     string
+	Owner       string
+	Type        string
+	Records     []*model.ArchivedRecord
+	ArchiveTime time.Time // When the last of the group's records was archived
+	ExpireTime  time.Time // When the first of the group's archived records expires
+	Reason      string
+}
+
+// List returns the archived groups in group ID order
+func (uc *ArchiveUseCase) List(ctx context.Context) ([]ArchivedGroup, error) {
+	archived, err := uc.archive.ListArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived records: %w", err)
+	}
+
+	byGroupID := make(map[string][]*model.ArchivedRecord)
+	for _, a := range archived {
+		byGroupID[a.Record.GroupID] = append(byGroupID[a.Record.GroupID], a)
+	}
+
+	groups := make([]ArchivedGroup, 0, len(byGroupID))
+	for groupID, records := range byGroupID {
+		groups = append(groups, newArchivedGroup(groupID, records))
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].GroupID < groups[j].GroupID })
+	return groups, nil
+}
+
+// RestoreResult contains the result of restoring an archived group
+type RestoreResult struct {
+	Group    ArchivedGroup
+	Attest   *attestation.AttestResult // nil if the check was skipped
+	Restored int                       // Records returned to the data store
+}
+
+// Restore returns an archived group to the data store, keeping each record's FirstAttestedTime.
+// Unless skipCheck is set, the group is attested first, and ErrStillInvalid is returned if it fails.
+// It returns model.ErrArchiveNotFound if the group has no archived records.
+func (uc *ArchiveUseCase) Restore(ctx context.Context, groupID string, skipCheck bool) (*RestoreResult, error) {
+	archived, err := uc.archive.ListArchivedByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived records for group %s: %w", groupID, err)
+	}
+	if len(archived) == 0 {
+		return nil, model.ErrArchiveNotFound
+	}
+
+	result := &RestoreResult{Group: newArchivedGroup(groupID, archived)}
+
+	if !skipCheck {
+		domains := make([]string, 0, len(archived))
+		for _, a := range archived {
+			domains = append(domains, a.Record.Hostname)
+		}
+		first := archived[0].Record
+		result.Attest, err = attestation.NewAttestationUseCase(uc.dnsService, uc.repository).Check(first.Owner, first.Type, domains)
+		if err != nil {
+			return result, fmt.Errorf("failed to attest group %s: %w", groupID, err)
+		}
+		if !result.Attest.IsValid {
+			return result, fmt.Errorf("%w: %s", ErrStillInvalid, result.Attest.ErrorMessage)
+		}
+	}
+
+	now := time.Now()
+	for _, a := range archived {
+		record := *a.Record
+		record.RecordPass(now)
+		if _, err := uc.repository.Upsert(ctx, &record); err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", record.Hostname, err)
+		}
+		result.Restored++
+		if err := uc.archive.DeleteArchived(ctx, groupID, record.Hostname); err != nil && !errors.Is(err, model.ErrArchiveNotFound) {
+			return result, fmt.Errorf("restored %s, but failed to remove it from the archive: %w", record.Hostname, err)
+		}
+	}
+
+	return result, nil
+}
+
+// newArchivedGroup summarizes the archived records of a group
+func newArchivedGroup(groupID string, records []*model.ArchivedRecord) ArchivedGroup {
+	group := ArchivedGroup{
+		GroupID: groupID,
+		Owner:   records[0].Record.Owner,
+		Type:    string(records[0].Record.Type),
+		Records: records,
+		Reason:  records[0].ArchiveReason,
+	}
+	for _, a := range records {
+		if a.ArchiveTime.After(group.ArchiveTime) {
+			group.ArchiveTime = a.ArchiveTime
+		}
+		if group.ExpireTime.IsZero() || a.ExpireTime.Before(group.ExpireTime) {
+			group.ExpireTime = a.ExpireTime
+		}
+	}
+	return group
+}
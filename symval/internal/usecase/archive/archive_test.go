@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example"}
+	groupID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	firstAttested := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	archive := memrepo.NewMemoryArchiveRepository()
+	for _, domain := range domains {
+		archived := &model.ArchivedRecord{
+			Record: &model.DomainRecord{
+				Owner:               owner,
+				Type:                symgroup.MirrorNames,
+				Hostname:            domain,
+				GroupID:             groupID,
+				ValidateTime:        firstAttested.Add(24 * time.Hour),
+				FirstAttestedTime:   firstAttested,
+				ConsecutiveFailures: 4,
+			},
+			ArchiveTime:   time.Now().Add(-time.Hour),
+			ExpireTime:    time.Now().Add(time.Hour),
+			ArchiveReason: "no record",
+		}
+		if err := archive.ArchiveRecord(ctx, archived); err != nil {
+			t.Fatalf("failed to archive record: %v", err)
+		}
+	}
+
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	repo := memrepo.NewMemoryRepository()
+	uc := NewArchiveUseCase(dnsclaims.NewServiceWithResolver(resolver), repo, archive)
+
+	groups, err := uc.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 1 || groups[0].GroupID != groupID || len(groups[0].Records) != 2 || groups[0].Reason != "no record" {
+		t.Fatalf("List() = %+v, want the one archived group", groups)
+	}
+
+	// A group whose records are still missing stays in the archive
+	if _, err := uc.Restore(ctx, groupID, false); !errors.Is(err, ErrStillInvalid) {
+		t.Errorf("Restore() error = %v, want ErrStillInvalid", err)
+	}
+	if records, _ := repo.List(ctx); len(records) != 0 {
+		t.Errorf("restored %d records of an invalid group", len(records))
+	}
+
+	// Once DNS is fixed, the group is restored with its original first attestation
+	for _, domain := range domains {
+		resolver.TXTRecords["_suns."+domain] = []string{groupID}
+	}
+	result, err := uc.Restore(ctx, groupID, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Restored != 2 {
+		t.Errorf("restored %d records, want 2", result.Restored)
+	}
+	records, _ := repo.ListByGroupID(ctx, groupID)
+	if len(records) != 2 {
+		t.Fatalf("got %d records in the data store, want 2", len(records))
+	}
+	for _, record := range records {
+		if !record.FirstAttestedTime.Equal(firstAttested) {
+			t.Errorf("%s: FirstAttestedTime = %v, want %v", record.Hostname, record.FirstAttestedTime, firstAttested)
+		}
+		if record.ConsecutiveFailures != 0 || time.Since(record.ValidateTime) > time.Minute {
+			t.Errorf("%s: want a fresh validation, got %+v", record.Hostname, record)
+		}
+	}
+	if archived, _ := archive.ListArchived(ctx); len(archived) != 0 {
+		t.Errorf("%d records left in the archive, want none", len(archived))
+	}
+
+	if _, err := uc.Restore(ctx, groupID, false); !errors.Is(err, model.ErrArchiveNotFound) {
+		t.Errorf("Restore() of a restored group: error = %v, want ErrArchiveNotFound", err)
+	}
+}
@@ -3,49 +3,145 @@ package attestation
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/psl"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
 	"github.com/mrled/suns/symval/internal/symgroup"
 	"github.com/mrled/suns/symval/internal/usecase/concheck"
+	"github.com/mrled/suns/symval/internal/usecase/conflict"
 	"github.com/mrled/suns/symval/internal/validation"
+	"github.com/mrled/suns/symval/internal/workpool"
 )
 
+// DefaultLookupWorkers is how many domains of one group are looked up at once by default
+const DefaultLookupWorkers = 4
+
+// storeLockStripes is how many locks serialize storing groups, chosen by registrable domain
+const storeLockStripes = 64
+
 // AttestationUseCase handles attestation of domain groups
 type AttestationUseCase struct {
-	dnsService *dnsclaims.Service
-	repository model.DomainRepository
-	logger     *slog.Logger
+	dnsService       *dnsclaims.Service
+	repository       model.DomainRepository
+	logger           *slog.Logger
+	registrableLimit int // Maximum groups per registrable domain; 0 means unlimited
+	conflictPolicy   conflict.Policy
+	lookupWorkers    int // Domains of one group looked up at once
+
+	// storeLocks serialize checking and storing groups under the same registrable domains (see lockRegistrable)
+	storeLocks [storeLockStripes]sync.Mutex
 }
 
 // NewAttestationUseCase creates a new attestation use case
 // If repository is nil, attestation results will not be persisted
 func NewAttestationUseCase(dnsService *dnsclaims.Service, repo model.DomainRepository) *AttestationUseCase {
 	return &AttestationUseCase{
-		dnsService: dnsService,
-		repository: repo,
-		logger:     slog.Default().With("component", "attestation"),
+		dnsService:     dnsService,
+		repository:     repo,
+		logger:         slog.Default().With("component", "attestation"),
+		conflictPolicy: conflict.DefaultPolicy,
+		lookupWorkers:  DefaultLookupWorkers,
 	}
 }
 
+// SetLookupWorkers sets how many domains of one group are looked up at once.
+// Results do not depend on the number of workers; lookups are still reported in domain order.
+// To bound lookups across groups, give the DNS service a dnsclaims.LimitedResolver.
+func (uc *AttestationUseCase) SetLookupWorkers(workers int) {
+	uc.lookupWorkers = workers
+}
+
+// SetConflictPolicy sets how claims on hostnames already attested by another owner are resolved.
+// Conflicts are only detected when a repository is configured.
+func (uc *AttestationUseCase) SetConflictPolicy(policy conflict.Policy) {
+	uc.conflictPolicy = policy
+}
+
+// SetRegistrableDomainLimit limits how many groups may include names under the same registrable domain (eTLD+1).
+// A limit of 0 (the default) means unlimited. The limit only applies when a repository is configured.
+func (uc *AttestationUseCase) SetRegistrableDomainLimit(limit int) {
+	uc.registrableLimit = limit
+}
+
 // AttestResult contains the result of an attestation check
 type AttestResult struct {
 	IsValid       bool
 	ExpectedID    string
 	GroupIDs      []groupid.GroupIDV1
 	DomainRecords []*model.DomainRecord
+	Conflicts     *conflict.Report   // Ownership conflicts found, if a repository is configured
+	Diagnostics   []DomainDiagnostic // What the checks and DNS found for each domain, in the order given
 	ErrorMessage  string
+	Persisted     bool // The records were stored; false for failed attestations, Check, and use cases without a repository
 }
 
 // Attest verifies a group of domains for consistency and validity
-// It calculates the expected group ID, looks up DNS records for all domains,
-// checks for consistency, validates the group, and returns the validity result
+// It normalizes the domains to their A-label form, rejects names that fail the homograph check,
+// refuses domains whose zone policy does not allow the owner, calculates the expected group ID,
+// looks up DNS records for all domains, checks for consistency, validates the group,
+// and returns the validity result.
+// Problems do not stop at the first domain: each domain's checks and records are diagnosed in the result,
+// so that every problem can be fixed in one round trip.
+// If the group is valid and a repository is configured, its records are stored.
 func (uc *AttestationUseCase) Attest(owner string, symmetryType symgroup.SymmetryType, domains []string) (*AttestResult, error) {
+	return uc.attest(owner, symmetryType, domains, true)
+}
+
+// Check runs the same checks as Attest, including the conflict and registrable domain checks
+// against the repository, but never stores anything.
+func (uc *AttestationUseCase) Check(owner string, symmetryType symgroup.SymmetryType, domains []string) (*AttestResult, error) {
+	return uc.attest(owner, symmetryType, domains, false)
+}
+
+// attest performs an attestation, storing the records of a valid group only if persist is true
+func (uc *AttestationUseCase) attest(owner string, symmetryType symgroup.SymmetryType, domains []string, persist bool) (*AttestResult, error) {
 	result := &AttestResult{}
 
+	// Check every domain before any DNS lookups, so that malformed and refused names are all reported at once.
+	// Domains are canonicalized, so that Unicode and punycode spellings of a name produce the same group ID.
+	var problems []string
+	checks := make([]DomainDiagnostic, 0, len(domains))
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		diagnostic, err := uc.checkDomain(owner, domain)
+		if err != nil {
+			return nil, err
+		}
+		if !diagnostic.OK() {
+			problems = append(problems, diagnostic.Problem)
+		}
+		checks = append(checks, diagnostic)
+		normalized = append(normalized, diagnostic.Hostname)
+	}
+	if len(problems) > 0 {
+		result.IsValid = false
+		result.ErrorMessage = strings.Join(problems, "; ")
+		result.Diagnostics = checks
+		return result, nil
+	}
+	given := domains
+	domains = normalized
+
+	// Refuse claims on hostnames that another owner has already attested, according to the conflict policy
+	if uc.repository != nil {
+		if allowed, err := uc.checkConflicts(owner, domains, result); err != nil || !allowed {
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+
 	// Calculate the expected group ID
 	expectedID, err := groupid.CalculateV1(owner, string(symmetryType), domains)
 	if err != nil {
@@ -53,7 +149,12 @@ func (uc *AttestationUseCase) Attest(owner string, symmetryType symgroup.Symmetr
 	}
 	result.ExpectedID = expectedID
 
-	// Look up DNS records for all domains and filter them
+	// Groups attested before hostnames were canonicalized publish the group ID of the hostnames as given,
+	// which is still accepted during the transition; see validation.LegacyGroupID
+	legacyID := validation.LegacyGroupID(owner, symmetryType, given)
+
+	// Look up DNS records for all domains and filter them.
+	// Every domain is checked before failing, so that all problems are reported at once.
 	var allRawRecords []string
 	var allDomainRecords []*model.DomainRecord
 	validateTime := time.Now()
@@ -64,51 +165,90 @@ func (uc *AttestationUseCase) Attest(owner string, symmetryType symgroup.Symmetr
 		Type:  &symmetryType,
 	}
 
-	for _, domain := range domains {
-		records, err := uc.dnsService.Lookup(domain)
-		if err != nil {
-			return nil, fmt.Errorf("failed to lookup DNS records for %s: %w", domain, err)
+	// Diagnostics also distinguish records that match the owner and type but belong to another group
+	diagnosticCriteria := criteria
+	diagnosticCriteria.GroupID = &expectedID
+
+	// Look up every domain concurrently, then check them in order
+	type lookup struct {
+		trace *dnsclaims.LookupTrace
+		err   error
+	}
+	lookups := workpool.Map(len(domains), uc.lookupWorkers, func(i int) lookup {
+		trace, err := uc.dnsService.Trace(domains[i])
+		return lookup{trace: trace, err: err}
+	})
+
+	for i, domain := range domains {
+		trace, lookupErr := lookups[i].trace, lookups[i].err
+		diagnostic := diagnoseDomain(domain, trace, lookupErr, diagnosticCriteria)
+
+		records := trace.Records
+		switch {
+		case lookupErr != nil:
+			diagnostic.Problem = fmt.Sprintf("failed to lookup DNS records for %s: %v", domain, lookupErr)
+
+		// Check if DNS lookup returned zero results before filtering
+		case len(records) == 0:
+			diagnostic.Problem = fmt.Sprintf("no DNS TXT records found for domain %s", domain)
 		}
 
 		// Log the raw DNS records found for this domain
 		uc.logger.Debug("DNS records found for domain",
 			slog.String("domain", domain),
 			slog.Int("count", len(records)),
-			slog.Any("records", records))
+			slog.Any("records", records),
+			slog.Any("cname_path", trace.CNAMEPath))
 
-		// Check if DNS lookup returned zero results before filtering
-		if len(records) == 0 {
-			result.IsValid = false
-			result.ErrorMessage = fmt.Sprintf("no DNS TXT records found for domain %s", domain)
-			return result, nil
-		}
+		if diagnostic.OK() {
+			// Filter the records for this domain
+			filteredData, err := filterDomainRecords(domain, records, criteria, validateTime)
+			if err != nil {
+				return nil, fmt.Errorf("failed to filter records for %s: %w", domain, err)
+			}
 
-		// Filter the records for this domain
-		filteredData, err := filterDomainRecords(domain, records, criteria, validateTime)
-		if err != nil {
-			return nil, fmt.Errorf("failed to filter records for %s: %w", domain, err)
-		}
+			// Log the filtered records for this domain
+			uc.logger.Debug("Filtered records for domain",
+				slog.String("domain", domain),
+				slog.Int("filtered_count", len(filteredData)),
+				slog.Int("original_count", len(records)),
+				slog.Any("filtered_records", filteredData))
 
-		// Log the filtered records for this domain
-		uc.logger.Debug("Filtered records for domain",
-			slog.String("domain", domain),
-			slog.Int("filtered_count", len(filteredData)),
-			slog.Int("original_count", len(records)),
-			slog.Any("filtered_records", filteredData))
+			// A hostname can belong to several groups with the same owner and type,
+			// so use the record for the group being attested
+			selected := selectRecord(filteredData, expectedID)
+			if selected == nil && legacyID != "" {
+				if selected = selectRecord(filteredData, legacyID); selected != nil {
+					// Keep the hostname the legacy group ID was calculated from, so that the group validates,
+					// and is stored under the same key as before
+					selected.Hostname = given[i]
+				}
+			}
+			switch {
+			case len(filteredData) == 0:
+				// Include the number of records that were filtered out
+				diagnostic.Problem = fmt.Sprintf("no matching records found for domain %s (filtered out %d records)", domain, len(records))
+			case selected == nil:
+				diagnostic.Problem = fmt.Sprintf("no record for group %s found for domain %s (found %d record(s) for other groups with this owner and type)", expectedID, domain, len(filteredData))
+			default:
+				allDomainRecords = append(allDomainRecords, selected)
 
-		// Fail attestation if no matching records found for this domain
-		if len(filteredData) == 0 {
-			result.IsValid = false
-			// Include the number of records that were filtered out
-			result.ErrorMessage = fmt.Sprintf("no matching records found for domain %s (filtered out %d records)", domain, len(records))
-			return result, nil
+				// Collect the group ID for consistency checking
+				allRawRecords = append(allRawRecords, selected.GroupID)
+			}
 		}
 
-		// Use the first matching record for this domain
-		allDomainRecords = append(allDomainRecords, filteredData[0])
+		if !diagnostic.OK() {
+			problems = append(problems, diagnostic.Problem)
+		}
+		result.Diagnostics = append(result.Diagnostics, diagnostic)
+	}
 
-		// Collect the group ID for consistency checking
-		allRawRecords = append(allRawRecords, filteredData[0].GroupID)
+	// Fail attestation if any domain had no usable records
+	if len(problems) > 0 {
+		result.IsValid = false
+		result.ErrorMessage = strings.Join(problems, "; ")
+		return result, nil
 	}
 
 	// Parse all records at once using ParseGroupIDv1Slice
@@ -137,18 +277,184 @@ func (uc *AttestationUseCase) Attest(owner string, symmetryType symgroup.Symmetr
 	}
 
 	result.IsValid = isValid
+	if !result.IsValid || uc.repository == nil {
+		return result, nil
+	}
+	if persist {
+		return uc.store(owner, result)
+	}
 
-	// If attestation is successful and repository is configured, persist the records
-	if result.IsValid && uc.repository != nil {
-		ctx := context.Background()
-		for _, record := range allDomainRecords {
-			if _, err := uc.repository.Upsert(ctx, record); err != nil {
-				// Log and exit with error
-				fmt.Printf("Warning: failed to store record for %s: %v\n", record.Hostname, err)
-				return nil, fmt.Errorf("failed to store record for %s: %w", record.Hostname, err)
-			}
+	// Enforce the per-registrable-domain limit, as store would
+	if uc.registrableLimit > 0 {
+		if err := uc.checkRegistrableLimit(allDomainRecords[0].GroupID, domains); err != nil {
+			result.IsValid = false
+			result.ErrorMessage = err.Error()
+		}
+	}
+	return result, nil
+}
+
+// checkDomain canonicalizes domain and refuses public suffixes, homographs,
+// and names that the zone owner's _suns-policy record does not allow the owner to claim.
+// The diagnostic has the canonical hostname if the domain passes, or the domain as given and the problem if not.
+func (uc *AttestationUseCase) checkDomain(owner, domain string) (DomainDiagnostic, error) {
+	diagnostic := DomainDiagnostic{Hostname: domain}
+	ascii, err := hostname.Canonicalize(domain)
+	if err != nil {
+		diagnostic.Problem = err.Error()
+		return diagnostic, nil
+	}
+	if psl.IsPublicSuffix(ascii) {
+		diagnostic.Problem = fmt.Sprintf("hostname %q is a public suffix and cannot be claimed", ascii)
+		return diagnostic, nil
+	}
+	if assessment := idn.Assess(ascii); assessment.Verdict == idn.VerdictReject {
+		diagnostic.Problem = fmt.Sprintf("hostname %q rejected by homograph check: %s", domain, strings.Join(assessment.Reasons, "; "))
+		return diagnostic, nil
+	}
+
+	// Refuse claims that a zone owner's _suns-policy record does not allow
+	policy, err := uc.dnsService.FindPolicy(ascii)
+	if err != nil {
+		return diagnostic, fmt.Errorf("failed to find policy for %s: %w", ascii, err)
+	}
+	if policy != nil && !policy.Allows(owner) {
+		diagnostic.Problem = fmt.Sprintf("owner is not allowed to claim %s by the policy at %s", ascii, policy.RecordName())
+		return diagnostic, nil
+	}
+
+	diagnostic.Hostname = ascii
+	return diagnostic, nil
+}
+
+// store stores the records of a group that attest found valid.
+// Another attestation may have stored a conflicting group since attest checked for conflicts,
+// so the conflict check is repeated, and the per-registrable-domain limit enforced,
+// while holding the locks of the group's registrable domains.
+func (uc *AttestationUseCase) store(owner string, result *AttestResult) (*AttestResult, error) {
+	// Records of legacy groups keep the hostnames as given, so canonicalize them again
+	domains := make([]string, 0, len(result.DomainRecords))
+	for _, record := range result.DomainRecords {
+		name, err := hostname.Canonicalize(record.Hostname)
+		if err != nil {
+			return nil, err
 		}
+		domains = append(domains, name)
 	}
+	groupID := result.DomainRecords[0].GroupID
 
+	unlock := uc.lockRegistrable(domains)
+	defer unlock()
+	if allowed, err := uc.checkConflicts(owner, domains, result); err != nil || !allowed {
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if uc.registrableLimit > 0 {
+		if err := uc.checkRegistrableLimit(groupID, domains); err != nil {
+			result.IsValid = false
+			result.ErrorMessage = err.Error()
+			return result, nil
+		}
+	}
+
+	ctx := context.Background()
+	for _, record := range result.DomainRecords {
+		if _, err := uc.repository.Upsert(ctx, record); err != nil {
+			// Log and exit with error
+			fmt.Printf("Warning: failed to store record for %s: %v\n", record.Hostname, err)
+			return nil, fmt.Errorf("failed to store record for %s: %w", record.Hostname, err)
+		}
+	}
+	result.Persisted = true
 	return result, nil
 }
+
+// checkConflicts refuses claims on hostnames that another owner has already attested, according to the conflict policy.
+// It records any conflicts in result, and returns false, with the reason in result, if the claim is refused.
+func (uc *AttestationUseCase) checkConflicts(owner string, domains []string, result *AttestResult) (bool, error) {
+	detector := conflict.NewDetector(uc.dnsService, uc.repository, uc.conflictPolicy)
+	report, err := detector.Check(context.Background(), owner, domains)
+	if err != nil {
+		return false, fmt.Errorf("failed to check ownership conflicts: %w", err)
+	}
+	if len(report.Conflicts) > 0 {
+		result.Conflicts = report
+	}
+	if report.Blocking() {
+		result.IsValid = false
+		result.ErrorMessage = report.String()
+		return false, nil
+	}
+	return true, nil
+}
+
+// lockRegistrable locks the store locks of the registrable domains of domains, always in the same order,
+// and returns a function that unlocks them.
+// Groups that share a hostname also share its registrable domain, so this serializes the conflict
+// and registrable domain checks with storing the groups that they compare, within this process.
+func (uc *AttestationUseCase) lockRegistrable(domains []string) func() {
+	var stripes []int
+	seen := make(map[int]bool)
+	for _, domain := range domains {
+		h := fnv.New32a()
+		h.Write([]byte(psl.RegistrableDomain(domain)))
+		stripe := int(h.Sum32() % storeLockStripes)
+		if !seen[stripe] {
+			seen[stripe] = true
+			stripes = append(stripes, stripe)
+		}
+	}
+	sort.Ints(stripes)
+
+	for _, stripe := range stripes {
+		uc.storeLocks[stripe].Lock()
+	}
+	return func() {
+		for _, stripe := range stripes {
+			uc.storeLocks[stripe].Unlock()
+		}
+	}
+}
+
+// selectRecord returns the record whose group ID is expectedID, or nil if there is none
+func selectRecord(records []*model.DomainRecord, expectedID string) *model.DomainRecord {
+	for _, record := range records {
+		if record.GroupID == expectedID {
+			return record
+		}
+	}
+	return nil
+}
+
+// checkRegistrableLimit returns an error if storing the group would exceed the number of groups
+// allowed to include names under any one registrable domain.
+// Groups that are already stored, including the one being attested, do not count against it twice.
+func (uc *AttestationUseCase) checkRegistrableLimit(groupID string, domains []string) error {
+	records, err := uc.repository.List(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list records for registrable domain limit: %w", err)
+	}
+
+	groupsByRegistrable := make(map[string]map[string]bool)
+	for _, record := range records {
+		if record.GroupID == groupID {
+			continue
+		}
+		registrable := record.Registrable()
+		if groupsByRegistrable[registrable] == nil {
+			groupsByRegistrable[registrable] = make(map[string]bool)
+		}
+		groupsByRegistrable[registrable][record.GroupID] = true
+	}
+
+	for _, domain := range domains {
+		registrable := psl.RegistrableDomain(domain)
+		if count := len(groupsByRegistrable[registrable]); count >= uc.registrableLimit {
+			return fmt.Errorf("registrable domain %s already has %d group(s), the limit is %d", registrable, count, uc.registrableLimit)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,560 @@
+package attestation
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestAttestRejectsPublicSuffix(t *testing.T) {
+	uc := NewAttestationUseCase(nil, nil)
+
+	result, err := uc.Attest("alice@example.com", symgroup.Palindrome, []string{"co.uk"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid || !strings.Contains(result.ErrorMessage, "public suffix") {
+		t.Errorf("expected a public suffix failure, got valid=%v message=%q", result.IsValid, result.ErrorMessage)
+	}
+}
+
+func TestAttestReportsEveryDomainCheck(t *testing.T) {
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns-policy.example.net": {"v1:allow:" + groupid.OwnerHashV1("bob@example.com")},
+		},
+	})
+	uc := NewAttestationUseCase(dnsService, nil)
+
+	domains := []string{"https://example.com", "co.uk", "example.org", "example.net"}
+	result, err := uc.Attest("alice@example.com", symgroup.MirrorNames, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Fatal("expected attestation to fail")
+	}
+	if len(result.Diagnostics) != len(domains) {
+		t.Fatalf("got %d diagnostics, want one for each of %d domains", len(result.Diagnostics), len(domains))
+	}
+
+	wantProblems := []string{"https://example.com", "public suffix", "", "not allowed to claim example.net"}
+	for i, want := range wantProblems {
+		d := result.Diagnostics[i]
+		switch {
+		case want == "" && !d.OK():
+			t.Errorf("diagnostic for %s has problem %q, want none", d.Hostname, d.Problem)
+		case want != "" && !strings.Contains(d.Problem, want):
+			t.Errorf("diagnostic for %s has problem %q, want one containing %q", d.Hostname, d.Problem, want)
+		case want != "" && !strings.Contains(result.ErrorMessage, d.Problem):
+			t.Errorf("error message %q does not include the problem for %s", result.ErrorMessage, d.Hostname)
+		}
+	}
+	if result.LookupFailed() {
+		t.Error("LookupFailed() = true for domains refused before any lookups")
+	}
+}
+
+func TestCheckRegistrableLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.NewMemoryRepository()
+	for _, record := range []*model.DomainRecord{
+		{GroupID: "group1", Hostname: "a.example.com", Owner: "alice", Type: symgroup.Palindrome},
+		{GroupID: "group2", Hostname: "b.example.com", Owner: "bob", Type: symgroup.Palindrome},
+		{GroupID: "group3", Hostname: "c.example.org", Owner: "carol", Type: symgroup.Palindrome},
+	} {
+		if _, err := repo.Upsert(ctx, record); err != nil {
+			t.Fatalf("failed to seed repository: %v", err)
+		}
+	}
+
+	uc := NewAttestationUseCase(nil, repo)
+	uc.SetRegistrableDomainLimit(2)
+
+	tests := []struct {
+		name    string
+		groupID string
+		domains []string
+		wantErr bool
+	}{
+		{"new group under a full registrable domain", "group4", []string{"d.example.com"}, true},
+		{"existing group under a full registrable domain", "group1", []string{"a.example.com"}, false},
+		{"new group under a registrable domain with room", "group4", []string{"d.example.org"}, false},
+		{"new group spanning a full registrable domain", "group4", []string{"d.example.org", "d.example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uc.checkRegistrableLimit(tt.groupID, tt.domains)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkRegistrableLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttestHostnameInSeveralGroups(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example"}
+
+	expectedID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	// Another group with the same owner and type that also includes example.com
+	otherID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), []string{"example.com", "com.elpmaxe"})
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		published []string
+		wantValid bool
+	}{
+		{"other group listed first", []string{otherID, expectedID}, true},
+		{"other group listed last", []string{expectedID, otherID}, true},
+		{"only the other group", []string{otherID}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+				TXTRecords: map[string][]string{
+					"_suns.example.com": tt.published,
+					"_suns.com.example": {expectedID},
+				},
+			})
+			repo := memrepo.NewMemoryRepository()
+			uc := NewAttestationUseCase(dnsService, repo)
+
+			result, err := uc.Attest(owner, symgroup.MirrorNames, domains)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Fatalf("IsValid = %v, want %v (%s)", result.IsValid, tt.wantValid, result.ErrorMessage)
+			}
+			if !tt.wantValid {
+				return
+			}
+
+			for _, record := range result.DomainRecords {
+				if record.GroupID != expectedID {
+					t.Errorf("record for %s has group ID %s, want %s", record.Hostname, record.GroupID, expectedID)
+				}
+			}
+			stored, err := repo.ListByHostname(context.Background(), "example.com")
+			if err != nil {
+				t.Fatalf("failed to list stored records: %v", err)
+			}
+			if len(stored) != 1 || stored[0].GroupID != expectedID {
+				t.Errorf("stored records for example.com = %v, want one record in group %s", stored, expectedID)
+			}
+		})
+	}
+}
+
+func TestAttestLegacyGroup(t *testing.T) {
+	owner := "alice@example.com"
+	// Hostnames as stored by an attestation from before hostnames were canonicalized
+	stored := []string{"Example.com", "com.example."}
+
+	legacyID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), stored)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.example.com": {legacyID},
+			"_suns.com.example": {legacyID},
+		},
+	})
+	repo := memrepo.NewMemoryRepository()
+	uc := NewAttestationUseCase(dnsService, repo)
+
+	// Reattesting the group with its stored hostnames keeps it valid, under the same keys
+	result, err := uc.Attest(owner, symgroup.MirrorNames, stored)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || !result.Persisted {
+		t.Fatalf("IsValid = %v, Persisted = %v, want valid and persisted (%s)", result.IsValid, result.Persisted, result.ErrorMessage)
+	}
+	for i, record := range result.DomainRecords {
+		if record.Hostname != stored[i] || record.GroupID != legacyID {
+			t.Errorf("record %d = %s in group %s, want %s in group %s", i, record.Hostname, record.GroupID, stored[i], legacyID)
+		}
+	}
+
+	// The legacy group ID is only accepted for the hostnames it was calculated from
+	result, err = uc.Attest(owner, symgroup.MirrorNames, []string{"example.com", "com.example"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Error("canonical hostnames validated against the legacy group ID")
+	}
+}
+
+func TestAttestDiagnostics(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example", "example.net", "net.example"}
+
+	expectedID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	otherOwnerID, err := groupid.CalculateV1("mallory@example.com", string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	otherTypeID, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{"example.com"})
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.example.com":     {"not a group id", otherOwnerID, otherTypeID},
+			"delegated.example.org": {expectedID},
+		},
+		CNAMEs: map[string]string{
+			"_suns.net.example": "delegated.example.org",
+		},
+		Errors: map[string]error{
+			"_suns.example.net": &net.DNSError{Err: "server misbehaving", Name: "_suns.example.net", IsTemporary: true},
+		},
+	})
+	uc := NewAttestationUseCase(dnsService, nil)
+
+	result, err := uc.Attest(owner, symgroup.MirrorNames, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Fatal("expected attestation to fail")
+	}
+	if len(result.Diagnostics) != len(domains) {
+		t.Fatalf("got %d diagnostics, want one per domain", len(result.Diagnostics))
+	}
+
+	// Every domain is diagnosed, not just the first one with a problem
+	rejected := result.Diagnostics[0]
+	if rejected.OK() || !strings.Contains(result.ErrorMessage, rejected.Problem) {
+		t.Errorf("example.com: expected a problem in the error message, got %q", result.ErrorMessage)
+	}
+	wantRejections := []Rejection{RejectedUnparseable, RejectedOwner, RejectedType}
+	if len(rejected.Records) != len(wantRejections) {
+		t.Fatalf("example.com: got %d record diagnostics, want %d", len(rejected.Records), len(wantRejections))
+	}
+	for i, want := range wantRejections {
+		if got := rejected.Records[i].Rejected; got != want {
+			t.Errorf("example.com record %d: rejected by %q, want %q", i, got, want)
+		}
+	}
+	if rejected.Records[0].GroupID != nil || rejected.Records[1].GroupID == nil {
+		t.Errorf("example.com: expected only parseable records to have a group ID")
+	}
+
+	if missing := result.Diagnostics[1]; missing.OK() || len(missing.Records) != 0 {
+		t.Errorf("com.example: expected a problem and no records, got %+v", missing)
+	}
+
+	if failed := result.Diagnostics[2]; failed.OK() || failed.ResolverError == "" {
+		t.Errorf("example.net: expected a resolver error, got %+v", failed)
+	}
+
+	delegated := result.Diagnostics[3]
+	if !delegated.OK() {
+		t.Errorf("net.example: unexpected problem %q", delegated.Problem)
+	}
+	if len(delegated.CNAMEPath) != 2 || delegated.CNAMEPath[1] != "delegated.example.org" {
+		t.Errorf("net.example: expected CNAME path through delegated.example.org, got %v", delegated.CNAMEPath)
+	}
+	if len(delegated.Records) != 1 || delegated.Records[0].Rejected != "" {
+		t.Errorf("net.example: expected one matching record, got %+v", delegated.Records)
+	}
+}
+
+func TestAttestLookupFailed(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example"}
+	expectedID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	servfail := &net.DNSError{Err: "server misbehaving", Name: "_suns.example.com", IsTemporary: true}
+
+	tests := []struct {
+		name       string
+		resolver   *dnsclaimstest.Resolver
+		wantFailed bool
+	}{
+		{
+			name: "valid",
+			resolver: &dnsclaimstest.Resolver{TXTRecords: map[string][]string{
+				"_suns.example.com": {expectedID},
+				"_suns.com.example": {expectedID},
+			}},
+		},
+		{
+			name: "record missing",
+			resolver: &dnsclaimstest.Resolver{TXTRecords: map[string][]string{
+				"_suns.example.com": {expectedID},
+			}},
+		},
+		{
+			name: "lookup failed",
+			resolver: &dnsclaimstest.Resolver{
+				TXTRecords: map[string][]string{"_suns.com.example": {expectedID}},
+				Errors:     map[string]error{"_suns.example.com": servfail},
+			},
+			wantFailed: true,
+		},
+		{
+			name: "lookup failed and record missing",
+			resolver: &dnsclaimstest.Resolver{
+				Errors: map[string]error{"_suns.example.com": servfail},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewAttestationUseCase(dnsclaims.NewServiceWithResolver(tt.resolver), nil)
+			result, err := uc.Attest(owner, symgroup.MirrorNames, domains)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.LookupFailed(); got != tt.wantFailed {
+				t.Errorf("LookupFailed() = %v, want %v (%s)", got, tt.wantFailed, result.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestCheckDoesNotPersist(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example"}
+	expectedID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.example.com": {expectedID},
+			"_suns.com.example": {expectedID},
+		},
+	})
+	repo := memrepo.NewMemoryRepository()
+	uc := NewAttestationUseCase(dnsService, repo)
+
+	result, err := uc.Check(owner, symgroup.MirrorNames, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || result.Persisted {
+		t.Errorf("Check: IsValid = %v, Persisted = %v, want valid and not persisted (%s)", result.IsValid, result.Persisted, result.ErrorMessage)
+	}
+	if stored, _ := repo.List(context.Background()); len(stored) != 0 {
+		t.Errorf("Check stored %d records, want none", len(stored))
+	}
+
+	result, err = uc.Attest(owner, symgroup.MirrorNames, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || !result.Persisted {
+		t.Errorf("Attest: IsValid = %v, Persisted = %v, want valid and persisted", result.IsValid, result.Persisted)
+	}
+}
+
+func TestAttestBatch(t *testing.T) {
+	owner := "alice@example.com"
+	validDomains := []string{"example.com", "com.example"}
+	validID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), validDomains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.example.com": {validID},
+			"_suns.com.example": {validID},
+		},
+	})
+	repo := memrepo.NewMemoryRepository()
+	uc := NewAttestationUseCase(dnsService, repo)
+
+	items := []BatchItem{
+		{Owner: owner, Type: symgroup.MirrorNames, Domains: validDomains},
+		{Owner: owner, Type: symgroup.Palindrome, Domains: []string{"co.uk"}},
+		{Owner: owner, Type: symgroup.MirrorNames, Domains: []string{"example.net", "net.example"}},
+	}
+	wantValid := []bool{true, false, false}
+
+	for _, dryRun := range []bool{true, false} {
+		results := uc.AttestBatch(items, 2, dryRun)
+		if len(results) != len(items) {
+			t.Fatalf("got %d results, want %d", len(results), len(items))
+		}
+		for i, r := range results {
+			if r.Err != nil {
+				t.Fatalf("item %d: unexpected error: %v", i, r.Err)
+			}
+			if r.Result.IsValid != wantValid[i] {
+				t.Errorf("item %d: IsValid = %v, want %v (%s)", i, r.Result.IsValid, wantValid[i], r.Result.ErrorMessage)
+			}
+		}
+
+		stored, _ := repo.List(context.Background())
+		if dryRun && len(stored) != 0 {
+			t.Errorf("dry run stored %d records, want none", len(stored))
+		}
+		if !dryRun && len(stored) != len(validDomains) {
+			t.Errorf("stored %d records, want %d", len(stored), len(validDomains))
+		}
+	}
+}
+
+// contestedResolver answers with the group IDs of each owner for example.com and com.example
+func contestedResolver(t *testing.T, owners ...string) *dnsclaimstest.Resolver {
+	t.Helper()
+	domains := []string{"example.com", "com.example"}
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	for _, owner := range owners {
+		id, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+		if err != nil {
+			t.Fatalf("failed to calculate group ID: %v", err)
+		}
+		for _, domain := range domains {
+			resolver.TXTRecords["_suns."+domain] = append(resolver.TXTRecords["_suns."+domain], id)
+		}
+	}
+	return resolver
+}
+
+// storedOwners returns the owners of the stored records
+func storedOwners(t *testing.T, repo *memrepo.MemoryRepository) map[string]bool {
+	t.Helper()
+	stored, err := repo.List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list records: %v", err)
+	}
+	owners := make(map[string]bool)
+	for _, record := range stored {
+		owners[record.Owner] = true
+	}
+	return owners
+}
+
+func TestAttestBatchContestedHostname(t *testing.T) {
+	domains := []string{"example.com", "com.example"}
+	// alice has not published her group ID, so her claim is invalid and does not block bob's
+	dnsService := dnsclaims.NewServiceWithResolver(contestedResolver(t, "bob@example.com", "carol@example.com"))
+	repo := memrepo.NewMemoryRepository()
+	uc := NewAttestationUseCase(dnsService, repo)
+
+	items := []BatchItem{
+		{Owner: "alice@example.com", Type: symgroup.MirrorNames, Domains: domains},
+		{Owner: "bob@example.com", Type: symgroup.MirrorNames, Domains: domains},
+		{Owner: "carol@example.com", Type: symgroup.MirrorNames, Domains: []string{"COM.example", "example.com"}},
+	}
+	results := uc.AttestBatch(items, 3, false)
+
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("item %d: unexpected error: %v", i+1, r.Err)
+		}
+	}
+	if results[0].Result.IsValid {
+		t.Errorf("alice's unpublished claim is valid, want invalid")
+	}
+	if !results[1].Result.IsValid || !results[1].Result.Persisted {
+		t.Errorf("bob's claim: %+v, want valid and stored", results[1].Result)
+	}
+	if results[2].Result.IsValid || !strings.Contains(results[2].Result.ErrorMessage, "item 2 of this batch") {
+		t.Errorf("carol's claim: %+v, want invalid for the conflict with item 2", results[2].Result)
+	}
+	if owners := storedOwners(t, repo); len(owners) != 1 || !owners["bob@example.com"] {
+		t.Errorf("stored records of %v, want only bob's", owners)
+	}
+}
+
+// barrierResolver holds the first n TXT lookups of a name until all n have arrived,
+// so that concurrent attestations all pass their first conflict check before either stores
+type barrierResolver struct {
+	*dnsclaimstest.Resolver
+	name    string
+	mu      sync.Mutex
+	waiting int
+	release chan struct{}
+}
+
+func (r *barrierResolver) LookupTXT(domain string) ([]string, error) {
+	if domain == r.name {
+		r.mu.Lock()
+		r.waiting--
+		if r.waiting == 0 {
+			close(r.release)
+		}
+		wait := r.waiting >= 0
+		r.mu.Unlock()
+		if wait {
+			<-r.release
+		}
+	}
+	return r.Resolver.LookupTXT(domain)
+}
+
+func TestAttestSerializesConflictingStores(t *testing.T) {
+	owners := []string{"alice@example.com", "bob@example.com"}
+	resolver := &barrierResolver{
+		Resolver: contestedResolver(t, owners...),
+		name:     "_suns.example.com",
+		waiting:  len(owners),
+		release:  make(chan struct{}),
+	}
+	repo := memrepo.NewMemoryRepository()
+	uc := NewAttestationUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+
+	results := make([]*AttestResult, len(owners))
+	var wg sync.WaitGroup
+	for i, owner := range owners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := uc.Attest(owner, symgroup.MirrorNames, []string{"example.com", "com.example"})
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", owner, err)
+				return
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	persisted := 0
+	for _, result := range results {
+		if result != nil && result.Persisted {
+			persisted++
+		}
+	}
+	if persisted != 1 {
+		t.Errorf("%d claims persisted, want exactly 1", persisted)
+	}
+	if owners := storedOwners(t, repo); len(owners) != 1 {
+		t.Errorf("stored records of %v, want a single owner", owners)
+	}
+}
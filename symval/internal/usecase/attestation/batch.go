@@ -0,0 +1,82 @@
+package attestation
+
+import (
+	"fmt"
+
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/workpool"
+)
+
+// DefaultBatchWorkers is how many groups AttestBatch attests at once by default.
+// It is small so that a batch does not flood the resolver.
+const DefaultBatchWorkers = 4
+
+// BatchItem is one group to attest in a batch
+type BatchItem struct {
+	Owner   string
+	Type    symgroup.SymmetryType
+	Domains []string
+}
+
+// BatchResult is the outcome of attesting one BatchItem.
+// Err is set if the attestation could not be performed, for example because storing the records failed;
+// an invalid group is not an error, and is reported in Result.
+type BatchResult struct {
+	Result *AttestResult
+	Err    error
+}
+
+// AttestBatch attests each item on at most workers goroutines, returning the results in item order.
+// Every item is checked before any is stored. A hostname claimed by valid items of different owners
+// goes to the first of them; the later items claiming it are invalid.
+// If dryRun is true, the items are checked as with Check and nothing is stored.
+func (uc *AttestationUseCase) AttestBatch(items []BatchItem, workers int, dryRun bool) []BatchResult {
+	results := workpool.Map(len(items), workers, func(i int) BatchResult {
+		item := items[i]
+		result, err := uc.attest(item.Owner, item.Type, item.Domains, false)
+		return BatchResult{Result: result, Err: err}
+	})
+	refuseContested(items, results)
+	if dryRun || uc.repository == nil {
+		return results
+	}
+
+	return workpool.Map(len(items), workers, func(i int) BatchResult {
+		if results[i].Err != nil || !results[i].Result.IsValid {
+			return results[i]
+		}
+		result, err := uc.store(items[i].Owner, results[i].Result)
+		return BatchResult{Result: result, Err: err}
+	})
+}
+
+// refuseContested marks invalid each valid result that claims a hostname
+// an earlier valid result claims for a different owner
+func refuseContested(items []BatchItem, results []BatchResult) {
+	type claim struct {
+		item  int
+		owner string
+	}
+	claimed := make(map[string]claim)
+	for i, r := range results {
+		if r.Err != nil || !r.Result.IsValid {
+			continue
+		}
+		owner := items[i].Owner
+		for _, record := range r.Result.DomainRecords {
+			if first, ok := claimed[record.Hostname]; ok && first.owner != owner {
+				r.Result.IsValid = false
+				r.Result.ErrorMessage = fmt.Sprintf("%s is also claimed by another owner in item %d of this batch", record.Hostname, first.item+1)
+				break
+			}
+		}
+		if !r.Result.IsValid {
+			continue
+		}
+		for _, record := range r.Result.DomainRecords {
+			if _, ok := claimed[record.Hostname]; !ok {
+				claimed[record.Hostname] = claim{item: i, owner: owner}
+			}
+		}
+	}
+}
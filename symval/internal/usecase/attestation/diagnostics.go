@@ -0,0 +1,69 @@
+package attestation
+
+import (
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+// RecordDiagnostic describes one TXT record found for a domain
+type RecordDiagnostic struct {
+	Value    string
+	GroupID  *groupid.GroupIDV1 // Parsed group ID; nil if Value is not a valid v1 group ID
+	Rejected Rejection          // The filter that rejected the record, or "" if it is a record for the expected group
+}
+
+// DomainDiagnostic describes what the checks and DNS found for one domain during attestation
+type DomainDiagnostic struct {
+	Hostname      string
+	Label         string   // The TXT record label queried, like "_suns.example.com"
+	CNAMEPath     []string // Names queried for TXT records, including the CNAME target if one was followed
+	ResolverError string   // The DNS lookup error, if the lookup failed
+	Records       []RecordDiagnostic
+	Problem       string // Why the domain fails attestation, or "" if it has a record for the expected group
+}
+
+// OK reports whether the domain has a record for the expected group
+func (d *DomainDiagnostic) OK() bool {
+	return d.Problem == ""
+}
+
+// LookupFailed reports whether an attestation failed only because DNS lookups failed,
+// such as on a timeout or SERVFAIL, so that whether the group is valid could not be determined.
+// It is false for valid groups, and for groups with any domain whose records were found missing or wrong.
+func (r *AttestResult) LookupFailed() bool {
+	if r.IsValid {
+		return false
+	}
+	failed := false
+	for _, d := range r.Diagnostics {
+		if d.ResolverError != "" {
+			failed = true
+		} else if !d.OK() {
+			return false
+		}
+	}
+	return failed
+}
+
+// diagnoseDomain classifies each TXT record found for a domain against the criteria
+func diagnoseDomain(hostname string, trace *dnsclaims.LookupTrace, lookupErr error, criteria FilterCriteria) DomainDiagnostic {
+	diagnostic := DomainDiagnostic{
+		Hostname:  hostname,
+		Label:     trace.Label,
+		CNAMEPath: trace.CNAMEPath,
+	}
+	if lookupErr != nil {
+		diagnostic.ResolverError = lookupErr.Error()
+	}
+
+	for _, record := range trace.Records {
+		gid, rejection := matchRecord(record, criteria)
+		diagnostic.Records = append(diagnostic.Records, RecordDiagnostic{
+			Value:    record,
+			GroupID:  gid,
+			Rejected: rejection,
+		})
+	}
+
+	return diagnostic
+}
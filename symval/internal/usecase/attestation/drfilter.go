@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/psl"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
 
@@ -15,6 +17,48 @@ type FilterCriteria struct {
 	GroupID *string
 }
 
+// Rejection names the filter that rejected a TXT record
+type Rejection string
+
+const (
+	// RejectedUnparseable means the record is not a valid v1 group ID
+	RejectedUnparseable Rejection = "unparseable"
+
+	// RejectedType means the record is for a different symmetry type
+	RejectedType Rejection = "type"
+
+	// RejectedOwner means the record is for a different owner
+	RejectedOwner Rejection = "owner"
+
+	// RejectedGroupID means the record matches the owner and type, but belongs to a different group
+	RejectedGroupID Rejection = "group-id"
+)
+
+// matchRecord parses a TXT record and checks it against the criteria.
+// It returns the parsed group ID, if the record could be parsed, and the filter that rejected it, or "" if it matches.
+// Filters are applied in order: parsing, type, owner, then group ID.
+func matchRecord(record string, criteria FilterCriteria) (*groupid.GroupIDV1, Rejection) {
+	gid, err := groupid.ParseGroupIDv1(record)
+	if err != nil {
+		return nil, RejectedUnparseable
+	}
+
+	if criteria.Type != nil && gid.TypeCode != string(*criteria.Type) {
+		return &gid, RejectedType
+	}
+
+	// GroupIDV1 only contains the owner hash, so compare it with the hash of the provided owner
+	if criteria.Owner != nil && gid.OwnerHash != groupid.OwnerHashV1(*criteria.Owner) {
+		return &gid, RejectedOwner
+	}
+
+	if criteria.GroupID != nil && record != *criteria.GroupID {
+		return &gid, RejectedGroupID
+	}
+
+	return &gid, ""
+}
+
 // filterDomainRecords filters DNS records based on the provided criteria
 // and returns matching DomainRecord structs. Records are parsed into GroupIDV1
 // and filtered by optional Owner, Type, and GroupID values.
@@ -22,39 +66,12 @@ func filterDomainRecords(hostname string, records []string, criteria FilterCrite
 	var filtered []*model.DomainRecord
 
 	for _, record := range records {
-		// Parse the record
-		gid, err := groupid.ParseGroupIDv1(record)
-		if err != nil {
-			// Skip invalid records
-			continue
-		}
-
-		// Apply filters if specified
-		if criteria.GroupID != nil && record != *criteria.GroupID {
+		gid, rejection := matchRecord(record, criteria)
+		if rejection != "" {
+			// Skip invalid and non-matching records
 			continue
 		}
 
-		if criteria.Type != nil && gid.TypeCode != string(*criteria.Type) {
-			continue
-		}
-
-		// For owner filtering, we need to calculate the expected owner hash
-		if criteria.Owner != nil {
-			// Since GroupIDV1 only contains OwnerHash, we need to calculate
-			// the expected hash from the provided owner
-			expectedGroupID, err := groupid.CalculateV1(*criteria.Owner, gid.TypeCode, []string{hostname})
-			if err != nil {
-				continue
-			}
-			expectedGID, err := groupid.ParseGroupIDv1(expectedGroupID)
-			if err != nil {
-				continue
-			}
-			if gid.OwnerHash != expectedGID.OwnerHash {
-				continue
-			}
-		}
-
 		// Create DomainRecord for this matching record
 		var ownerValue string
 		if criteria.Owner != nil {
@@ -69,11 +86,14 @@ func filterDomainRecords(hostname string, records []string, criteria FilterCrite
 		}
 
 		filtered = append(filtered, &model.DomainRecord{
-			Owner:        ownerValue,
-			Type:         typeValue,
-			Hostname:     hostname,
-			GroupID:      record,
-			ValidateTime: validateTime,
+			Owner:             ownerValue,
+			Type:              typeValue,
+			Hostname:          hostname,
+			UnicodeHostname:   idn.ToUnicode(hostname),
+			IDNSafety:         idn.Assess(hostname).Verdict,
+			RegistrableDomain: psl.RegistrableDomain(hostname),
+			GroupID:           record,
+			ValidateTime:      validateTime,
 		})
 	}
 
@@ -0,0 +1,237 @@
+package conflict
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+// Policy decides whether a hostname already held by one owner may be claimed by another
+type Policy string
+
+const (
+	// FirstCome keeps a hostname with the owner who attested it first; later owners are always refused
+	FirstCome Policy = "first-come"
+
+	// RequireRelease lets a later owner claim a hostname once the earlier owner's _suns TXT records are gone
+	RequireRelease Policy = "require-release"
+)
+
+// DefaultPolicy is the policy used when none is configured
+const DefaultPolicy = FirstCome
+
+// ParsePolicy parses a policy name, returning DefaultPolicy for an empty string
+func ParsePolicy(name string) (Policy, error) {
+	switch Policy(strings.ToLower(name)) {
+	case "":
+		return DefaultPolicy, nil
+	case FirstCome:
+		return FirstCome, nil
+	case RequireRelease:
+		return RequireRelease, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q (expected %s or %s)", name, FirstCome, RequireRelease)
+	}
+}
+
+// Conflict is a hostname claimed by a new owner while records for an earlier owner are stored
+type Conflict struct {
+	Hostname string
+
+	// Claimant is the new owner, if known; ClaimantHash is always set
+	Claimant     string
+	ClaimantHash string
+
+	// Existing holds the stored records for the hostname that belong to other owners
+	Existing []*model.DomainRecord
+
+	// Released is true if none of the earlier owners' TXT records are still published
+	Released bool
+
+	// Blocking is true if the policy refuses the claim
+	Blocking bool
+}
+
+// Report is the result of a conflict check
+type Report struct {
+	Policy    Policy
+	Conflicts []Conflict
+}
+
+// Blocking reports whether any conflict refuses the claim
+func (r *Report) Blocking() bool {
+	for _, c := range r.Conflicts {
+		if c.Blocking {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a human-readable conflict report
+func (r *Report) String() string {
+	if len(r.Conflicts) == 0 {
+		return "No ownership conflicts"
+	}
+
+	var b strings.Builder
+	for i, c := range r.Conflicts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		claimant := c.Claimant
+		if claimant == "" {
+			claimant = "owner hash " + c.ClaimantHash
+		}
+		fmt.Fprintf(&b, "Conflict on %s: claimed by %s, but already attested by:\n", c.Hostname, claimant)
+		for _, record := range c.Existing {
+			fmt.Fprintf(&b, "  - %s in group %s (validated %s)\n",
+				record.Owner, record.GroupID, record.ValidateTime.UTC().Format("2006-01-02 15:04:05 MST"))
+		}
+		switch {
+		case !c.Blocking:
+			fmt.Fprintf(&b, "  Resolution (%s): allowed, the earlier owner's TXT records are gone\n", r.Policy)
+		case r.Policy == RequireRelease:
+			fmt.Fprintf(&b, "  Resolution (%s): refused until the earlier owner removes their _suns TXT records\n", r.Policy)
+		default:
+			fmt.Fprintf(&b, "  Resolution (%s): refused, the earlier owner keeps the hostname\n", r.Policy)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Detector finds hostnames claimed by more than one owner
+type Detector struct {
+	dnsService *dnsclaims.Service
+	repository model.DomainRepository
+	policy     Policy
+}
+
+// NewDetector creates a conflict detector that applies policy to records in repo.
+// dnsService is used by Inspect, and by the RequireRelease policy to check whether earlier owners' records are gone.
+func NewDetector(dnsService *dnsclaims.Service, repo model.DomainRepository, policy Policy) *Detector {
+	return &Detector{
+		dnsService: dnsService,
+		repository: repo,
+		policy:     policy,
+	}
+}
+
+// Check reports conflicts between a claim by owner on hostnames and records stored for other owners
+func (d *Detector) Check(ctx context.Context, owner string, hostnames []string) (*Report, error) {
+	report := &Report{Policy: d.policy}
+	for _, hostname := range hostnames {
+		conflict, err := d.check(ctx, hostname, owner, groupid.OwnerHashV1(owner))
+		if err != nil {
+			return nil, err
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, *conflict)
+		}
+	}
+	return report, nil
+}
+
+// Inspect reports conflicts between the owners currently claiming hostname in DNS
+// and the owners of records stored for it. Claimants are only known by their owner hash.
+func (d *Detector) Inspect(ctx context.Context, hostname string) (*Report, error) {
+	report := &Report{Policy: d.policy}
+
+	claimantHashes, err := d.claimedOwnerHashes(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claimantHash := range claimantHashes {
+		conflict, err := d.check(ctx, hostname, "", claimantHash)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, *conflict)
+		}
+	}
+	return report, nil
+}
+
+// check returns the conflict between a claimant and the stored records for hostname, or nil if there is none
+func (d *Detector) check(ctx context.Context, hostname, claimant, claimantHash string) (*Conflict, error) {
+	records, err := d.repository.ListByHostname(ctx, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records for %s: %w", hostname, err)
+	}
+
+	var existing []*model.DomainRecord
+	for _, record := range records {
+		if groupid.OwnerHashV1(record.Owner) == claimantHash {
+			// The claimant already holds the hostname, so this is a renewal rather than a new claim
+			return nil, nil
+		}
+		existing = append(existing, record)
+	}
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	conflict := &Conflict{
+		Hostname:     hostname,
+		Claimant:     claimant,
+		ClaimantHash: claimantHash,
+		Existing:     existing,
+		Blocking:     true,
+	}
+
+	if d.policy == RequireRelease {
+		released, err := d.released(hostname, existing)
+		if err != nil {
+			return nil, err
+		}
+		conflict.Released = released
+		conflict.Blocking = !released
+	}
+
+	return conflict, nil
+}
+
+// released reports whether none of the existing records' owners still publish a claim on hostname
+func (d *Detector) released(hostname string, existing []*model.DomainRecord) (bool, error) {
+	claimed, err := d.claimedOwnerHashes(hostname)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range existing {
+		ownerHash := groupid.OwnerHashV1(record.Owner)
+		for _, hash := range claimed {
+			if hash == ownerHash {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// claimedOwnerHashes returns the distinct owner hashes of the group IDs published for hostname
+func (d *Detector) claimedOwnerHashes(hostname string) ([]string, error) {
+	records, err := d.dnsService.Lookup(hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup DNS records for %s: %w", hostname, err)
+	}
+
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, record := range records {
+		gid, err := groupid.ParseGroupIDv1(record)
+		if err != nil {
+			continue
+		}
+		if !seen[gid.OwnerHash] {
+			seen[gid.OwnerHash] = true
+			hashes = append(hashes, gid.OwnerHash)
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,125 @@
+package conflict
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func mustGroupID(t *testing.T, owner string, hostnames ...string) string {
+	t.Helper()
+	gid, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), hostnames)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	return gid
+}
+
+func setupRepo(t *testing.T) model.DomainRepository {
+	t.Helper()
+	repo := memrepo.NewMemoryRepository()
+	record := &model.DomainRecord{
+		Owner:        "alice@example.com",
+		Type:         symgroup.Palindrome,
+		Hostname:     "aba.example",
+		GroupID:      mustGroupID(t, "alice@example.com", "aba.example"),
+		ValidateTime: time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+	if _, err := repo.Upsert(context.Background(), record); err != nil {
+		t.Fatalf("failed to seed repository: %v", err)
+	}
+	return repo
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Policy
+		wantErr bool
+	}{
+		{"", FirstCome, false},
+		{"first-come", FirstCome, false},
+		{"Require-Release", RequireRelease, false},
+		{"last-come", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %q, %v, want %q, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	aliceGroup := mustGroupID(t, "alice@example.com", "aba.example")
+	bobGroup := mustGroupID(t, "bob@example.com", "aba.example")
+
+	tests := []struct {
+		name          string
+		policy        Policy
+		owner         string
+		published     []string
+		wantConflicts int
+		wantBlocking  bool
+	}{
+		{"same owner renews", FirstCome, "alice@example.com", []string{aliceGroup}, 0, false},
+		{"first-come refuses a new owner", FirstCome, "bob@example.com", []string{bobGroup}, 1, true},
+		{"require-release refuses while the earlier claim is published", RequireRelease, "bob@example.com", []string{aliceGroup, bobGroup}, 1, true},
+		{"require-release allows once the earlier claim is gone", RequireRelease, "bob@example.com", []string{bobGroup}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+				TXTRecords: map[string][]string{"_suns.aba.example": tt.published},
+			})
+			detector := NewDetector(dnsService, setupRepo(t), tt.policy)
+
+			report, err := detector.Check(ctx, tt.owner, []string{"aba.example"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(report.Conflicts) != tt.wantConflicts {
+				t.Fatalf("got %d conflicts, want %d", len(report.Conflicts), tt.wantConflicts)
+			}
+			if report.Blocking() != tt.wantBlocking {
+				t.Errorf("Blocking() = %v, want %v\n%s", report.Blocking(), tt.wantBlocking, report)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	ctx := context.Background()
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.aba.example": {mustGroupID(t, "mallory@example.com", "aba.example")},
+		},
+	})
+	detector := NewDetector(dnsService, setupRepo(t), FirstCome)
+
+	report, err := detector.Inspect(ctx, "aba.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1", len(report.Conflicts))
+	}
+
+	text := report.String()
+	for _, want := range []string{"Conflict on aba.example", groupid.OwnerHashV1("mallory@example.com"), "alice@example.com", "first-come"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report does not mention %q:\n%s", want, text)
+		}
+	}
+}
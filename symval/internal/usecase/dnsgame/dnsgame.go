@@ -0,0 +1,257 @@
+package dnsgame
+
+import (
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/idn"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/validation"
+)
+
+const (
+	// maxLabelLen is the maximum length of a single DNS label
+	maxLabelLen = 63
+
+	// DefaultResultTTL is how long the result of a claim is reused for repeated claims of the same group
+	DefaultResultTTL = 30 * time.Second
+)
+
+// tokenEncoding is base32 without padding, which only produces characters valid in DNS labels.
+// Tokens are emitted in lowercase and decoded case-insensitively,
+// because resolvers may randomize the case of query names.
+var tokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ResultCode is the first word of the TXT answer to a claim query
+type ResultCode string
+
+const (
+	CodeOK      ResultCode = "ok"      // The group was attested and stored
+	CodeFailed  ResultCode = "fail"    // The group's DNS records did not prove the claim
+	CodeBadName ResultCode = "badname" // The query name could not be decoded into a claim
+	CodeError   ResultCode = "error"   // Attestation could not be completed
+)
+
+// Claim is a decoded claim query
+type Claim struct {
+	Owner   string
+	Type    symgroup.SymmetryType
+	Domain  string
+	Domains []string // Domain plus any partner domains implied by Type
+}
+
+// Result is the outcome of a claim query
+type Result struct {
+	Code    ResultCode
+	Message string
+}
+
+// TXT returns the result formatted as a TXT record value, like "ok v1:a:..."
+func (r Result) TXT() string {
+	if r.Message == "" {
+		return string(r.Code)
+	}
+	return string(r.Code) + " " + r.Message
+}
+
+// EncodeToken encodes an owner and symmetry type into a single DNS label.
+// The token is the lowercase unpadded base32 encoding of "<typecode>:<owner>".
+func EncodeToken(owner string, symmetryType symgroup.SymmetryType) (string, error) {
+	if owner == "" {
+		return "", fmt.Errorf("owner cannot be empty")
+	}
+	if _, ok := symgroup.TypeCodeToName[string(symmetryType)]; !ok {
+		return "", fmt.Errorf("invalid symmetry type: %s", symmetryType)
+	}
+
+	token := strings.ToLower(tokenEncoding.EncodeToString([]byte(string(symmetryType) + ":" + owner)))
+	if len(token) > maxLabelLen {
+		return "", fmt.Errorf("owner %q is too long to encode in a DNS label (max %d bytes)", owner, maxLabelLen*5/8-2)
+	}
+	return token, nil
+}
+
+// DecodeToken decodes a token produced by EncodeToken
+func DecodeToken(token string) (string, symgroup.SymmetryType, error) {
+	raw, err := tokenEncoding.DecodeString(strings.ToUpper(token))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid token encoding: %w", err)
+	}
+
+	typeCode, owner, found := strings.Cut(string(raw), ":")
+	if !found || owner == "" {
+		return "", "", fmt.Errorf("invalid token: expected <type>:<owner>")
+	}
+	if _, ok := symgroup.TypeCodeToName[typeCode]; !ok {
+		return "", "", fmt.Errorf("invalid token: unknown symmetry type %q", typeCode)
+	}
+
+	return owner, symgroup.SymmetryType(typeCode), nil
+}
+
+// ClaimName returns the name a member should query to claim domain, like
+// "<token>.example.com.claim.suns.bz".
+func ClaimName(owner string, symmetryType symgroup.SymmetryType, domain, zone string) (string, error) {
+	token, err := EncodeToken(owner, symmetryType)
+	if err != nil {
+		return "", err
+	}
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	return token + "." + domain + "." + zone, nil
+}
+
+// ParseClaim decodes the portion of a query name in front of the zone,
+// like "<token>.example.com", into a Claim.
+func ParseClaim(prefix string) (*Claim, error) {
+	prefix = strings.TrimSuffix(strings.ToLower(prefix), ".")
+	token, domain, found := strings.Cut(prefix, ".")
+	if !found || token == "" || domain == "" {
+		return nil, fmt.Errorf("expected <token>.<domain> in front of the zone")
+	}
+
+	owner, symmetryType, err := DecodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := GroupDomains(symmetryType, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claim{
+		Owner:   owner,
+		Type:    symmetryType,
+		Domain:  domain,
+		Domains: domains,
+	}, nil
+}
+
+// GroupDomains returns every domain in the group implied by a single member domain.
+// Two-domain symmetry types have a partner that can be derived from the first domain:
+// doubleflip180 partners are the 180-degree flip, and mirrornames partners have their labels reversed.
+// Partners are derived from the U-label form, so punycode labels are flipped by what they display.
+func GroupDomains(symmetryType symgroup.SymmetryType, domain string) ([]string, error) {
+	domain = idn.ToUnicode(domain)
+	switch symmetryType {
+	case symgroup.DoubleFlip180:
+		partner, err := validation.Flip180Hostname(domain)
+		if err != nil {
+			return nil, fmt.Errorf("cannot derive doubleflip180 partner for %s: %w", domain, err)
+		}
+		return []string{domain, partner}, nil
+	case symgroup.MirrorNames:
+		labels := strings.Split(domain, ".")
+		for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+			labels[i], labels[j] = labels[j], labels[i]
+		}
+		return []string{domain, strings.Join(labels, ".")}, nil
+	default:
+		return []string{domain}, nil
+	}
+}
+
+// DNSGameUseCase turns claim queries into attestations.
+// Resolvers retry queries and randomize their case, so one claim arrives as several queries;
+// claims of the same group share one attestation, and its result is reused for a short time.
+type DNSGameUseCase struct {
+	attestUseCase *attestation.AttestationUseCase
+	logger        *slog.Logger
+	resultTTL     time.Duration
+
+	mu      sync.Mutex
+	results map[string]*claimResult // By group ID
+}
+
+// claimResult is the result of attesting a group, shared by the claims that arrive while it is in flight
+type claimResult struct {
+	done    chan struct{} // Closed once result is set
+	result  Result
+	expires time.Time // Zero while in flight
+}
+
+// NewDNSGameUseCase creates a new DNS game use case backed by the given attestation use case
+func NewDNSGameUseCase(attestUseCase *attestation.AttestationUseCase) *DNSGameUseCase {
+	return &DNSGameUseCase{
+		attestUseCase: attestUseCase,
+		logger:        slog.Default().With("component", "dnsgame"),
+		resultTTL:     DefaultResultTTL,
+		results:       make(map[string]*claimResult),
+	}
+}
+
+// SetResultTTL sets how long the result of a claim is reused; 0 only shares attestations in flight
+func (uc *DNSGameUseCase) SetResultTTL(ttl time.Duration) {
+	uc.resultTTL = ttl
+}
+
+// Play decodes a claim from the query name prefix and attests it,
+// unless the same group was just attested or is being attested.
+// The result is always returned as a value so that it can be sent back to the client.
+func (uc *DNSGameUseCase) Play(prefix string) Result {
+	claim, err := ParseClaim(prefix)
+	if err != nil {
+		return Result{Code: CodeBadName, Message: err.Error()}
+	}
+
+	gid, err := groupid.CalculateV1(claim.Owner, string(claim.Type), claim.Domains)
+	if err != nil {
+		return uc.attest(claim)
+	}
+
+	now := time.Now()
+	uc.mu.Lock()
+	if cached, ok := uc.results[gid]; ok && (cached.expires.IsZero() || now.Before(cached.expires)) {
+		uc.mu.Unlock()
+		<-cached.done
+		return cached.result
+	}
+	for key, cached := range uc.results {
+		if !cached.expires.IsZero() && !now.Before(cached.expires) {
+			delete(uc.results, key)
+		}
+	}
+	pending := &claimResult{done: make(chan struct{})}
+	uc.results[gid] = pending
+	uc.mu.Unlock()
+
+	pending.result = uc.attest(claim)
+
+	// Errors may be transient, so they are only shared with the claims that were waiting
+	uc.mu.Lock()
+	pending.expires = time.Now()
+	if pending.result.Code != CodeError {
+		pending.expires = pending.expires.Add(uc.resultTTL)
+	}
+	uc.mu.Unlock()
+	close(pending.done)
+
+	return pending.result
+}
+
+// attest attests a claim
+func (uc *DNSGameUseCase) attest(claim *Claim) Result {
+	uc.logger.Info("Claim received",
+		slog.String("owner", claim.Owner),
+		slog.String("type", string(claim.Type)),
+		slog.Any("domains", claim.Domains))
+
+	result, err := uc.attestUseCase.Attest(claim.Owner, claim.Type, claim.Domains)
+	if err != nil {
+		uc.logger.Error("Attestation error", slog.String("error", err.Error()))
+		return Result{Code: CodeError, Message: err.Error()}
+	}
+
+	if !result.IsValid {
+		return Result{Code: CodeFailed, Message: result.ErrorMessage}
+	}
+
+	return Result{Code: CodeOK, Message: result.ExpectedID}
+}
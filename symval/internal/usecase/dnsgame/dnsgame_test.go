@@ -0,0 +1,242 @@
+package dnsgame
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		owner string
+		typ   symgroup.SymmetryType
+	}{
+		{"email owner", "alice@example.com", symgroup.Palindrome},
+		{"owner with colon", "a:b", symgroup.MirrorNames},
+		{"mixed case owner", "Bob", symgroup.DoubleFlip180},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := EncodeToken(tt.owner, tt.typ)
+			if err != nil {
+				t.Fatalf("EncodeToken() error = %v", err)
+			}
+			if token != strings.ToLower(token) {
+				t.Errorf("EncodeToken() = %q, want lowercase", token)
+			}
+
+			// Resolvers may randomize case, so decoding must be case-insensitive
+			owner, typ, err := DecodeToken(strings.ToUpper(token))
+			if err != nil {
+				t.Fatalf("DecodeToken() error = %v", err)
+			}
+			if owner != tt.owner || typ != tt.typ {
+				t.Errorf("DecodeToken() = (%q, %q), want (%q, %q)", owner, typ, tt.owner, tt.typ)
+			}
+		})
+	}
+}
+
+func TestEncodeTokenErrors(t *testing.T) {
+	if _, err := EncodeToken("", symgroup.Palindrome); err == nil {
+		t.Error("expected error for empty owner")
+	}
+	if _, err := EncodeToken("alice", symgroup.SymmetryType("z")); err == nil {
+		t.Error("expected error for invalid type")
+	}
+	if _, err := EncodeToken(strings.Repeat("x", 38), symgroup.Palindrome); err == nil {
+		t.Error("expected error for owner that does not fit in a label")
+	}
+	if _, err := EncodeToken(strings.Repeat("x", 37), symgroup.Palindrome); err != nil {
+		t.Errorf("expected 37 byte owner to fit in a label, got %v", err)
+	}
+}
+
+func TestParseClaim(t *testing.T) {
+	token, err := EncodeToken("alice", symgroup.MirrorNames)
+	if err != nil {
+		t.Fatalf("EncodeToken() error = %v", err)
+	}
+
+	claim, err := ParseClaim(token + ".a.b.c")
+	if err != nil {
+		t.Fatalf("ParseClaim() error = %v", err)
+	}
+	if claim.Owner != "alice" || claim.Type != symgroup.MirrorNames || claim.Domain != "a.b.c" {
+		t.Errorf("ParseClaim() = %+v", claim)
+	}
+	if len(claim.Domains) != 2 || claim.Domains[1] != "c.b.a" {
+		t.Errorf("ParseClaim() domains = %v, want [a.b.c c.b.a]", claim.Domains)
+	}
+
+	badNames := []string{"", token, "!!!.example.com", "mfrgg.example.com"}
+	for _, name := range badNames {
+		if _, err := ParseClaim(name); err == nil {
+			t.Errorf("ParseClaim(%q) expected error", name)
+		}
+	}
+}
+
+func TestGroupDomains(t *testing.T) {
+	tests := []struct {
+		typ     symgroup.SymmetryType
+		domain  string
+		want    []string
+		wantErr bool
+	}{
+		{symgroup.Palindrome, "abba.example", []string{"abba.example"}, false},
+		{symgroup.Flip180, "zq.suns.bz", []string{"zq.suns.bz"}, false},
+		{symgroup.DoubleFlip180, "zq.su", []string{"zq.su", "ns.bz"}, false},
+		{symgroup.DoubleFlip180, "bücher.example", nil, true},
+		{symgroup.DoubleFlip180, "xn--s-k2a.su", []string{"ɯs.su", "ns.sm"}, false},
+		{symgroup.MirrorNames, "one.two.three", []string{"one.two.three", "three.two.one"}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := GroupDomains(tt.typ, tt.domain)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GroupDomains(%s, %s) error = %v, wantErr %v", tt.typ, tt.domain, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("GroupDomains(%s, %s) = %v, want %v", tt.typ, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	owner := "alice@example.com"
+	domain := "abc.cba"
+	gid, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{domain})
+	if err != nil {
+		t.Fatalf("CalculateV1() error = %v", err)
+	}
+
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{
+		"_suns." + domain: {gid},
+	}}
+	repo := memrepo.NewMemoryRepository()
+	uc := NewDNSGameUseCase(attestation.NewAttestationUseCase(dnsclaims.NewServiceWithResolver(resolver), repo))
+
+	token, _ := EncodeToken(owner, symgroup.Palindrome)
+
+	t.Run("valid claim is attested and stored", func(t *testing.T) {
+		result := uc.Play(token + "." + domain)
+		if result.Code != CodeOK {
+			t.Fatalf("Play() = %+v, want code %s", result, CodeOK)
+		}
+		if result.TXT() != "ok "+gid {
+			t.Errorf("TXT() = %q, want %q", result.TXT(), "ok "+gid)
+		}
+		if _, err := repo.Get(context.Background(), gid, domain); err != nil {
+			t.Errorf("expected record to be stored, got %v", err)
+		}
+	})
+
+	t.Run("claim without records fails", func(t *testing.T) {
+		result := uc.Play(token + ".otto.example")
+		if result.Code != CodeFailed {
+			t.Errorf("Play() = %+v, want code %s", result, CodeFailed)
+		}
+	})
+
+	t.Run("undecodable name", func(t *testing.T) {
+		result := uc.Play("hello.example")
+		if result.Code != CodeBadName {
+			t.Errorf("Play() = %+v, want code %s", result, CodeBadName)
+		}
+	})
+}
+
+// countingResolver counts TXT lookups, blocking each until released
+type countingResolver struct {
+	dnsclaimstest.Resolver
+	lookups atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingResolver) LookupTXT(domain string) ([]string, error) {
+	c.lookups.Add(1)
+	<-c.release
+	return c.Resolver.LookupTXT(domain)
+}
+
+func TestPlayDeduplicatesClaims(t *testing.T) {
+	owner := "alice@example.com"
+	domain := "abc.cba"
+	gid, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{domain})
+	if err != nil {
+		t.Fatalf("CalculateV1() error = %v", err)
+	}
+	newUseCase := func(release chan struct{}) (*DNSGameUseCase, *countingResolver) {
+		resolver := &countingResolver{
+			Resolver: dnsclaimstest.Resolver{TXTRecords: map[string][]string{"_suns." + domain: {gid}}},
+			release:  release,
+		}
+		return NewDNSGameUseCase(attestation.NewAttestationUseCase(dnsclaims.NewServiceWithResolver(resolver), memrepo.NewMemoryRepository())), resolver
+	}
+	token, _ := EncodeToken(owner, symgroup.Palindrome)
+
+	// Count the lookups of a single attestation
+	released := make(chan struct{})
+	close(released)
+	single, singleResolver := newUseCase(released)
+	single.SetResultTTL(0)
+	single.Play(token + "." + domain)
+	perAttestation := singleResolver.lookups.Load()
+
+	uc, resolver := newUseCase(make(chan struct{}))
+
+	// Resolvers retry and randomize case, so the same claim arrives several times at once
+	names := []string{token + "." + domain, strings.ToUpper(token + "." + domain), token + ".ABC.cba"}
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = uc.Play(name)
+		}()
+	}
+
+	// Wait for the first attestation to look up the group, then give the others time to join it
+	for resolver.lookups.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(resolver.release)
+	wg.Wait()
+
+	if n := resolver.lookups.Load(); n != perAttestation {
+		t.Errorf("looked up TXT records %d times, want %d for one attestation of all claims", n, perAttestation)
+	}
+	for i, result := range results {
+		if result.Code != CodeOK {
+			t.Errorf("claim %d: Play() = %+v, want code %s", i, result, CodeOK)
+		}
+	}
+
+	// A claim right after the attestation reuses its result
+	if result := uc.Play(names[0]); result.Code != CodeOK || resolver.lookups.Load() != perAttestation {
+		t.Errorf("Play() = %+v after %d lookups, want the cached result", result, resolver.lookups.Load())
+	}
+
+	// Without a result TTL, each claim after the last attestation finished attests again
+	single.Play(names[0])
+	single.Play(names[0])
+	if n := singleResolver.lookups.Load(); n != 3*perAttestation {
+		t.Errorf("looked up TXT records %d times, want %d with the cache expired", n, 3*perAttestation)
+	}
+}
@@ -0,0 +1,336 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/mrled/suns/symval/internal/idn"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/psl"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/concheck"
+	"github.com/mrled/suns/symval/internal/validation"
+)
+
+// DoctorUseCase diagnoses why a group of domains does or does not attest
+type DoctorUseCase struct {
+	dnsService *dnsclaims.Service
+	repository model.DomainRepository
+}
+
+// NewDoctorUseCase creates a new doctor use case.
+// If repository is nil, the stored state of the group is not checked.
+func NewDoctorUseCase(dnsService *dnsclaims.Service, repo model.DomainRepository) *DoctorUseCase {
+	return &DoctorUseCase{
+		dnsService: dnsService,
+		repository: repo,
+	}
+}
+
+// RecordLint describes one TXT value found for a domain and how it differs from the expected group ID
+type RecordLint struct {
+	Value     string
+	Expected  bool     // The value is exactly the expected group ID
+	Malformed bool     // The value is not a well-formed v1 group ID, so it cannot be a record for any group
+	Problems  []string // What differs from the expected group ID; empty if Expected
+}
+
+// DomainReport describes the DNS and stored state of one domain in the group
+type DomainReport struct {
+	Hostname      string
+	Label         string   // The TXT record label queried, like "_suns.example.com"
+	CNAMEPath     []string // Names queried for TXT records, including the CNAME target if one was followed
+	ResolverError string
+	Records       []RecordLint
+
+	// MixedOwners is set if the records at the label were published for more than one owner
+	MixedOwners string
+
+	// PolicyRecord names the zone policy that refuses the owner, if any
+	PolicyRecord string
+
+	// Stored is the stored record for the expected group, or nil if there is none or no repository was given
+	Stored *model.DomainRecord
+
+	// OtherGroups holds stored records for the hostname in other groups
+	OtherGroups []*model.DomainRecord
+}
+
+// HasExpected reports whether the expected group ID is published for the domain
+func (d *DomainReport) HasExpected() bool {
+	for _, record := range d.Records {
+		if record.Expected {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishName returns the name where the expected TXT record must be published:
+// the CNAME target if the label is delegated, otherwise the label itself
+func (d *DomainReport) PublishName() string {
+	return strings.TrimSuffix(d.CNAMEPath[len(d.CNAMEPath)-1], ".")
+}
+
+// Report is the result of diagnosing a group
+type Report struct {
+	Owner      string
+	Type       symgroup.SymmetryType
+	ExpectedID string
+	Domains    []DomainReport
+
+	// InputError explains why the group can never attest as given, like a public suffix or a homograph
+	InputError string
+
+	// SymmetryError explains why the domains do not form a valid group of the type, or "" if they do
+	SymmetryError string
+
+	// StoreChecked is true if a repository was given
+	StoreChecked bool
+
+	// Fixes lists concrete steps to make the group attest, in order
+	Fixes []string
+}
+
+// Healthy reports whether the group should attest as-is
+func (r *Report) Healthy() bool {
+	return len(r.Fixes) == 0
+}
+
+// Diagnose computes the expected group ID, lints each domain's _suns records against it,
+// tests the symmetry of the domains, checks the stored state, and suggests fixes.
+func (uc *DoctorUseCase) Diagnose(ctx context.Context, owner string, symmetryType symgroup.SymmetryType, domains []string) (*Report, error) {
+	if err := hostname.CheckCanonical(domains); err != nil {
+		return nil, err
+	}
+	expectedID, err := groupid.CalculateV1(owner, string(symmetryType), domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate group ID: %w", err)
+	}
+
+	report := &Report{
+		Owner:        owner,
+		Type:         symmetryType,
+		ExpectedID:   expectedID,
+		StoreChecked: uc.repository != nil,
+	}
+
+	// Names that can never be claimed make everything else moot
+	for _, domain := range domains {
+		if psl.IsPublicSuffix(domain) {
+			report.InputError = fmt.Sprintf("%s is a public suffix and cannot be claimed", domain)
+		} else if assessment := idn.Assess(domain); assessment.Verdict == idn.VerdictReject {
+			report.InputError = fmt.Sprintf("%s is rejected by the homograph check: %s", domain, strings.Join(assessment.Reasons, "; "))
+		}
+		if report.InputError != "" {
+			report.Fixes = append(report.Fixes, fmt.Sprintf("Choose different names: %s.", report.InputError))
+			return report, nil
+		}
+	}
+
+	// Test the symmetry as if every domain published the expected group ID,
+	// so that a group that can never attest is reported before any DNS problems
+	var records []*model.DomainRecord
+	for _, domain := range domains {
+		records = append(records, &model.DomainRecord{
+			Owner:           owner,
+			Type:            symmetryType,
+			Hostname:        domain,
+			UnicodeHostname: idn.ToUnicode(domain),
+			GroupID:         expectedID,
+		})
+	}
+	if _, err := validation.Validate(records); err != nil {
+		report.SymmetryError = err.Error()
+		report.Fixes = append(report.Fixes, fmt.Sprintf("These domains do not form a valid %s group (%s); no TXT records will make them attest.", typeName(string(symmetryType)), err))
+	}
+
+	for _, domain := range domains {
+		domainReport, err := uc.diagnoseDomain(ctx, owner, symmetryType, domains, expectedID, domain)
+		if err != nil {
+			return nil, err
+		}
+		report.Domains = append(report.Domains, *domainReport)
+	}
+
+	if report.SymmetryError == "" {
+		report.Fixes = append(report.Fixes, fixes(report)...)
+	}
+
+	return report, nil
+}
+
+// diagnoseDomain looks up and lints the records for one domain, and checks its policy and stored state
+func (uc *DoctorUseCase) diagnoseDomain(ctx context.Context, owner string, symmetryType symgroup.SymmetryType, domains []string, expectedID, domain string) (*DomainReport, error) {
+	trace, lookupErr := uc.dnsService.Trace(domain)
+	report := &DomainReport{
+		Hostname:  domain,
+		Label:     trace.Label,
+		CNAMEPath: trace.CNAMEPath,
+	}
+	if lookupErr != nil {
+		report.ResolverError = lookupErr.Error()
+	}
+
+	var parsed []groupid.GroupIDV1
+	for _, value := range trace.Records {
+		report.Records = append(report.Records, lintRecord(value, owner, symmetryType, domains, domain, expectedID))
+		if gid, err := groupid.ParseGroupIDv1(value); err == nil {
+			parsed = append(parsed, gid)
+		}
+	}
+	if err := concheck.CheckGroupIdConsistency(parsed); err != nil {
+		report.MixedOwners = err.Error()
+	}
+
+	policy, err := uc.dnsService.FindPolicy(domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find policy for %s: %w", domain, err)
+	}
+	if policy != nil && !policy.Allows(owner) {
+		report.PolicyRecord = policy.RecordName()
+	}
+
+	if uc.repository != nil {
+		stored, err := uc.repository.ListByHostname(ctx, domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list records for %s: %w", domain, err)
+		}
+		for _, record := range stored {
+			if record.GroupID == expectedID {
+				report.Stored = record
+			} else {
+				report.OtherGroups = append(report.OtherGroups, record)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// lintRecord compares a TXT value with the expected group ID and explains every difference
+func lintRecord(value, owner string, symmetryType symgroup.SymmetryType, domains []string, domain, expectedID string) RecordLint {
+	lint := RecordLint{Value: value}
+	if value == expectedID {
+		lint.Expected = true
+		return lint
+	}
+
+	// Stray quotes and whitespace usually come from pasting the value with the quotes of a zone file
+	cleaned := value
+	if strings.ContainsAny(cleaned, `"'`) {
+		lint.Malformed = true
+		lint.Problems = append(lint.Problems, "contains quotation marks; publish the value without them")
+		cleaned = strings.Trim(cleaned, `"'`)
+	}
+	if trimmed := strings.TrimSpace(cleaned); trimmed != cleaned {
+		lint.Malformed = true
+		lint.Problems = append(lint.Problems, "has leading or trailing whitespace")
+		cleaned = trimmed
+	}
+	if cleaned == expectedID {
+		return lint
+	}
+
+	parts := strings.Split(cleaned, ":")
+	if len(parts) != 4 {
+		lint.Malformed = true
+		lint.Problems = append(lint.Problems, fmt.Sprintf("is not a group ID: expected 4 colon-separated parts, got %d", len(parts)))
+		return lint
+	}
+	if parts[0] != groupid.IDVersion {
+		lint.Malformed = true
+		lint.Problems = append(lint.Problems, fmt.Sprintf("has version %q, expected %q", parts[0], groupid.IDVersion))
+	}
+	if parts[1] != string(symmetryType) {
+		lint.Problems = append(lint.Problems, fmt.Sprintf("has type %s (%s), expected %s (%s)",
+			parts[1], typeName(parts[1]), symmetryType, typeName(string(symmetryType))))
+	}
+	if parts[2] != groupid.OwnerHashV1(owner) {
+		lint.Problems = append(lint.Problems, "has a different owner hash: it was calculated for another owner, or the owner is spelled differently (owners are case-sensitive)")
+	}
+
+	expectedParts := strings.Split(expectedID, ":")
+	if parts[3] != expectedParts[3] {
+		problem := "has a different domains hash: it was calculated for a different set of domains"
+		if len(domains) > 1 && parts[3] == domainsHash(owner, symmetryType, domain) {
+			problem += fmt.Sprintf("; it covers %s alone, but a group ID must be calculated from all %d domains", domain, len(domains))
+		}
+		lint.Problems = append(lint.Problems, problem)
+	}
+
+	return lint
+}
+
+// fixes returns the steps needed to make the group attest, given its DNS and stored state
+func fixes(report *Report) []string {
+	var steps []string
+
+	// Zone policies are controlled by someone else, so list them first
+	for _, d := range report.Domains {
+		if d.PolicyRecord != "" {
+			steps = append(steps, fmt.Sprintf("Ask the owner of the zone to allow this owner in %s: publish %s. IN TXT \"v1:allow:%s\"",
+				d.PolicyRecord, d.PolicyRecord, groupid.OwnerHashV1(report.Owner)))
+		}
+	}
+
+	for _, d := range report.Domains {
+		if d.ResolverError != "" {
+			steps = append(steps, fmt.Sprintf("Fix DNS resolution of %s, which failed: %s", d.Label, d.ResolverError))
+			continue
+		}
+		if d.HasExpected() {
+			continue
+		}
+		steps = append(steps, fmt.Sprintf("Publish %s. IN TXT %s", d.PublishName(), dnsclaims.QuoteTXT(report.ExpectedID)))
+		if d.Stored != nil {
+			steps = append(steps, fmt.Sprintf("%s is stored for this group, but will be dropped by the next reattest unless the record is published", d.Hostname))
+		}
+		// Well-formed records for other groups are left alone, since a hostname can belong to several groups
+		for _, record := range d.Records {
+			if record.Malformed {
+				steps = append(steps, fmt.Sprintf("Remove the incorrect record %s. IN TXT %s", d.PublishName(), dnsclaims.QuoteTXT(record.Value)))
+			}
+		}
+	}
+
+	for _, d := range report.Domains {
+		for _, other := range d.OtherGroups {
+			if other.Owner != report.Owner {
+				steps = append(steps, fmt.Sprintf("%s is already attested by another owner in group %s; attestation may be refused as a conflict", d.Hostname, other.GroupID))
+			}
+		}
+	}
+
+	if len(steps) == 0 && report.StoreChecked {
+		for _, d := range report.Domains {
+			if d.Stored == nil {
+				steps = append(steps, "DNS is correct but the group is not stored; run symval attest to record it")
+				break
+			}
+		}
+	}
+
+	return steps
+}
+
+// domainsHash returns the domains hash field of the v1 group ID for a single hostname
+func domainsHash(owner string, symmetryType symgroup.SymmetryType, hostname string) string {
+	gid, err := groupid.CalculateV1(owner, string(symmetryType), []string{hostname})
+	if err != nil {
+		return ""
+	}
+	return strings.Split(gid, ":")[3]
+}
+
+// typeName returns the human-readable name of a type code, or "unknown"
+func typeName(code string) string {
+	if name, ok := symgroup.TypeCodeToName[code]; ok {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,161 @@
+package doctor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+const owner = "alice@example.com"
+
+var domains = []string{"example.com", "com.example"}
+
+func mustGroupID(t *testing.T, owner string, symmetryType symgroup.SymmetryType, hostnames ...string) string {
+	t.Helper()
+	gid, err := groupid.CalculateV1(owner, string(symmetryType), hostnames)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	return gid
+}
+
+func TestLintRecord(t *testing.T) {
+	expectedID := mustGroupID(t, owner, symgroup.MirrorNames, domains...)
+
+	tests := []struct {
+		name          string
+		value         string
+		wantExpected  bool
+		wantMalformed bool
+		wantProblem   string
+	}{
+		{"exact match", expectedID, true, false, ""},
+		{"stray quotes", `"` + expectedID + `"`, false, true, "quotation marks"},
+		{"whitespace", " " + expectedID, false, true, "whitespace"},
+		{"wrong version", "v2" + strings.TrimPrefix(expectedID, "v1"), false, true, `version "v2"`},
+		{"not a group ID", "hello world", false, true, "not a group ID"},
+		{"type mismatch", mustGroupID(t, owner, symgroup.Palindrome, domains...), false, false, "has type a (palindrome)"},
+		{"different owner", mustGroupID(t, "Alice@example.com", symgroup.MirrorNames, domains...), false, false, "different owner hash"},
+		{"calculated for one domain", mustGroupID(t, owner, symgroup.MirrorNames, "example.com"), false, false, "covers example.com alone"},
+		{"different domains", mustGroupID(t, owner, symgroup.MirrorNames, "example.com", "com.elpmaxe"), false, false, "different domains hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lint := lintRecord(tt.value, owner, symgroup.MirrorNames, domains, "example.com", expectedID)
+			if lint.Expected != tt.wantExpected {
+				t.Errorf("Expected = %v, want %v", lint.Expected, tt.wantExpected)
+			}
+			if lint.Malformed != tt.wantMalformed {
+				t.Errorf("Malformed = %v, want %v", lint.Malformed, tt.wantMalformed)
+			}
+			problems := strings.Join(lint.Problems, "\n")
+			if tt.wantProblem != "" && !strings.Contains(problems, tt.wantProblem) {
+				t.Errorf("problems do not mention %q:\n%s", tt.wantProblem, problems)
+			}
+			if tt.wantExpected && len(lint.Problems) > 0 {
+				t.Errorf("expected no problems, got %v", lint.Problems)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	ctx := context.Background()
+	expectedID := mustGroupID(t, owner, symgroup.MirrorNames, domains...)
+
+	t.Run("healthy and stored", func(t *testing.T) {
+		dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+			TXTRecords: map[string][]string{
+				"_suns.example.com": {expectedID},
+				"_suns.com.example": {expectedID},
+			},
+		})
+		repo := memrepo.NewMemoryRepository()
+		for _, domain := range domains {
+			record := &model.DomainRecord{Owner: owner, Type: symgroup.MirrorNames, Hostname: domain, GroupID: expectedID, ValidateTime: time.Now()}
+			if _, err := repo.Upsert(ctx, record); err != nil {
+				t.Fatalf("failed to seed repository: %v", err)
+			}
+		}
+
+		report, err := NewDoctorUseCase(dnsService, repo).Diagnose(ctx, owner, symgroup.MirrorNames, domains)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !report.Healthy() {
+			t.Errorf("expected a healthy report, got fixes %v", report.Fixes)
+		}
+		for _, d := range report.Domains {
+			if d.Stored == nil {
+				t.Errorf("%s: expected the stored record to be found", d.Hostname)
+			}
+		}
+	})
+
+	t.Run("missing and malformed records", func(t *testing.T) {
+		otherGroupID := mustGroupID(t, owner, symgroup.Palindrome, "aba.example")
+		dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+			TXTRecords: map[string][]string{
+				"_suns.example.com": {`"` + expectedID + `"`, otherGroupID},
+			},
+		})
+
+		report, err := NewDoctorUseCase(dnsService, nil).Diagnose(ctx, owner, symgroup.MirrorNames, domains)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Healthy() {
+			t.Fatal("expected fixes")
+		}
+
+		fixes := strings.Join(report.Fixes, "\n")
+		for _, want := range []string{
+			`Publish _suns.example.com. IN TXT "` + expectedID + `"`,
+			`Publish _suns.com.example. IN TXT "` + expectedID + `"`,
+			`Remove the incorrect record _suns.example.com. IN TXT "\"` + expectedID + `\""`,
+		} {
+			if !strings.Contains(fixes, want) {
+				t.Errorf("fixes do not include %q:\n%s", want, fixes)
+			}
+		}
+
+		// A well-formed record for another group is left alone
+		if strings.Contains(fixes, otherGroupID) {
+			t.Errorf("fixes should not remove the record for another group:\n%s", fixes)
+		}
+	})
+
+	t.Run("not symmetric", func(t *testing.T) {
+		dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{})
+		report, err := NewDoctorUseCase(dnsService, nil).Diagnose(ctx, owner, symgroup.Palindrome, []string{"example.com"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.SymmetryError == "" {
+			t.Error("expected a symmetry error")
+		}
+		if len(report.Fixes) != 1 || !strings.Contains(report.Fixes[0], "no TXT records will make them attest") {
+			t.Errorf("expected a single fix explaining the group cannot attest, got %v", report.Fixes)
+		}
+	})
+
+	t.Run("public suffix", func(t *testing.T) {
+		dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{})
+		report, err := NewDoctorUseCase(dnsService, nil).Diagnose(ctx, owner, symgroup.Palindrome, []string{"co.uk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(report.InputError, "public suffix") {
+			t.Errorf("expected a public suffix error, got %q", report.InputError)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package order
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/mrled/suns/symval/internal/idn"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/psl"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/validation"
+)
+
+// DefaultTTL is how long a pending order can be finalized.
+// It leaves time to publish records and wait out DNS caches, without keeping abandoned orders around.
+const DefaultTTL = 24 * time.Hour
+
+var (
+	// ErrInvalidGroup is returned by Create when the domains can never attest, whatever records are published
+	ErrInvalidGroup = errors.New("invalid group")
+
+	// ErrExpired is returned by Finalize when the order expired before it was finalized
+	ErrExpired = errors.New("order has expired")
+)
+
+// OrderUseCase creates attestation orders, which tell a member which records to publish,
+// and finalizes them by attesting the group once the records are in place
+type OrderUseCase struct {
+	dnsService    *dnsclaims.Service
+	attestUseCase *attestation.AttestationUseCase
+	orders        model.OrderRepository
+	ttl           time.Duration
+	now           func() time.Time
+}
+
+// NewOrderUseCase creates a new order use case.
+// attestUseCase is used to finalize orders, and should persist its results.
+func NewOrderUseCase(dnsService *dnsclaims.Service, attestUseCase *attestation.AttestationUseCase, orders model.OrderRepository) *OrderUseCase {
+	return &OrderUseCase{
+		dnsService:    dnsService,
+		attestUseCase: attestUseCase,
+		orders:        orders,
+		ttl:           DefaultTTL,
+		now:           time.Now,
+	}
+}
+
+// SetTTL sets how long new orders can be finalized
+func (uc *OrderUseCase) SetTTL(ttl time.Duration) {
+	uc.ttl = ttl
+}
+
+// Create validates the group up front and stores a pending order listing the TXT records to publish.
+// Groups that can never attest, because a name cannot be claimed or the domains are not symmetric,
+// are rejected with an error wrapping ErrInvalidGroup.
+func (uc *OrderUseCase) Create(ctx context.Context, owner string, symmetryType symgroup.SymmetryType, domains []string) (*model.Order, error) {
+	if err := hostname.CheckCanonical(domains); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGroup, err)
+	}
+	for _, domain := range domains {
+		if psl.IsPublicSuffix(domain) {
+			return nil, fmt.Errorf("%w: hostname %q is a public suffix and cannot be claimed", ErrInvalidGroup, domain)
+		}
+		if assessment := idn.Assess(domain); assessment.Verdict == idn.VerdictReject {
+			return nil, fmt.Errorf("%w: hostname %q rejected by homograph check: %s", ErrInvalidGroup, domain, strings.Join(assessment.Reasons, "; "))
+		}
+	}
+
+	expectedID, err := groupid.CalculateV1(owner, string(symmetryType), domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate group ID: %w", err)
+	}
+
+	// Test the symmetry as if every domain published the expected group ID
+	var records []*model.DomainRecord
+	for _, domain := range domains {
+		records = append(records, &model.DomainRecord{
+			Owner:           owner,
+			Type:            symmetryType,
+			Hostname:        domain,
+			UnicodeHostname: idn.ToUnicode(domain),
+			GroupID:         expectedID,
+		})
+	}
+	if _, err := validation.Validate(records); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGroup, err)
+	}
+
+	id, err := newOrderID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	order := &model.Order{
+		ID:         id,
+		Owner:      owner,
+		Type:       symmetryType,
+		Domains:    domains,
+		ExpectedID: expectedID,
+		Records:    uc.orderRecords(expectedID, domains),
+		Status:     model.OrderPending,
+		CreateTime: now,
+		ExpireTime: now.Add(uc.ttl),
+	}
+	if err := uc.orders.StoreOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to store order: %w", err)
+	}
+
+	return order, nil
+}
+
+// Get retrieves an order, reporting pending orders past their expiry time as expired
+func (uc *OrderUseCase) Get(ctx context.Context, id string) (*model.Order, error) {
+	order, err := uc.orders.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Expired(uc.now()) {
+		order.Status = model.OrderExpired
+	}
+	return order, nil
+}
+
+// Finalize attests the order's group.
+// If attestation passes, the order becomes valid; if not, it stays pending with the reason in LastError,
+// so that it can be finalized again once the records are fixed or have propagated.
+// The attestation result is nil if the order was already valid.
+func (uc *OrderUseCase) Finalize(ctx context.Context, id string) (*model.Order, *attestation.AttestResult, error) {
+	order, err := uc.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch order.Status {
+	case model.OrderExpired:
+		return order, nil, ErrExpired
+	case model.OrderValid:
+		return order, nil, nil
+	}
+
+	result, err := uc.attestUseCase.Attest(order.Owner, order.Type, order.Domains)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if result.IsValid {
+		order.Status = model.OrderValid
+		order.FinalizeTime = uc.now()
+		order.LastError = ""
+	} else {
+		order.LastError = result.ErrorMessage
+	}
+	if err := uc.orders.StoreOrder(ctx, order); err != nil {
+		return nil, nil, fmt.Errorf("failed to store order: %w", err)
+	}
+
+	return order, result, nil
+}
+
+// orderRecords lists the record to publish for every domain, in the order given.
+// Domains that already publish the expected group ID are included, so the order lists the whole group.
+// A record goes where Lookup will look for it: at the target of the domain's _suns CNAME, if it has one.
+// Looking that up only refines the name, so if the lookup fails the record is listed at the _suns label,
+// rather than failing the order over a resolver problem.
+func (uc *OrderUseCase) orderRecords(expectedID string, domains []string) []model.OrderRecord {
+	var records []model.OrderRecord
+	for _, domain := range domains {
+		name := dnsclaims.RecordName + "." + domain
+		if trace, err := uc.dnsService.Trace(domain); err == nil && len(trace.CNAMEPath) > 0 {
+			name = strings.TrimSuffix(trace.CNAMEPath[len(trace.CNAMEPath)-1], ".")
+		}
+		records = append(records, model.OrderRecord{
+			Hostname: domain,
+			Name:     name,
+			Value:    expectedID,
+		})
+	}
+	return records
+}
+
+// newOrderID returns a random, unguessable order ID
+func newOrderID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate order ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
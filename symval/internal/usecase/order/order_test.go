@@ -0,0 +1,169 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+	"github.com/mrled/suns/symval/internal/usecase/attestation"
+)
+
+// newTestUseCase returns an order use case over resolver whose clock can be moved with the returned pointer
+func newTestUseCase(resolver *dnsclaimstest.Resolver) (*OrderUseCase, *memrepo.MemoryRepository, *time.Time) {
+	dnsService := dnsclaims.NewServiceWithResolver(resolver)
+	repo := memrepo.NewMemoryRepository()
+	uc := NewOrderUseCase(dnsService, attestation.NewAttestationUseCase(dnsService, repo), memrepo.NewMemoryOrderRepository())
+	now := time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+	return uc, repo, &now
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		typ       symgroup.SymmetryType
+		domains   []string
+		wantError bool
+	}{
+		{"valid palindrome", symgroup.Palindrome, []string{"zb.snus.suns.bz"}, false},
+		{"asymmetric palindrome", symgroup.Palindrome, []string{"abc.example.com"}, true},
+		{"mirrornames missing its mirror", symgroup.MirrorNames, []string{"example.com"}, true},
+		{"public suffix", symgroup.Palindrome, []string{"co.uk"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _ := newTestUseCase(&dnsclaimstest.Resolver{})
+			order, err := uc.Create(context.Background(), "alice@example.com", tt.typ, tt.domains)
+			if tt.wantError {
+				if !errors.Is(err, ErrInvalidGroup) {
+					t.Errorf("expected ErrInvalidGroup, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if order.Status != model.OrderPending {
+				t.Errorf("Status = %s, want pending", order.Status)
+			}
+			if len(order.Records) != len(tt.domains) {
+				t.Fatalf("got %d records, want %d", len(order.Records), len(tt.domains))
+			}
+			for i, record := range order.Records {
+				if record.Name != "_suns."+tt.domains[i] || record.Value != order.ExpectedID {
+					t.Errorf("record %d = %s %s, want _suns.%s %s", i, record.Name, record.Value, tt.domains[i], order.ExpectedID)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateFollowsCNAME(t *testing.T) {
+	uc, _, _ := newTestUseCase(&dnsclaimstest.Resolver{
+		CNAMEs: map[string]string{"_suns.zb.snus.suns.bz": "delegated.example.net."},
+	})
+	order, err := uc.Create(context.Background(), "alice@example.com", symgroup.Palindrome, []string{"zb.snus.suns.bz"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Records[0].Name != "delegated.example.net" {
+		t.Errorf("Name = %s, want delegated.example.net", order.Records[0].Name)
+	}
+}
+
+func TestCreateToleratesLookupFailures(t *testing.T) {
+	// The order only needs where to publish the record, so a failing resolver does not stop it
+	uc, _, _ := newTestUseCase(&dnsclaimstest.Resolver{
+		Errors: map[string]error{"_suns.zb.snus.suns.bz": &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+	})
+	order, err := uc.Create(context.Background(), "alice@example.com", symgroup.Palindrome, []string{"zb.snus.suns.bz"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Records[0].Name != "_suns.zb.snus.suns.bz" {
+		t.Errorf("Name = %s, want _suns.zb.snus.suns.bz", order.Records[0].Name)
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	ctx := context.Background()
+	domains := []string{"zb.snus.suns.bz"}
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	uc, repo, _ := newTestUseCase(resolver)
+
+	order, err := uc.Create(ctx, "alice@example.com", symgroup.Palindrome, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Records not published yet: the order stays pending with the reason
+	order, result, err := uc.Finalize(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.IsValid {
+		t.Fatalf("expected a failed attestation, got %+v", result)
+	}
+	if order.Status != model.OrderPending || order.LastError == "" {
+		t.Errorf("got status %s with error %q, want pending with an error", order.Status, order.LastError)
+	}
+
+	// Once published, finalizing attests the group
+	resolver.TXTRecords["_suns.zb.snus.suns.bz"] = []string{order.ExpectedID}
+	order, result, err = uc.Finalize(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || !result.IsValid {
+		t.Fatalf("expected a passed attestation, got %+v", result)
+	}
+	if order.Status != model.OrderValid || order.LastError != "" {
+		t.Errorf("got status %s with error %q, want valid", order.Status, order.LastError)
+	}
+	if _, err := repo.Get(ctx, order.ExpectedID, "zb.snus.suns.bz"); err != nil {
+		t.Errorf("attested record not stored: %v", err)
+	}
+
+	// Finalizing again does not attest again
+	order, result, err = uc.Finalize(ctx, order.ID)
+	if err != nil || result != nil || order.Status != model.OrderValid {
+		t.Errorf("refinalize: got status %s, result %v, error %v", order.Status, result, err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	ctx := context.Background()
+	uc, _, now := newTestUseCase(&dnsclaimstest.Resolver{})
+	uc.SetTTL(time.Hour)
+
+	order, err := uc.Create(ctx, "alice@example.com", symgroup.Palindrome, []string{"zb.snus.suns.bz"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	*now = now.Add(59 * time.Minute)
+	if got, _ := uc.Get(ctx, order.ID); got.Status != model.OrderPending {
+		t.Errorf("before expiry: Status = %s, want pending", got.Status)
+	}
+
+	*now = now.Add(time.Minute)
+	if got, _ := uc.Get(ctx, order.ID); got.Status != model.OrderExpired {
+		t.Errorf("at expiry: Status = %s, want expired", got.Status)
+	}
+
+	if _, _, err := uc.Finalize(ctx, order.ID); !errors.Is(err, ErrExpired) {
+		t.Errorf("Finalize: expected ErrExpired, got %v", err)
+	}
+
+	if _, err := uc.Get(ctx, "no-such-order"); !errors.Is(err, model.ErrOrderNotFound) {
+		t.Errorf("Get: expected ErrOrderNotFound, got %v", err)
+	}
+}
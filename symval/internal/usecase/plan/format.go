@@ -0,0 +1,226 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+// Format is an output format for a plan
+type Format string
+
+const (
+	// FormatZoneFile prints BIND zone file lines
+	FormatZoneFile Format = "zonefile"
+
+	// FormatRoute53JSON prints a Route 53 change batch per zone, keyed by zone name
+	FormatRoute53JSON Format = "route53-json"
+
+	// FormatTerraform prints aws_route53_record resources
+	FormatTerraform Format = "terraform"
+
+	// FormatOctoDNS prints octoDNS YAML records per zone
+	FormatOctoDNS Format = "octodns"
+
+	// FormatNSUpdate prints nsupdate commands
+	FormatNSUpdate Format = "bind-nsupdate"
+)
+
+// Formats lists the supported formats, in the order they are documented
+var Formats = []Format{FormatZoneFile, FormatRoute53JSON, FormatTerraform, FormatOctoDNS, FormatNSUpdate}
+
+// ParseFormat parses a format name
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if Format(strings.ToLower(name)) == format {
+			return format, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("invalid format %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Render returns the plan's changes in the given format.
+// Formats that replace a whole record set (route53-json, terraform and octodns)
+// include the values already published at each name, so that applying the change does not remove them.
+func (p *Plan) Render(format Format) (string, error) {
+	switch format {
+	case FormatZoneFile:
+		return p.renderZoneFile(), nil
+	case FormatRoute53JSON:
+		return p.renderRoute53JSON()
+	case FormatTerraform:
+		return p.renderTerraform(), nil
+	case FormatOctoDNS:
+		return p.renderOctoDNS(), nil
+	case FormatNSUpdate:
+		return p.renderNSUpdate(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func (p *Plan) renderZoneFile() string {
+	var b strings.Builder
+	zones, changes := byZone(p.Changes)
+	for _, zone := range zones {
+		fmt.Fprintf(&b, "; zone %s\n", zone)
+		for _, c := range changes[zone] {
+			fmt.Fprintf(&b, "%s. %d IN TXT %s\n", c.Name, c.TTL, dnsclaims.QuoteTXT(c.Value))
+		}
+	}
+	return b.String()
+}
+
+// route53ChangeBatch is the JSON accepted by aws route53 change-resource-record-sets --change-batch
+type route53ChangeBatch struct {
+	Comment string          `json:"Comment"`
+	Changes []route53Change `json:"Changes"`
+}
+
+type route53Change struct {
+	Action            string                   `json:"Action"`
+	ResourceRecordSet route53ResourceRecordSet `json:"ResourceRecordSet"`
+}
+
+type route53ResourceRecordSet struct {
+	Name            string                  `json:"Name"`
+	Type            string                  `json:"Type"`
+	TTL             int                     `json:"TTL"`
+	ResourceRecords []route53ResourceRecord `json:"ResourceRecords"`
+}
+
+type route53ResourceRecord struct {
+	Value string `json:"Value"`
+}
+
+func (p *Plan) renderRoute53JSON() (string, error) {
+	batches := make(map[string]route53ChangeBatch)
+	zones, changes := byZone(p.Changes)
+	for _, zone := range zones {
+		batch := route53ChangeBatch{Comment: "SUNS group " + p.ExpectedID}
+		for _, c := range changes[zone] {
+			var records []route53ResourceRecord
+			for _, value := range c.values() {
+				// Route 53 expects TXT values in their quoted zone file form
+				records = append(records, route53ResourceRecord{Value: dnsclaims.QuoteTXT(value)})
+			}
+			batch.Changes = append(batch.Changes, route53Change{
+				Action: "UPSERT",
+				ResourceRecordSet: route53ResourceRecordSet{
+					Name:            c.Name + ".",
+					Type:            "TXT",
+					TTL:             c.TTL,
+					ResourceRecords: records,
+				},
+			})
+		}
+		batches[zone] = batch
+	}
+
+	out, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal change batches: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+func (p *Plan) renderTerraform() string {
+	var b strings.Builder
+	zones, changes := byZone(p.Changes)
+	for _, zone := range zones {
+		zoneResource := "zone_" + resourceName(zone)
+		fmt.Fprintf(&b, "data \"aws_route53_zone\" %q {\n  name = %q\n}\n\n", zoneResource, zone)
+		for _, c := range changes[zone] {
+			quoted := make([]string, 0, len(c.values()))
+			for _, value := range c.values() {
+				quoted = append(quoted, fmt.Sprintf("%q", value))
+			}
+			fmt.Fprintf(&b, "resource \"aws_route53_record\" %q {\n", resourceName(c.Name))
+			fmt.Fprintf(&b, "  zone_id = data.aws_route53_zone.%s.zone_id\n", zoneResource)
+			fmt.Fprintf(&b, "  name    = %q\n", c.Name)
+			fmt.Fprintf(&b, "  type    = \"TXT\"\n")
+			fmt.Fprintf(&b, "  ttl     = %d\n", c.TTL)
+			fmt.Fprintf(&b, "  records = [%s]\n", strings.Join(quoted, ", "))
+			if len(c.Existing) > 0 {
+				// The record set exists outside Terraform; its values are kept above
+				fmt.Fprintf(&b, "  allow_overwrite = true\n")
+			}
+			fmt.Fprintf(&b, "}\n\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (p *Plan) renderOctoDNS() string {
+	var b strings.Builder
+	zones, changes := byZone(p.Changes)
+	for _, zone := range zones {
+		fmt.Fprintf(&b, "# %s.yaml\n", zone)
+		for _, c := range changes[zone] {
+			fmt.Fprintf(&b, "%s:\n", yamlQuote(relativeName(c.Name, zone)))
+			fmt.Fprintf(&b, "  type: TXT\n")
+			fmt.Fprintf(&b, "  ttl: %d\n", c.TTL)
+			fmt.Fprintf(&b, "  values:\n")
+			for _, value := range c.values() {
+				// octoDNS expects semicolons in TXT values to be escaped
+				fmt.Fprintf(&b, "  - %s\n", yamlQuote(strings.ReplaceAll(value, ";", `\;`)))
+			}
+		}
+	}
+	return b.String()
+}
+
+func (p *Plan) renderNSUpdate() string {
+	var b strings.Builder
+	zones, changes := byZone(p.Changes)
+	for _, zone := range zones {
+		fmt.Fprintf(&b, "zone %s.\n", zone)
+		for _, c := range changes[zone] {
+			fmt.Fprintf(&b, "update add %s. %d TXT %s\n", c.Name, c.TTL, dnsclaims.QuoteTXT(c.Value))
+		}
+		fmt.Fprintf(&b, "send\n")
+	}
+	return b.String()
+}
+
+// values returns the full set of TXT values at the change's name after it is applied
+func (c *Change) values() []string {
+	return append(append([]string(nil), c.Existing...), c.Value)
+}
+
+// byZone groups changes by zone, returning the zones in the order they first appear
+func byZone(changes []Change) ([]string, map[string][]Change) {
+	var zones []string
+	grouped := make(map[string][]Change)
+	for _, c := range changes {
+		if _, ok := grouped[c.Zone]; !ok {
+			zones = append(zones, c.Zone)
+		}
+		grouped[c.Zone] = append(grouped[c.Zone], c)
+	}
+	return zones, grouped
+}
+
+// relativeName returns name relative to zone, or "" for the zone apex
+func relativeName(name, zone string) string {
+	if name == zone {
+		return ""
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// resourceName turns a DNS name into a Terraform resource name, like "suns_example_com" for "_suns.example.com"
+func resourceName(name string) string {
+	return strings.Trim(strings.NewReplacer(".", "_", "-", "_").Replace(name), "_")
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
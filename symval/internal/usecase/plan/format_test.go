@@ -0,0 +1,129 @@
+package plan
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testPlan() *Plan {
+	return &Plan{
+		ExpectedID: "v1:e:owner:domains",
+		Changes: []Change{
+			{Hostname: "example.com", Name: "_suns.example.com", Zone: "example.com", Value: "v1:e:owner:domains", TTL: 300},
+			{Hostname: "www.example.com", Name: "_suns.www.example.com", Zone: "example.com", Value: "v1:e:owner:domains", TTL: 300, Existing: []string{"v1:a:owner:other"}},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range Formats {
+		if got, err := ParseFormat(strings.ToUpper(string(format))); err != nil || got != format {
+			t.Errorf("ParseFormat(%q) = %q, %v", format, got, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   []string
+		absent []string
+	}{
+		{FormatZoneFile, []string{
+			`_suns.example.com. 300 IN TXT "v1:e:owner:domains"`,
+			`_suns.www.example.com. 300 IN TXT "v1:e:owner:domains"`,
+		}, []string{"v1:a:owner:other"}},
+		{FormatTerraform, []string{
+			`data "aws_route53_zone" "zone_example_com"`,
+			`resource "aws_route53_record" "suns_www_example_com"`,
+			`records = ["v1:a:owner:other", "v1:e:owner:domains"]`,
+			`allow_overwrite = true`,
+		}, nil},
+		{FormatOctoDNS, []string{
+			"# example.com.yaml",
+			"'_suns.www':",
+			"  - 'v1:a:owner:other'",
+		}, nil},
+		{FormatNSUpdate, []string{
+			"zone example.com.",
+			`update add _suns.example.com. 300 TXT "v1:e:owner:domains"`,
+			"send",
+		}, []string{"v1:a:owner:other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out, err := testPlan().Render(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output does not contain %q:\n%s", want, out)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(out, absent) {
+					t.Errorf("output should not contain %q:\n%s", absent, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderRoute53JSON(t *testing.T) {
+	out, err := testPlan().Render(FormatRoute53JSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var batches map[string]route53ChangeBatch
+	if err := json.Unmarshal([]byte(out), &batches); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	batch, ok := batches["example.com"]
+	if !ok || len(batch.Changes) != 2 {
+		t.Fatalf("expected 2 changes for example.com, got %+v", batches)
+	}
+
+	set := batch.Changes[1].ResourceRecordSet
+	if batch.Changes[1].Action != "UPSERT" || set.Name != "_suns.www.example.com." || set.Type != "TXT" {
+		t.Errorf("unexpected change %+v", batch.Changes[1])
+	}
+	// UPSERT replaces the record set, so existing values must be kept
+	if len(set.ResourceRecords) != 2 || set.ResourceRecords[0].Value != `"v1:a:owner:other"` {
+		t.Errorf("expected the existing value to be kept and quoted, got %+v", set.ResourceRecords)
+	}
+}
+
+func TestRenderEscapesTXTValues(t *testing.T) {
+	p := &Plan{
+		ExpectedID: `v1 "ü"`,
+		Changes: []Change{
+			{Hostname: "example.com", Name: "_suns.example.com", Zone: "example.com", Value: `v1 "ü"`, TTL: 300, Existing: []string{"a;b"}},
+		},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatZoneFile, `_suns.example.com. 300 IN TXT "v1 \"\195\188\""`},
+		{FormatNSUpdate, `update add _suns.example.com. 300 TXT "v1 \"\195\188\""`},
+		{FormatOctoDNS, `  - 'a\;b'`},
+	}
+
+	for _, tt := range tests {
+		out, err := p.Render(tt.format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.format, err)
+		}
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("%s output does not contain %s:\n%s", tt.format, tt.want, out)
+		}
+	}
+}
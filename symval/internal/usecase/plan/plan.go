@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
+	"github.com/mrled/suns/symval/internal/psl"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+// DefaultTTL is the suggested TTL in seconds for _suns TXT records.
+// It is short so that mistakes can be corrected quickly; the records are only read during attestation.
+const DefaultTTL = 300
+
+// PlanUseCase computes the TXT records that must be published for a group to attest
+type PlanUseCase struct {
+	dnsService *dnsclaims.Service
+	ttl        int
+}
+
+// NewPlanUseCase creates a new plan use case
+func NewPlanUseCase(dnsService *dnsclaims.Service) *PlanUseCase {
+	return &PlanUseCase{
+		dnsService: dnsService,
+		ttl:        DefaultTTL,
+	}
+}
+
+// SetTTL sets the TTL in seconds suggested for new records
+func (uc *PlanUseCase) SetTTL(ttl int) {
+	uc.ttl = ttl
+}
+
+// Change is a TXT record that must be added for one domain in the group
+type Change struct {
+	Hostname string
+	Name     string   // Where to publish the record: the _suns label, or its CNAME target; no trailing dot
+	Zone     string   // The registrable domain of Name, which is usually the zone to change
+	Value    string   // The TXT value to add
+	TTL      int      // Suggested TTL in seconds
+	Existing []string // TXT values already published at Name, which must be kept
+}
+
+// Plan lists the changes needed for a group to attest
+type Plan struct {
+	ExpectedID string
+	Changes    []Change
+
+	// Published lists domains that already publish the expected group ID and need no change
+	Published []string
+}
+
+// Plan computes the expected group ID and returns a change for every domain that does not already publish it.
+// If a domain's _suns label is a CNAME, the change is for the CNAME target, where Lookup will look for it.
+func (uc *PlanUseCase) Plan(owner string, symmetryType symgroup.SymmetryType, domains []string) (*Plan, error) {
+	if err := hostname.CheckCanonical(domains); err != nil {
+		return nil, err
+	}
+	expectedID, err := groupid.CalculateV1(owner, string(symmetryType), domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate group ID: %w", err)
+	}
+
+	plan := &Plan{ExpectedID: expectedID}
+	for _, domain := range domains {
+		trace, err := uc.dnsService.Trace(domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup existing records for %s: %w", domain, err)
+		}
+
+		if contains(trace.Records, expectedID) {
+			plan.Published = append(plan.Published, domain)
+			continue
+		}
+
+		name := strings.TrimSuffix(trace.CNAMEPath[len(trace.CNAMEPath)-1], ".")
+		plan.Changes = append(plan.Changes, Change{
+			Hostname: domain,
+			Name:     name,
+			Zone:     psl.RegistrableDomain(name),
+			Value:    expectedID,
+			TTL:      uc.ttl,
+			Existing: trace.Records,
+		})
+	}
+
+	return plan, nil
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
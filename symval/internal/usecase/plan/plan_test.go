@@ -0,0 +1,69 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestPlan(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"example.com", "com.example", "www.example.co.uk"}
+	expectedID, err := groupid.CalculateV1(owner, string(symgroup.MirrorText), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{
+		TXTRecords: map[string][]string{
+			"_suns.example.com":       {expectedID},
+			"_suns.www.example.co.uk": {"v1:a:other:group"},
+			"delegated.example.net.":  {"v1:b:another:group"},
+		},
+		CNAMEs: map[string]string{
+			"_suns.com.example": "delegated.example.net.",
+		},
+	})
+
+	uc := NewPlanUseCase(dnsService)
+	uc.SetTTL(60)
+	plan, err := uc.Plan(owner, symgroup.MirrorText, domains)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plan.ExpectedID != expectedID {
+		t.Errorf("ExpectedID = %s, want %s", plan.ExpectedID, expectedID)
+	}
+	if len(plan.Published) != 1 || plan.Published[0] != "example.com" {
+		t.Errorf("Published = %v, want [example.com]", plan.Published)
+	}
+	if len(plan.Changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(plan.Changes))
+	}
+
+	tests := []struct {
+		hostname string
+		name     string
+		zone     string
+		existing int
+	}{
+		{"com.example", "delegated.example.net", "example.net", 1},
+		{"www.example.co.uk", "_suns.www.example.co.uk", "example.co.uk", 1},
+	}
+	for i, tt := range tests {
+		c := plan.Changes[i]
+		if c.Hostname != tt.hostname || c.Name != tt.name || c.Zone != tt.zone {
+			t.Errorf("change %d = %s at %s in %s, want %s at %s in %s", i, c.Hostname, c.Name, c.Zone, tt.hostname, tt.name, tt.zone)
+		}
+		if len(c.Existing) != tt.existing {
+			t.Errorf("change %d: got %d existing values, want %d", i, len(c.Existing), tt.existing)
+		}
+		if c.Value != expectedID || c.TTL != 60 {
+			t.Errorf("change %d: got value %s with TTL %d", i, c.Value, c.TTL)
+		}
+	}
+}
@@ -3,20 +3,48 @@ package reattest
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/service/dnsclaims"
 	"github.com/mrled/suns/symval/internal/symgroup"
 	"github.com/mrled/suns/symval/internal/usecase/attestation"
+	"github.com/mrled/suns/symval/internal/workpool"
 )
 
+// DefaultWorkers is how many groups are re-attested at once by default
+const DefaultWorkers = 4
+
+// DefaultBreakerThreshold is the fraction of groups with indeterminate results above which
+// ReattestAllAndUpdate stops and removes nothing, by default
+const DefaultBreakerThreshold = 0.5
+
+// DefaultBreakerMinGroups is how many groups must be processed before the circuit breaker can trip, by default
+const DefaultBreakerMinGroups = 4
+
+// DefaultArchiveRetention is how long removed records are kept in the archive by default
+const DefaultArchiveRetention = 365 * 24 * time.Hour
+
+// DefaultDeadlineMargin is how long before the context deadline ReattestAllAndUpdate stops starting new groups by default
+const DefaultDeadlineMargin = 30 * time.Second
+
 // ReattestUseCase handles re-attestation of all groups in the data store
 type ReattestUseCase struct {
 	dnsService       *dnsclaims.Service
 	repository       model.DomainRepository
 	dynamoRepo       model.DomainRepository // Optional: for updating validation timestamps
-	gracePeriodHours int
+	retention        *RetentionPolicy
+	workers          int                      // Groups re-attested at once
+	lookupWorkers    int                      // Domains of one group looked up at once
+	stateStore       model.ReattestStateStore // Optional: for resuming passes that do not finish in one run
+	schedule         *Schedule                // Optional: for re-attesting only groups that are due
+	deadlineMargin   time.Duration
+	breakerThreshold float64 // Fraction of indeterminate results that trips the circuit breaker; 0 disables it
+	breakerMinGroups int
+	filter           model.RecordFilter      // Optional: for re-attesting only some groups
+	archive          model.ArchiveRepository // Optional: for keeping removed records so that they can be restored
+	archiveRetention time.Duration
 }
 
 // NewReattestUseCase creates a new reattest use case
@@ -24,7 +52,12 @@ func NewReattestUseCase(dnsService *dnsclaims.Service, repo model.DomainReposito
 	return &ReattestUseCase{
 		dnsService:       dnsService,
 		repository:       repo,
-		gracePeriodHours: 72, // Default grace period
+		retention:        DefaultRetentionPolicy(),
+		workers:          DefaultWorkers,
+		lookupWorkers:    attestation.DefaultLookupWorkers,
+		deadlineMargin:   DefaultDeadlineMargin,
+		breakerThreshold: DefaultBreakerThreshold,
+		breakerMinGroups: DefaultBreakerMinGroups,
 	}
 }
 
@@ -34,15 +67,96 @@ func NewReattestUseCaseWithDynamo(dnsService *dnsclaims.Service, repo model.Doma
 		dnsService:       dnsService,
 		repository:       repo,
 		dynamoRepo:       dynamoRepo,
-		gracePeriodHours: 72, // Default grace period
+		retention:        DefaultRetentionPolicy(),
+		workers:          DefaultWorkers,
+		lookupWorkers:    attestation.DefaultLookupWorkers,
+		deadlineMargin:   DefaultDeadlineMargin,
+		breakerThreshold: DefaultBreakerThreshold,
+		breakerMinGroups: DefaultBreakerMinGroups,
 	}
 }
 
-// SetGracePeriod sets the grace period in hours for dropping invalid groups
+// SetGracePeriod sets the grace period in hours of the default retention rule for dropping invalid groups
 func (uc *ReattestUseCase) SetGracePeriod(hours int) {
-	uc.gracePeriodHours = hours
+	uc.retention.Default.GracePeriod = time.Duration(hours) * time.Hour
+}
+
+// SetRetentionPolicy sets the policy that decides when invalid groups are dropped
+func (uc *ReattestUseCase) SetRetentionPolicy(policy *RetentionPolicy) {
+	uc.retention = policy
+}
+
+// SetWorkers sets how many groups are re-attested at once, and how many domains of each group are looked up at once.
+// Results are returned in group ID order however many workers there are.
+// To bound the total lookups in flight, give the DNS service a dnsclaims.LimitedResolver.
+func (uc *ReattestUseCase) SetWorkers(groups, lookups int) {
+	uc.workers = groups
+	uc.lookupWorkers = lookups
+}
+
+// SetStateStore sets where ReattestAllAndUpdate saves how far it got.
+// With a state store, a run that stops at its deadline is resumed by the next run;
+// without one, every run starts a new pass.
+func (uc *ReattestUseCase) SetStateStore(store model.ReattestStateStore) {
+	uc.stateStore = store
+}
+
+// SetSchedule sets when groups are due, so that ReattestAllAndUpdate only re-attests groups that are due.
+// The schedule of each group is kept in the state store, so a schedule is only useful with one.
+// Without a schedule (the default), every group is re-attested on every pass.
+func (uc *ReattestUseCase) SetSchedule(schedule *Schedule) {
+	uc.schedule = schedule
+}
+
+// SetDeadlineMargin sets how long before the context deadline ReattestAllAndUpdate stops starting new groups,
+// leaving time to finish the groups in progress and save the state
+func (uc *ReattestUseCase) SetDeadlineMargin(margin time.Duration) {
+	uc.deadlineMargin = margin
+}
+
+// SetCircuitBreaker sets when ReattestAllAndUpdate gives up on a run: once at least minGroups groups are processed,
+// if more than threshold (a fraction) of them are indeterminate, the resolver is presumed broken,
+// so no more groups are started and no groups are removed in the run. A threshold of 0 disables the breaker.
+func (uc *ReattestUseCase) SetCircuitBreaker(threshold float64, minGroups int) {
+	uc.breakerThreshold = threshold
+	uc.breakerMinGroups = minGroups
+}
+
+// SetArchive sets where ReattestAllAndUpdate keeps the records it removes, and for how long.
+// Without an archive (the default), removed records are gone for good.
+func (uc *ReattestUseCase) SetArchive(archive model.ArchiveRepository, retention time.Duration) {
+	uc.archive = archive
+	uc.archiveRetention = retention
+}
+
+// SetFilter limits re-attestation to the groups with at least one record matching filter, as in revalidate:
+// a group is always re-attested whole, even if only one of its domains matches.
+// A filtered run is targeted: ReattestAllAndUpdate re-attests every matching group whether or not it is due,
+// and neither resumes nor moves the cursor of the regular pass.
+func (uc *ReattestUseCase) SetFilter(filter model.RecordFilter) {
+	uc.filter = filter
+}
+
+// targeted reports whether a filter limits the groups that are re-attested
+func (uc *ReattestUseCase) targeted() bool {
+	return len(uc.filter.Owners) > 0 || len(uc.filter.Domains) > 0 || len(uc.filter.GroupIDs) > 0 || len(uc.filter.Types) > 0
 }
 
+// Outcome classifies the result of re-attesting a group
+type Outcome string
+
+const (
+	// OutcomeValid groups passed attestation
+	OutcomeValid Outcome = "valid"
+
+	// OutcomeInvalid groups definitively failed: DNS answered, and a record was missing (NXDOMAIN or no TXT record) or changed
+	OutcomeInvalid Outcome = "invalid"
+
+	// OutcomeIndeterminate groups could not be checked, because DNS lookups failed (timeouts, SERVFAIL and the like).
+	// They may well still be valid, so they never count toward removal.
+	OutcomeIndeterminate Outcome = "indeterminate"
+)
+
 // GroupAttestResult contains the result of re-attesting a group
 type GroupAttestResult struct {
 	GroupID      string
@@ -51,33 +165,68 @@ type GroupAttestResult struct {
 	Domains      []string
 	Records      []*model.DomainRecord // Include full records with revision info
 	IsValid      bool
+	Outcome      Outcome
 	ErrorMessage string
+	Removed      bool // The group's records were removed by ReattestAllAndUpdate
 }
 
 // ReattestAll loads all groups from the datastore and re-attests them by querying DNS.
-// Returns a list of results for each group, indicating which groups are valid or invalid.
+// Returns a list of results for each group in group ID order, indicating which groups are valid or invalid.
 func (uc *ReattestUseCase) ReattestAll(ctx context.Context) ([]GroupAttestResult, error) {
+	groupIDs, groupedRecords, err := uc.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs = uc.selectGroups(groupIDs, groupedRecords)
+	return uc.reattestGroups(uc.newAttestationUseCase(), groupIDs, groupedRecords), nil
+}
+
+// listGroups loads all records from the datastore, returning them by group ID along with the group IDs in sorted order
+func (uc *ReattestUseCase) listGroups(ctx context.Context) ([]string, map[string][]*model.DomainRecord, error) {
 	// Get all records from repository
 	allRecords, err := uc.repository.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list records: %w", err)
+		return nil, nil, fmt.Errorf("failed to list records: %w", err)
 	}
 
-	// If no records, return empty list
-	if len(allRecords) == 0 {
-		return []GroupAttestResult{}, nil
+	// Group records by GroupID, and sort the groups so that results come back in a stable order
+	groupedRecords := model.GroupByGroupID(allRecords)
+	groupIDs := make([]string, 0, len(groupedRecords))
+	for groupID := range groupedRecords {
+		groupIDs = append(groupIDs, groupID)
 	}
+	sort.Strings(groupIDs)
 
-	// Group records by GroupID
-	groupedRecords := model.GroupByGroupID(allRecords)
+	return groupIDs, groupedRecords, nil
+}
+
+// selectGroups returns the group IDs with a record matching the filter, keeping their order
+func (uc *ReattestUseCase) selectGroups(groupIDs []string, groupedRecords map[string][]*model.DomainRecord) []string {
+	if !uc.targeted() {
+		return groupIDs
+	}
+	var selected []string
+	for _, groupID := range groupIDs {
+		if len(model.FilterRecords(groupedRecords[groupID], uc.filter)) > 0 {
+			selected = append(selected, groupID)
+		}
+	}
+	return selected
+}
 
-	// Create attestation use case for performing attestations
+// newAttestationUseCase creates the attestation use case that groups are re-attested with
+func (uc *ReattestUseCase) newAttestationUseCase() *attestation.AttestationUseCase {
 	attestUC := attestation.NewAttestationUseCase(uc.dnsService, nil)
+	attestUC.SetLookupWorkers(uc.lookupWorkers)
+	return attestUC
+}
 
-	// Re-attest each group
-	var results []GroupAttestResult
+// reattestGroups re-attests the groups concurrently; results are returned in the order of groupIDs
+func (uc *ReattestUseCase) reattestGroups(attestUC *attestation.AttestationUseCase, groupIDs []string, groupedRecords map[string][]*model.DomainRecord) []GroupAttestResult {
+	return workpool.Map(len(groupIDs), uc.workers, func(i int) GroupAttestResult {
+		groupID := groupIDs[i]
+		groupRecords := groupedRecords[groupID]
 
-	for groupID, groupRecords := range groupedRecords {
 		// Get first record to extract owner and type
 		firstRecord := groupRecords[0]
 		owner := firstRecord.Owner
@@ -89,38 +238,36 @@ func (uc *ReattestUseCase) ReattestAll(ctx context.Context) ([]GroupAttestResult
 			domains = append(domains, record.Hostname)
 		}
 
+		result := GroupAttestResult{
+			GroupID: groupID,
+			Owner:   owner,
+			Type:    string(symmetryType),
+			Domains: domains,
+			Records: groupRecords,
+		}
+
 		// Perform attestation
 		attestResult, err := attestUC.Attest(owner, symgroup.SymmetryType(symmetryType), domains)
 		if err != nil {
-			// If there's an error performing attestation, mark as invalid
-			result := GroupAttestResult{
-				GroupID:      groupID,
-				Owner:        owner,
-				Type:         string(symmetryType),
-				Domains:      domains,
-				Records:      groupRecords,
-				IsValid:      false,
-				ErrorMessage: fmt.Sprintf("attestation error: %v", err),
-			}
-			results = append(results, result)
-			continue
+			// The attestation could not be performed, for example because a zone policy lookup failed,
+			// so whether the group is valid is unknown
+			result.Outcome = OutcomeIndeterminate
+			result.ErrorMessage = fmt.Sprintf("attestation error: %v", err)
+			return result
 		}
 
-		// Create result
-		result := GroupAttestResult{
-			GroupID:      groupID,
-			Owner:        owner,
-			Type:         string(symmetryType),
-			Domains:      domains,
-			Records:      groupRecords,
-			IsValid:      attestResult.IsValid,
-			ErrorMessage: attestResult.ErrorMessage,
+		result.IsValid = attestResult.IsValid
+		result.ErrorMessage = attestResult.ErrorMessage
+		switch {
+		case attestResult.IsValid:
+			result.Outcome = OutcomeValid
+		case attestResult.LookupFailed():
+			result.Outcome = OutcomeIndeterminate
+		default:
+			result.Outcome = OutcomeInvalid
 		}
-
-		results = append(results, result)
-	}
-
-	return results, nil
+		return result
+	})
 }
 
 // UpdateStats tracks statistics for ReattestAllAndUpdate operations
@@ -128,13 +275,37 @@ type UpdateStats struct {
 	GroupsProcessed int
 	RecordsUpdated  int
 	RecordsDeleted  int
+	RecordsArchived int // Deleted records kept in the archive
 	RecordsSkipped  int
+	RecordsFailed   int // Records of invalid groups whose failure was recorded
 	Errors          int
+
+	GroupsDue    int // Groups due in this pass, including any left for a later run
+	GroupsNotDue int // Groups skipped because they are not due yet
+
+	GroupsValid         int
+	GroupsInvalid       int
+	GroupsIndeterminate int
+	CircuitOpen         bool // Too many groups were indeterminate, so the run stopped and removed nothing
+
+	Complete        bool   // Whether the run finished the pass; if not, the next run resumes after Cursor
+	ResumedFrom     string // Group ID the run resumed after, if it continued a partial pass
+	Cursor          string // Last group ID processed
+	GroupsRemaining int    // Groups left for the next run, if the pass is partial
+	StopReason      string // Why a partial run stopped
 }
 
 // ReattestAllAndUpdate loads all groups from the datastore, re-attests them,
 // updates validation timestamps for valid groups, and removes records for
-// invalid groups that have exceeded the grace period.
+// invalid groups that the retention policy says have expired.
+// Only definitively invalid groups are removed; indeterminate groups are left alone, and if the circuit breaker trips,
+// nothing is removed in the run.
+//
+// Groups are processed in group ID order. If the context has a deadline, no new groups are started
+// within the deadline margin of it, and the run ends as a partial pass; if a state store is set,
+// the last group ID processed is saved there and the next run resumes after it.
+// If a schedule is set, only groups that are due are processed, and each group's next check is saved in the state store.
+// If a filter is set, the run is targeted: see SetFilter.
 func (uc *ReattestUseCase) ReattestAllAndUpdate(ctx context.Context) ([]GroupAttestResult, UpdateStats, error) {
 	stats := UpdateStats{}
 
@@ -144,67 +315,236 @@ func (uc *ReattestUseCase) ReattestAllAndUpdate(ctx context.Context) ([]GroupAtt
 		updateRepo = uc.repository
 	}
 
-	// Perform re-attestation
-	results, err := uc.ReattestAll(ctx)
+	groupIDs, groupedRecords, err := uc.listGroups(ctx)
 	if err != nil {
 		return nil, stats, fmt.Errorf("failed to re-attest groups: %w", err)
 	}
 
-	stats.GroupsProcessed = len(results)
+	// Resume a partial pass, skipping the groups it already processed
+	state := &model.ReattestState{}
+	if uc.stateStore != nil {
+		state, err = uc.stateStore.LoadReattestState(ctx)
+		if err != nil {
+			return nil, stats, fmt.Errorf("failed to load reattest state: %w", err)
+		}
+	}
+	if state.Cursor == "" && !uc.targeted() {
+		state.PassStartTime = time.Now()
+	}
+	if state.Groups == nil {
+		state.Groups = make(map[string]model.GroupSchedule)
+	}
 
-	// Process each attestation result
-	for _, result := range results {
-		if result.IsValid {
-			// Attestation succeeded - update all records in the group with current timestamp
-			for _, record := range result.Records {
-				// Keep the snapshot revision for conditional update
-				snapshotRev := record.Rev
-				record.ValidateTime = time.Now()
-				if _, err := updateRepo.SetValidationIfUnchanged(ctx, record, snapshotRev); err != nil {
-					if err == model.ErrRevConflict {
-						// Record changed during validation, skip
-						stats.RecordsSkipped++
-					} else {
-						// Other error
-						stats.Errors++
-					}
-				} else {
-					stats.RecordsUpdated++
-				}
-			}
-		} else {
-			// Attestation failed - check grace period
-			// Get the oldest validation time from the group
-			var oldestValidation time.Time
-			for _, record := range result.Records {
-				if oldestValidation.IsZero() || record.ValidateTime.Before(oldestValidation) {
-					oldestValidation = record.ValidateTime
-				}
+	// Forget the schedules of groups that no longer exist
+	for groupID := range state.Groups {
+		if _, ok := groupedRecords[groupID]; !ok {
+			delete(state.Groups, groupID)
+		}
+	}
+
+	// A targeted run re-attests every matching group, apart from the regular pass
+	passCursor := state.Cursor
+	if uc.targeted() {
+		groupIDs = uc.selectGroups(groupIDs, groupedRecords)
+		state.Cursor = ""
+	}
+
+	// Only re-attest groups that are due
+	now := time.Now()
+	due := groupIDs
+	if uc.schedule != nil && !uc.targeted() {
+		due = nil
+		for _, groupID := range groupIDs {
+			if uc.schedule.Due(state.Groups, groupID, now) {
+				due = append(due, groupID)
 			}
+		}
+	}
+	stats.GroupsDue = len(due)
+	stats.GroupsNotDue = len(groupIDs) - len(due)
 
-			hoursSinceValidation := time.Since(oldestValidation).Hours()
-
-			if hoursSinceValidation > float64(uc.gracePeriodHours) {
-				// Grace period exceeded - delete all records in the group
-				for _, record := range result.Records {
-					if err := updateRepo.DeleteIfUnchanged(ctx, result.GroupID, record.Hostname, record.Rev); err != nil {
-						if err == model.ErrRevConflict {
-							// Record changed during deletion, skip
-							stats.RecordsSkipped++
-						} else {
-							// Other error
-							stats.Errors++
-						}
-					} else {
-						stats.RecordsDeleted++
-					}
+	stats.ResumedFrom = state.Cursor
+	remaining := due[sort.Search(len(due), func(i int) bool { return due[i] > state.Cursor }):]
+
+	// Re-attest a few groups at a time, checking the deadline before starting each batch
+	attestUC := uc.newAttestationUseCase()
+	chunkSize := max(uc.workers, 1)
+	var results []GroupAttestResult
+	var expired []int // Indexes in results of invalid groups the retention policy has expired
+	for len(remaining) > 0 {
+		if stats.StopReason = uc.stopReason(ctx); stats.StopReason != "" {
+			break
+		}
+
+		chunk := remaining[:min(chunkSize, len(remaining))]
+		for _, result := range uc.reattestGroups(attestUC, chunk, groupedRecords) {
+			switch result.Outcome {
+			case OutcomeValid:
+				stats.GroupsValid++
+				uc.updateValidGroup(ctx, updateRepo, result, &stats)
+			case OutcomeInvalid:
+				stats.GroupsInvalid++
+				uc.recordFailedGroup(ctx, updateRepo, result, &stats)
+				if uc.retention.Expired(result.Records, time.Now()) {
+					// Removed once the run is over, if the circuit breaker has not tripped
+					expired = append(expired, len(results))
 				}
-			} else {
-				// Within grace period - skip all records
+			case OutcomeIndeterminate:
+				// The group may still be valid, so leave it as it is
+				stats.GroupsIndeterminate++
 				stats.RecordsSkipped += len(result.Records)
 			}
+			if uc.schedule != nil {
+				state.Groups[result.GroupID] = uc.schedule.Next(groupFailures(result.Records), result.Outcome, time.Now())
+			}
+			results = append(results, result)
+		}
+
+		state.Cursor = chunk[len(chunk)-1]
+		remaining = remaining[len(chunk):]
+
+		if uc.breakerTripped(stats.GroupsIndeterminate, len(results)) {
+			stats.CircuitOpen = true
+			stats.StopReason = fmt.Sprintf("circuit breaker: %d of %d groups indeterminate", stats.GroupsIndeterminate, len(results))
+			break
+		}
+	}
+
+	// Remove expired groups, unless so many lookups failed that the results cannot be trusted
+	for _, i := range expired {
+		if stats.CircuitOpen {
+			continue
+		}
+		uc.removeGroup(ctx, updateRepo, results[i], &stats)
+		results[i].Removed = true
+		delete(state.Groups, results[i].GroupID)
+	}
+
+	stats.GroupsProcessed = len(results)
+	stats.GroupsRemaining = len(remaining)
+	stats.Complete = len(remaining) == 0
+	stats.Cursor = state.Cursor
+
+	// Start the next pass from the beginning once this one is complete, leaving the regular pass alone in a targeted run
+	if uc.targeted() {
+		state.Cursor = passCursor
+	} else if stats.Complete {
+		state.Cursor = ""
+		state.LastCompletedTime = time.Now()
+	}
+	if uc.stateStore != nil {
+		state.UpdateTime = time.Now()
+		// Save the state even if the context is done, so that the work already done is not repeated
+		if err := uc.stateStore.SaveReattestState(context.WithoutCancel(ctx), state); err != nil {
+			return results, stats, fmt.Errorf("failed to save reattest state: %w", err)
 		}
 	}
 
 	return results, stats, nil
 }
+
+// stopReason returns why no more groups should be started, or an empty string if there is time for more
+func (uc *ReattestUseCase) stopReason(ctx context.Context) string {
+	if err := ctx.Err(); err != nil {
+		return err.Error()
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < uc.deadlineMargin {
+		return fmt.Sprintf("within %s of the deadline", uc.deadlineMargin)
+	}
+	return ""
+}
+
+// breakerTripped reports whether so many of the processed groups are indeterminate that the run should stop
+func (uc *ReattestUseCase) breakerTripped(indeterminate, processed int) bool {
+	if uc.breakerThreshold <= 0 || processed < max(uc.breakerMinGroups, 1) {
+		return false
+	}
+	return float64(indeterminate)/float64(processed) > uc.breakerThreshold
+}
+
+// updateValidGroup updates all records in a valid group with the current timestamp
+func (uc *ReattestUseCase) updateValidGroup(ctx context.Context, updateRepo model.DomainRepository, result GroupAttestResult, stats *UpdateStats) {
+	for _, record := range result.Records {
+		// Keep the snapshot revision for conditional update
+		snapshotRev := record.Rev
+		record.RecordPass(time.Now())
+		if _, err := updateRepo.SetValidationIfUnchanged(ctx, record, snapshotRev); err != nil {
+			if err == model.ErrRevConflict {
+				// Record changed during validation, skip
+				stats.RecordsSkipped++
+			} else {
+				// Other error
+				stats.Errors++
+			}
+		} else {
+			stats.RecordsUpdated++
+		}
+	}
+}
+
+// recordFailedGroup records a failed check on all records in an invalid group.
+// Each record's revision is updated, so that the group can still be removed in the same run.
+func (uc *ReattestUseCase) recordFailedGroup(ctx context.Context, updateRepo model.DomainRepository, result GroupAttestResult, stats *UpdateStats) {
+	now := time.Now()
+	for _, record := range result.Records {
+		// Keep the snapshot revision for conditional update
+		snapshotRev := record.Rev
+		record.RecordFailure(now, result.ErrorMessage)
+		rev, err := updateRepo.SetValidationIfUnchanged(ctx, record, snapshotRev)
+		if err != nil {
+			if err == model.ErrRevConflict {
+				// Record changed during validation, skip
+				stats.RecordsSkipped++
+			} else {
+				// Other error
+				stats.Errors++
+			}
+			continue
+		}
+		record.Rev = rev
+		stats.RecordsFailed++
+	}
+}
+
+// removeGroup deletes all records in a group, keeping them in the archive if there is one
+func (uc *ReattestUseCase) removeGroup(ctx context.Context, updateRepo model.DomainRepository, result GroupAttestResult, stats *UpdateStats) {
+	now := time.Now()
+	for _, record := range result.Records {
+		// Archive before deleting, so that a record is never deleted without its archived copy;
+		// if the archive fails, the record stays in place to be removed by a later run
+		archived := false
+		if uc.archive != nil {
+			if err := uc.archive.ArchiveRecord(ctx, &model.ArchivedRecord{
+				Record:        record,
+				ArchiveTime:   now,
+				ExpireTime:    now.Add(uc.archiveRetention),
+				ArchiveReason: result.ErrorMessage,
+			}); err != nil {
+				stats.Errors++
+				continue
+			}
+			archived = true
+		}
+
+		if err := updateRepo.DeleteIfUnchanged(ctx, result.GroupID, record.Hostname, record.Rev); err != nil {
+			if err == model.ErrRevConflict {
+				// Record changed during deletion, skip
+				stats.RecordsSkipped++
+			} else {
+				// Other error
+				stats.Errors++
+			}
+			// The record is still current, so withdraw its archived copy
+			if archived {
+				if err := uc.archive.DeleteArchived(ctx, result.GroupID, record.Hostname); err != nil {
+					stats.Errors++
+				}
+			}
+			continue
+		}
+		stats.RecordsDeleted++
+		if archived {
+			stats.RecordsArchived++
+		}
+	}
+}
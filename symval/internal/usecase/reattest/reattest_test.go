@@ -0,0 +1,413 @@
+package reattest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+// servfail is the error a resolver returns when a nameserver fails
+func servfail(domain string) error {
+	return &net.DNSError{Err: "server misbehaving", Name: domain, IsTemporary: true}
+}
+
+// storeGroup stores the records of a mirrornames group of domains last validated at validateTime, returning its group ID
+func storeGroup(t *testing.T, repo model.DomainRepository, owner string, domains []string, validateTime time.Time) string {
+	t.Helper()
+	groupID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	for _, domain := range domains {
+		record := &model.DomainRecord{
+			Owner:        owner,
+			Type:         symgroup.MirrorNames,
+			Hostname:     domain,
+			GroupID:      groupID,
+			ValidateTime: validateTime,
+		}
+		if _, err := repo.UnconditionalStore(context.Background(), record); err != nil {
+			t.Fatalf("failed to store record: %v", err)
+		}
+	}
+	return groupID
+}
+
+// newTestGroups stores n groups of the same domains with different owners, where the even ones still have their records.
+// It returns whether each group should be valid, and the group IDs in sorted order.
+func newTestGroups(t *testing.T, n int) (*dnsclaimstest.Resolver, *memrepo.MemoryRepository, map[string]bool, []string) {
+	t.Helper()
+	domains := []string{"example.com", "com.example"}
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	repo := memrepo.NewMemoryRepository()
+
+	wantValid := map[string]bool{}
+	for i := range n {
+		groupID := storeGroup(t, repo, fmt.Sprintf("owner%02d@example.com", i), domains, time.Now())
+		wantValid[groupID] = i%2 == 0
+		if i%2 == 0 {
+			for _, domain := range domains {
+				resolver.TXTRecords["_suns."+domain] = append(resolver.TXTRecords["_suns."+domain], groupID)
+			}
+		}
+	}
+
+	wantOrder := make([]string, 0, len(wantValid))
+	for groupID := range wantValid {
+		wantOrder = append(wantOrder, groupID)
+	}
+	sort.Strings(wantOrder)
+
+	return resolver, repo, wantValid, wantOrder
+}
+
+func TestReattestAllStableOrder(t *testing.T) {
+	ctx := context.Background()
+	resolver, repo, wantValid, wantOrder := newTestGroups(t, 12)
+
+	for _, workers := range []int{1, 3, 12} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+			uc.SetWorkers(workers, workers)
+
+			results, err := uc.ReattestAll(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(wantOrder) {
+				t.Fatalf("got %d results, want %d", len(results), len(wantOrder))
+			}
+			for i, result := range results {
+				if result.GroupID != wantOrder[i] {
+					t.Errorf("results[%d].GroupID = %s, want %s", i, result.GroupID, wantOrder[i])
+				}
+				if result.IsValid != wantValid[result.GroupID] {
+					t.Errorf("group %s: IsValid = %v, want %v (%s)", result.GroupID, result.IsValid, wantValid[result.GroupID], result.ErrorMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestReattestAllAndUpdateResume(t *testing.T) {
+	resolver, repo, _, groupIDs := newTestGroups(t, 6)
+	store := memrepo.NewMemoryStateStore()
+	newUseCase := func() *ReattestUseCase {
+		uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+		uc.SetWorkers(2, 2)
+		uc.SetStateStore(store)
+		uc.SetGracePeriod(1000) // Keep invalid groups, so that every run sees all six
+		return uc
+	}
+
+	// A run that is already within the margin of its deadline stops before starting any groups
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	uc := newUseCase()
+	uc.SetDeadlineMargin(time.Hour)
+	results, stats, err := uc.ReattestAllAndUpdate(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 || stats.Complete || stats.GroupsRemaining != 6 || stats.StopReason == "" {
+		t.Errorf("near deadline: got %d results, stats %+v; want no results and a partial pass with 6 groups remaining", len(results), stats)
+	}
+
+	// A run resuming a partial pass starts after the cursor, and finishes the pass
+	if err := store.SaveReattestState(context.Background(), &model.ReattestState{Cursor: groupIDs[3]}); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	results, stats, err = newUseCase().ReattestAllAndUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].GroupID != groupIDs[4] || results[1].GroupID != groupIDs[5] {
+		t.Errorf("resumed run processed %d groups, want the 2 after the cursor", len(results))
+	}
+	if !stats.Complete || stats.ResumedFrom != groupIDs[3] || stats.Cursor != groupIDs[5] {
+		t.Errorf("resumed run: stats %+v, want a complete pass resumed from %s", stats, groupIDs[3])
+	}
+
+	state, err := store.LoadReattestState(context.Background())
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Cursor != "" || state.LastCompletedTime.IsZero() {
+		t.Errorf("after a complete pass: state %+v, want an empty cursor and a completion time", state)
+	}
+
+	// The next run starts a new pass from the beginning
+	results, stats, err = newUseCase().ReattestAllAndUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 6 || !stats.Complete || stats.ResumedFrom != "" {
+		t.Errorf("new pass: got %d results, stats %+v; want all 6 groups in a complete pass", len(results), stats)
+	}
+}
+
+func TestReattestAllAndUpdateOutcomes(t *testing.T) {
+	ctx := context.Background()
+	owner := "alice@example.com"
+	expired := time.Now().Add(-100 * time.Hour)
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}, Errors: map[string]error{}}
+	repo := memrepo.NewMemoryRepository()
+
+	valid := storeGroup(t, repo, owner, []string{"valid.example.com", "com.example.valid"}, expired)
+	resolver.TXTRecords["_suns.valid.example.com"] = []string{valid}
+	resolver.TXTRecords["_suns.com.example.valid"] = []string{valid}
+
+	// DNS answered, and the records are gone
+	removed := storeGroup(t, repo, owner, []string{"gone.example.com", "com.example.gone"}, expired)
+
+	// Every lookup failed, so the group may still be valid
+	unknown := storeGroup(t, repo, owner, []string{"down.example.com", "com.example.down"}, expired)
+	resolver.Errors["_suns.down.example.com"] = servfail("_suns.down.example.com")
+	resolver.Errors["_suns.com.example.down"] = servfail("_suns.com.example.down")
+
+	// One lookup failed, but the other domain's record definitely changed
+	changed := storeGroup(t, repo, owner, []string{"mixed.example.com", "com.example.mixed"}, time.Now())
+	resolver.Errors["_suns.mixed.example.com"] = servfail("_suns.mixed.example.com")
+	resolver.TXTRecords["_suns.com.example.mixed"] = []string{"v1:a:someone-else"}
+
+	archive := memrepo.NewMemoryArchiveRepository()
+	uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+	uc.SetCircuitBreaker(0, 0)
+	uc.SetArchive(archive, DefaultArchiveRetention)
+	results, stats, err := uc.ReattestAllAndUpdate(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]struct {
+		outcome Outcome
+		removed bool
+	}{
+		valid:   {OutcomeValid, false},
+		removed: {OutcomeInvalid, true},
+		unknown: {OutcomeIndeterminate, false},
+		changed: {OutcomeInvalid, false},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for _, result := range results {
+		w := want[result.GroupID]
+		if result.Outcome != w.outcome || result.Removed != w.removed {
+			t.Errorf("group %s: outcome %s, removed %v; want %s, removed %v (%s)",
+				result.Domains[0], result.Outcome, result.Removed, w.outcome, w.removed, result.ErrorMessage)
+		}
+	}
+	if stats.GroupsValid != 1 || stats.GroupsInvalid != 2 || stats.GroupsIndeterminate != 1 || stats.RecordsDeleted != 2 {
+		t.Errorf("stats %+v, want 1 valid, 2 invalid, 1 indeterminate, and 2 records deleted", stats)
+	}
+
+	// Removed records are kept in the archive with the reason they were removed
+	archived, err := archive.ListArchivedByGroupID(ctx, removed)
+	if err != nil {
+		t.Fatalf("failed to list archive: %v", err)
+	}
+	if len(archived) != 2 || stats.RecordsArchived != 2 || archived[0].ArchiveReason == "" {
+		t.Errorf("archived %d records (stats %d), want the 2 removed records with a reason", len(archived), stats.RecordsArchived)
+	}
+
+	if records, _ := repo.ListByGroupID(ctx, unknown); len(records) != 2 {
+		t.Errorf("indeterminate group has %d records left, want 2", len(records))
+	}
+
+	// Check history is recorded for conclusive checks only
+	for _, record := range mustList(t, repo, valid) {
+		if record.ConsecutiveFailures != 0 || record.LastCheckTime.IsZero() || !record.ValidateTime.Equal(record.LastCheckTime) {
+			t.Errorf("valid record %s: %+v, want a pass recorded", record.Hostname, record)
+		}
+	}
+	for _, record := range mustList(t, repo, changed) {
+		if record.ConsecutiveFailures != 1 || record.LastFailureTime.IsZero() || record.LastFailureReason == "" {
+			t.Errorf("invalid record %s: %+v, want a failure recorded", record.Hostname, record)
+		}
+	}
+	for _, record := range mustList(t, repo, unknown) {
+		if record.ConsecutiveFailures != 0 || !record.LastCheckTime.IsZero() {
+			t.Errorf("indeterminate record %s: %+v, want no check recorded", record.Hostname, record)
+		}
+	}
+}
+
+// mustList returns the records of a group
+func mustList(t *testing.T, repo model.DomainRepository, groupID string) []*model.DomainRecord {
+	t.Helper()
+	records, err := repo.ListByGroupID(context.Background(), groupID)
+	if err != nil {
+		t.Fatalf("failed to list group %s: %v", groupID, err)
+	}
+	return records
+}
+
+func TestReattestAllAndUpdateCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	owner := "alice@example.com"
+	expired := time.Now().Add(-100 * time.Hour)
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}, Errors: map[string]error{}}
+	repo := memrepo.NewMemoryRepository()
+
+	// One group is definitively invalid and past the grace period, but most lookups are failing
+	storeGroup(t, repo, owner, []string{"gone.example.com", "com.example.gone"}, expired)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		domains := []string{name + ".example.com", "com.example." + name}
+		storeGroup(t, repo, owner, domains, expired)
+		for _, domain := range domains {
+			resolver.Errors["_suns."+domain] = servfail("_suns." + domain)
+		}
+	}
+
+	uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+	uc.SetWorkers(1, 1)
+	uc.SetCircuitBreaker(0.5, 4)
+	_, stats, err := uc.ReattestAllAndUpdate(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !stats.CircuitOpen || stats.Complete {
+		t.Errorf("stats %+v, want the circuit breaker to stop the run", stats)
+	}
+	if stats.RecordsDeleted != 0 {
+		t.Errorf("deleted %d records with the circuit breaker open, want none", stats.RecordsDeleted)
+	}
+	if records, _ := repo.List(ctx); len(records) != 10 {
+		t.Errorf("%d records left, want all 10", len(records))
+	}
+}
+
+func TestReattestAllAndUpdateTargeted(t *testing.T) {
+	ctx := context.Background()
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	repo := memrepo.NewMemoryRepository()
+	alice := storeGroup(t, repo, "alice@example.com", []string{"a.example.com", "com.example.a"}, time.Now())
+	bob := storeGroup(t, repo, "bob@example.com", []string{"b.example.com", "com.example.b"}, time.Now())
+	carol := storeGroup(t, repo, "carol@example.com", []string{"a.example.com", "com.example.a"}, time.Now())
+
+	store := memrepo.NewMemoryStateStore()
+	if err := store.SaveReattestState(ctx, &model.ReattestState{Cursor: "v1:partial-pass"}); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter model.RecordFilter
+		want   []string
+	}{
+		{"owner", model.RecordFilter{Owners: []string{"BOB@example.com"}}, []string{bob}},
+		{"domain expands to every group of the domain", model.RecordFilter{Domains: []string{"com.example.a"}}, []string{alice, carol}},
+		{"group ID", model.RecordFilter{GroupIDs: []string{carol}}, []string{carol}},
+		{"no match", model.RecordFilter{Domains: []string{"c.example.com"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+			uc.SetStateStore(store)
+			uc.SetSchedule(DefaultSchedule())
+			uc.SetGracePeriod(1000)
+			uc.SetFilter(tt.filter)
+
+			results, stats, err := uc.ReattestAllAndUpdate(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if len(results) != len(want) {
+				t.Fatalf("got %d results, want %d", len(results), len(want))
+			}
+			for i, result := range results {
+				if result.GroupID != want[i] {
+					t.Errorf("results[%d].GroupID = %s, want %s", i, result.GroupID, want[i])
+				}
+				if len(result.Records) != 2 {
+					t.Errorf("group %s re-attested with %d records, want the whole group", result.GroupID, len(result.Records))
+				}
+			}
+			if !stats.Complete || stats.ResumedFrom != "" {
+				t.Errorf("stats %+v, want a complete targeted run that did not resume the pass", stats)
+			}
+
+			state, err := store.LoadReattestState(ctx)
+			if err != nil {
+				t.Fatalf("failed to load state: %v", err)
+			}
+			if state.Cursor != "v1:partial-pass" || !state.LastCompletedTime.IsZero() {
+				t.Errorf("state %+v, want the regular pass left alone", state)
+			}
+		})
+	}
+}
+
+// failingArchive is an archive that cannot store records
+type failingArchive struct {
+	*memrepo.MemoryArchiveRepository
+}
+
+func (failingArchive) ArchiveRecord(ctx context.Context, archived *model.ArchivedRecord) error {
+	return fmt.Errorf("archive unavailable")
+}
+
+func TestRemoveGroupKeepsArchiveConsistent(t *testing.T) {
+	ctx := context.Background()
+	domains := []string{"gone.example.com", "com.example.gone"}
+
+	t.Run("archive fails", func(t *testing.T) {
+		repo := memrepo.NewMemoryRepository()
+		groupID := storeGroup(t, repo, "alice@example.com", domains, time.Now())
+		uc := NewReattestUseCase(nil, repo)
+		uc.SetArchive(failingArchive{memrepo.NewMemoryArchiveRepository()}, DefaultArchiveRetention)
+
+		var stats UpdateStats
+		uc.removeGroup(ctx, repo, GroupAttestResult{GroupID: groupID, Records: mustList(t, repo, groupID)}, &stats)
+
+		// A record is not deleted unless its archived copy was stored
+		if records := mustList(t, repo, groupID); len(records) != 2 || stats.RecordsDeleted != 0 || stats.Errors != 2 {
+			t.Errorf("%d records left, stats %+v; want both records kept and 2 errors", len(records), stats)
+		}
+	})
+
+	t.Run("record changed", func(t *testing.T) {
+		repo := memrepo.NewMemoryRepository()
+		groupID := storeGroup(t, repo, "alice@example.com", domains, time.Now())
+		archive := memrepo.NewMemoryArchiveRepository()
+		uc := NewReattestUseCase(nil, repo)
+		uc.SetArchive(archive, DefaultArchiveRetention)
+
+		// Copy the records, since the memory repository returns the ones it stores
+		var stale []*model.DomainRecord
+		for _, record := range mustList(t, repo, groupID) {
+			changed := *record
+			changed.Rev++
+			stale = append(stale, &changed)
+		}
+		var stats UpdateStats
+		uc.removeGroup(ctx, repo, GroupAttestResult{GroupID: groupID, Records: stale}, &stats)
+
+		// A record that changed stays current, and its archived copy is withdrawn
+		archived, err := archive.ListArchivedByGroupID(ctx, groupID)
+		if err != nil {
+			t.Fatalf("failed to list archive: %v", err)
+		}
+		if records := mustList(t, repo, groupID); len(records) != 2 || stats.RecordsSkipped != 2 {
+			t.Errorf("%d records left, stats %+v; want both records kept and skipped", len(records), stats)
+		}
+		if len(archived) != 0 || stats.RecordsArchived != 0 {
+			t.Errorf("archived %d records (stats %d), want none", len(archived), stats.RecordsArchived)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package reattest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+// DefaultGracePeriod is how long a group must have gone without passing before it is removed, by default
+const DefaultGracePeriod = 72 * time.Hour
+
+// RetentionRule decides when an invalid group is removed.
+// A group is removed only once both conditions hold.
+type RetentionRule struct {
+	// GracePeriod is how long since the group last passed, measured from the oldest validation time of its records
+	GracePeriod time.Duration
+
+	// MinFailures is how many definitive failures in a row, including the current one, every record must have; 0 means any
+	MinFailures int
+}
+
+// String returns the rule in the form accepted by ParseRetentionPolicy
+func (r RetentionRule) String() string {
+	s := "hours=" + strconv.FormatFloat(r.GracePeriod.Hours(), 'f', -1, 64)
+	if r.MinFailures > 0 {
+		s += ",failures=" + strconv.Itoa(r.MinFailures)
+	}
+	return s
+}
+
+// RetentionPolicy decides when invalid groups are removed, with an optional rule per symmetry type
+type RetentionPolicy struct {
+	Default RetentionRule
+	ByType  map[symgroup.SymmetryType]RetentionRule
+}
+
+// DefaultRetentionPolicy returns the default retention policy, which removes groups that have not passed for DefaultGracePeriod
+func DefaultRetentionPolicy() *RetentionPolicy {
+	return &RetentionPolicy{Default: RetentionRule{GracePeriod: DefaultGracePeriod}}
+}
+
+// Rule returns the rule for groups of a symmetry type
+func (p *RetentionPolicy) Rule(symmetryType symgroup.SymmetryType) RetentionRule {
+	if rule, ok := p.ByType[symmetryType]; ok {
+		return rule
+	}
+	return p.Default
+}
+
+// Expired reports whether an invalid group with the given records should be removed at now.
+// The records should already have the current failure recorded.
+func (p *RetentionPolicy) Expired(records []*model.DomainRecord, now time.Time) bool {
+	if len(records) == 0 {
+		return false
+	}
+	rule := p.Rule(records[0].Type)
+
+	oldestValidation := records[0].ValidateTime
+	for _, record := range records[1:] {
+		if record.ValidateTime.Before(oldestValidation) {
+			oldestValidation = record.ValidateTime
+		}
+	}
+	return now.Sub(oldestValidation) > rule.GracePeriod && groupFailures(records) >= rule.MinFailures
+}
+
+// groupFailures returns how many checks in a row a group has failed, the fewest of any of its records.
+// The records are the one count of failures that both the retention policy and the schedule use.
+func groupFailures(records []*model.DomainRecord) int {
+	if len(records) == 0 {
+		return 0
+	}
+	failures := records[0].ConsecutiveFailures
+	for _, record := range records[1:] {
+		failures = min(failures, record.ConsecutiveFailures)
+	}
+	return failures
+}
+
+// String returns the policy in the form accepted by ParseRetentionPolicy
+func (p *RetentionPolicy) String() string {
+	rules := []string{p.Default.String()}
+	types := make([]string, 0, len(p.ByType))
+	for symmetryType := range p.ByType {
+		types = append(types, string(symmetryType))
+	}
+	sort.Strings(types)
+	for _, symmetryType := range types {
+		name := symgroup.TypeCodeToName[symmetryType]
+		if name == "" {
+			name = symmetryType
+		}
+		rules = append(rules, name+":"+p.ByType[symgroup.SymmetryType(symmetryType)].String())
+	}
+	return strings.Join(rules, ";")
+}
+
+// ParseRetentionPolicy parses a retention policy of rules separated by semicolons.
+// Each rule is a comma-separated list of hours=N (the grace period) and failures=N (the consecutive failures);
+// a bare number is the grace period in hours.
+// A rule may start with a symmetry type and a colon, to apply only to that type;
+// otherwise it sets the default, which type rules start from.
+// For example, "hours=72;mirrornames:hours=24,failures=3" keeps most groups for 72 hours,
+// but removes mirrornames groups after 24 hours if they have also failed 3 checks in a row.
+// An empty policy is the default policy.
+func ParseRetentionPolicy(s string) (*RetentionPolicy, error) {
+	policy := DefaultRetentionPolicy()
+	typeRules := map[symgroup.SymmetryType]string{}
+
+	for _, ruleStr := range strings.Split(s, ";") {
+		ruleStr = strings.TrimSpace(ruleStr)
+		if ruleStr == "" {
+			continue
+		}
+		typeName, terms, typed := strings.Cut(ruleStr, ":")
+		if !typed {
+			rule, err := parseRetentionRule(policy.Default, ruleStr)
+			if err != nil {
+				return nil, err
+			}
+			policy.Default = rule
+			continue
+		}
+		symmetryType, ok := symgroup.ParseType(strings.TrimSpace(typeName))
+		if !ok {
+			return nil, fmt.Errorf("invalid retention rule %q: unknown symmetry type %q", ruleStr, typeName)
+		}
+		typeRules[symmetryType] = terms
+	}
+
+	// Type rules start from the default, wherever it appears in the policy
+	for symmetryType, terms := range typeRules {
+		rule, err := parseRetentionRule(policy.Default, terms)
+		if err != nil {
+			return nil, err
+		}
+		if policy.ByType == nil {
+			policy.ByType = make(map[symgroup.SymmetryType]RetentionRule)
+		}
+		policy.ByType[symmetryType] = rule
+	}
+
+	return policy, nil
+}
+
+// parseRetentionRule applies the comma-separated terms of a rule to base
+func parseRetentionRule(base RetentionRule, terms string) (RetentionRule, error) {
+	rule := base
+	for _, term := range strings.Split(terms, ",") {
+		term = strings.TrimSpace(term)
+		key, value, hasKey := strings.Cut(term, "=")
+		if !hasKey {
+			key, value = "hours", term
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return rule, fmt.Errorf("invalid retention rule term %q: must be a non-negative integer", term)
+		}
+		switch strings.TrimSpace(key) {
+		case "hours":
+			rule.GracePeriod = time.Duration(n) * time.Hour
+		case "failures":
+			rule.MinFailures = n
+		default:
+			return rule, fmt.Errorf("invalid retention rule term %q: expected hours=N or failures=N", term)
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,112 @@
+package reattest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", "hours=72", false},
+		{"bare hours", "24", "hours=24", false},
+		{"hours and failures", "hours=48, failures=3", "hours=48,failures=3", false},
+		{"type rule inherits the default", "palindrome:failures=2;hours=96", "hours=96;palindrome:hours=96,failures=2", false},
+		{"type code", "e:hours=12", "hours=72;mirrornames:hours=12", false},
+		{"unknown type", "square:24", "", true},
+		{"unknown term", "days=3", "", true},
+		{"negative", "failures=-1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseRetentionPolicy(tt.policy)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := policy.String(); got != tt.want {
+				t.Errorf("ParseRetentionPolicy(%q) = %q, want %q", tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicyExpired(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	policy := &RetentionPolicy{
+		Default: RetentionRule{GracePeriod: 72 * time.Hour},
+		ByType: map[symgroup.SymmetryType]RetentionRule{
+			symgroup.MirrorNames: {GracePeriod: 24 * time.Hour, MinFailures: 3},
+		},
+	}
+	group := func(symmetryType symgroup.SymmetryType, age time.Duration, failures ...int) []*model.DomainRecord {
+		var records []*model.DomainRecord
+		for _, n := range failures {
+			records = append(records, &model.DomainRecord{Type: symmetryType, ValidateTime: now.Add(-age), ConsecutiveFailures: n})
+		}
+		return records
+	}
+
+	tests := []struct {
+		name    string
+		records []*model.DomainRecord
+		want    bool
+	}{
+		{"within grace period", group(symgroup.Palindrome, 48*time.Hour, 10), false},
+		{"past grace period", group(symgroup.Palindrome, 96*time.Hour, 1), true},
+		{"type rule, too few failures", group(symgroup.MirrorNames, 48*time.Hour, 2, 2), false},
+		{"type rule, one record with too few failures", group(symgroup.MirrorNames, 48*time.Hour, 5, 2), false},
+		{"type rule, both conditions", group(symgroup.MirrorNames, 48*time.Hour, 3, 4), true},
+		{"type rule, within grace period", group(symgroup.MirrorNames, 12*time.Hour, 3, 3), false},
+		{"no records", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Expired(tt.records, now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReattestAllAndUpdateMinFailures(t *testing.T) {
+	ctx := context.Background()
+	resolver := &dnsclaimstest.Resolver{TXTRecords: map[string][]string{}}
+	repo := memrepo.NewMemoryRepository()
+	gone := storeGroup(t, repo, "alice@example.com", []string{"gone.example.com", "com.example.gone"}, time.Now().Add(-100*time.Hour))
+
+	uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+	uc.SetRetentionPolicy(&RetentionPolicy{Default: RetentionRule{GracePeriod: 72 * time.Hour, MinFailures: 3}})
+
+	// The group is past the grace period, but is only removed on its third failure in a row
+	for run := 1; run <= 3; run++ {
+		results, _, err := uc.ReattestAllAndUpdate(ctx)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error: %v", run, err)
+		}
+		if len(results) != 1 || results[0].GroupID != gone {
+			t.Fatalf("run %d: got %d results, want the one group", run, len(results))
+		}
+		if want := run == 3; results[0].Removed != want {
+			t.Errorf("run %d: Removed = %v, want %v", run, results[0].Removed, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package reattest
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+)
+
+const (
+	// DefaultHealthyInterval is how long after a successful re-attestation a group is next due by default
+	DefaultHealthyInterval = 24 * time.Hour
+
+	// DefaultRetryInterval is how long after its first failure a group is next due by default;
+	// the wait doubles with each further consecutive failure
+	DefaultRetryInterval = time.Hour
+
+	// DefaultMaxBackoff is the longest a failing group waits between re-attestations by default
+	DefaultMaxBackoff = 24 * time.Hour
+
+	// DefaultJitter is the fraction by which intervals are randomly lengthened or shortened by default
+	DefaultJitter = 0.1
+)
+
+// Schedule decides when each group is next due to be re-attested.
+// Healthy groups are checked every HealthyInterval. Failing groups are retried after RetryInterval,
+// doubling with each consecutive failure up to MaxBackoff, so that a group broken for days does not
+// cost a lookup every run. Every interval is randomly adjusted by up to Jitter (a fraction of it),
+// so that groups attested together drift apart and spread their DNS load across runs.
+type Schedule struct {
+	HealthyInterval time.Duration
+	RetryInterval   time.Duration
+	MaxBackoff      time.Duration
+	Jitter          float64
+
+	random func() float64 // Returns a number in [0, 1)
+}
+
+// DefaultSchedule returns the default schedule
+func DefaultSchedule() *Schedule {
+	return &Schedule{
+		HealthyInterval: DefaultHealthyInterval,
+		RetryInterval:   DefaultRetryInterval,
+		MaxBackoff:      DefaultMaxBackoff,
+		Jitter:          DefaultJitter,
+	}
+}
+
+// Due reports whether a group is due at now, given the schedules of all groups.
+// Groups without a schedule, such as newly attested groups, are always due.
+func (s *Schedule) Due(groups map[string]model.GroupSchedule, groupID string, now time.Time) bool {
+	schedule, ok := groups[groupID]
+	return !ok || !now.Before(schedule.NextCheck)
+}
+
+// Next returns the schedule of a group after a re-attestation at now with the given outcome,
+// where failures is how many checks in a row the group has now failed (see groupFailures).
+// An indeterminate outcome is retried after RetryInterval, since the group may not have failed at all.
+func (s *Schedule) Next(failures int, outcome Outcome, now time.Time) model.GroupSchedule {
+	switch outcome {
+	case OutcomeValid:
+		return model.GroupSchedule{NextCheck: now.Add(s.jitter(s.HealthyInterval))}
+	case OutcomeIndeterminate:
+		return model.GroupSchedule{NextCheck: now.Add(s.jitter(min(s.RetryInterval, s.MaxBackoff)))}
+	}
+
+	interval := s.RetryInterval
+	for i := 1; i < failures && interval < s.MaxBackoff; i++ {
+		interval *= 2
+	}
+	interval = min(interval, s.MaxBackoff)
+	return model.GroupSchedule{NextCheck: now.Add(s.jitter(interval))}
+}
+
+// jitter randomly lengthens or shortens interval by up to the Jitter fraction of it
+func (s *Schedule) jitter(interval time.Duration) time.Duration {
+	if s.Jitter <= 0 {
+		return interval
+	}
+	random := s.random
+	if random == nil {
+		random = rand.Float64
+	}
+	return time.Duration(float64(interval) * (1 + s.Jitter*(2*random()-1)))
+}
@@ -0,0 +1,114 @@
+package reattest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &Schedule{
+		HealthyInterval: 24 * time.Hour,
+		RetryInterval:   time.Hour,
+		MaxBackoff:      6 * time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		outcome  Outcome
+		wantWait time.Duration
+	}{
+		{"healthy", 0, OutcomeValid, 24 * time.Hour},
+		{"first failure", 1, OutcomeInvalid, time.Hour},
+		{"second failure", 2, OutcomeInvalid, 2 * time.Hour},
+		{"third failure", 3, OutcomeInvalid, 4 * time.Hour},
+		{"capped", 4, OutcomeInvalid, 6 * time.Hour},
+		{"long streak", 101, OutcomeInvalid, 6 * time.Hour},
+		{"indeterminate", 2, OutcomeIndeterminate, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := schedule.Next(tt.failures, tt.outcome, now)
+			if wait := next.NextCheck.Sub(now); wait != tt.wantWait {
+				t.Errorf("next check in %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestScheduleJitter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, random := range []float64{0, 0.5, 0.999} {
+		schedule := &Schedule{HealthyInterval: 10 * time.Hour, Jitter: 0.1, random: func() float64 { return random }}
+		wait := schedule.Next(0, OutcomeValid, now).NextCheck.Sub(now)
+		if wait < 9*time.Hour || wait > 11*time.Hour {
+			t.Errorf("random %v: next check in %v, want within 10%% of 10h", random, wait)
+		}
+	}
+}
+
+func TestReattestAllAndUpdateSkipsGroupsNotDue(t *testing.T) {
+	ctx := context.Background()
+	resolver, repo, wantValid, groupIDs := newTestGroups(t, 4)
+	store := memrepo.NewMemoryStateStore()
+
+	// One group was checked recently, and another is past its next check after failing twice
+	now := time.Now()
+	if err := store.SaveReattestState(ctx, &model.ReattestState{Groups: map[string]model.GroupSchedule{
+		groupIDs[0]: {NextCheck: now.Add(time.Hour)},
+		groupIDs[1]: {NextCheck: now.Add(-time.Hour)},
+	}}); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	for _, record := range mustList(t, repo, groupIDs[1]) {
+		record.ConsecutiveFailures = 2
+	}
+
+	uc := NewReattestUseCase(dnsclaims.NewServiceWithResolver(resolver), repo)
+	uc.SetStateStore(store)
+	uc.SetSchedule(&Schedule{HealthyInterval: 24 * time.Hour, RetryInterval: time.Hour, MaxBackoff: 24 * time.Hour})
+	uc.SetGracePeriod(1000) // Keep invalid groups
+
+	results, stats, err := uc.ReattestAllAndUpdate(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 || stats.GroupsDue != 3 || stats.GroupsNotDue != 1 {
+		t.Fatalf("got %d results, stats %+v; want the 3 groups that are due", len(results), stats)
+	}
+	for _, result := range results {
+		if result.GroupID == groupIDs[0] {
+			t.Errorf("group %s was re-attested before it was due", result.GroupID)
+		}
+	}
+
+	state, err := store.LoadReattestState(ctx)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	for _, groupID := range groupIDs[1:] {
+		schedule, ok := state.Groups[groupID]
+		if !ok || !schedule.NextCheck.After(now) {
+			t.Errorf("group %s: schedule %+v, want a next check in the future", groupID, schedule)
+			continue
+		}
+		// The backoff follows the failures recorded on the group's records
+		wantWait := 24 * time.Hour
+		if !wantValid[groupID] {
+			wantWait = time.Hour
+			if groupID == groupIDs[1] {
+				wantWait = 4 * time.Hour
+			}
+		}
+		if wait := schedule.NextCheck.Sub(now); wait < wantWait || wait > wantWait+time.Minute {
+			t.Errorf("group %s: next check in %v, want %v", groupID, wait, wantWait)
+		}
+	}
+}
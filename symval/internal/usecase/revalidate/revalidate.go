@@ -180,10 +180,11 @@ func expandForDomainFilter(allRecords []*model.DomainRecord, candidateRecords []
 		return candidateRecords
 	}
 
-	// Create a map of existing candidates for quick lookup
-	existingRecords := make(map[string]bool)
+	// Create a map of existing candidates for quick lookup.
+	// Records are keyed by group and hostname, because a hostname can belong to several groups.
+	existingRecords := make(map[recordKey]bool)
 	for _, record := range candidateRecords {
-		existingRecords[record.Hostname] = true
+		existingRecords[keyOf(record)] = true
 	}
 
 	// Add all records from the identified groups
@@ -191,11 +192,22 @@ func expandForDomainFilter(allRecords []*model.DomainRecord, candidateRecords []
 	expanded = append(expanded, candidateRecords...)
 
 	for _, record := range allRecords {
-		if groupIDs[record.GroupID] && !existingRecords[record.Hostname] {
+		if groupIDs[record.GroupID] && !existingRecords[keyOf(record)] {
 			expanded = append(expanded, record)
-			existingRecords[record.Hostname] = true
+			existingRecords[keyOf(record)] = true
 		}
 	}
 
 	return expanded
 }
+
+// recordKey identifies a record by its group ID and hostname, like the repository's composite key
+type recordKey struct {
+	groupID  string
+	hostname string
+}
+
+// keyOf returns the composite key of a record
+func keyOf(record *model.DomainRecord) recordKey {
+	return recordKey{groupID: record.GroupID, hostname: record.Hostname}
+}
@@ -249,6 +249,50 @@ func TestFindInvalid_DomainFilter(t *testing.T) {
 		}
 	})
 
+	t.Run("hostname in several groups", func(t *testing.T) {
+		owner := "owner1"
+
+		// noon belongs to a valid palindrome group and to an invalid group
+		validRecords := createValidPalindromeGroup(t, owner)
+		invalidRecords := createInvalidGroup(t, owner, []string{"noon", "test1.com"})
+		allRecords := append(validRecords, invalidRecords...)
+
+		repo := setupTestRepo(t, allRecords)
+		uc := NewRevalidateUseCase(repo)
+		ctx := context.Background()
+
+		for _, domain := range []string{"noon", "test1.com"} {
+			invalid, err := uc.FindInvalid(ctx, FilterOptions{Domains: []string{domain}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Should return both records from the invalid group, and not the valid group's noon
+			if len(invalid) != 2 {
+				t.Errorf("filter %s: expected 2 invalid records, got %d", domain, len(invalid))
+			}
+			for _, info := range invalid {
+				if info.Record.GroupID == validRecords[0].GroupID {
+					t.Errorf("filter %s: record %s in valid group %s reported invalid", domain, info.Record.Hostname, info.Record.GroupID)
+				}
+			}
+		}
+
+		if _, err := uc.FindInvalidAndDrop(ctx, FilterOptions{Domains: []string{"noon"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := repo.Get(ctx, validRecords[0].GroupID, "noon"); err != nil {
+			t.Errorf("valid record for noon should remain: %v", err)
+		}
+		remaining, err := repo.ListByHostname(ctx, "noon")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(remaining) != 1 {
+			t.Errorf("expected 1 remaining record for noon, got %d", len(remaining))
+		}
+	})
+
 	t.Run("filter by domain not in repository", func(t *testing.T) {
 		validRecords := createValidPalindromeGroup(t, "owner1")
 		repo := setupTestRepo(t, validRecords)
@@ -0,0 +1,169 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+const (
+	// DefaultInterval is the delay before the second poll
+	DefaultInterval = 5 * time.Second
+
+	// DefaultMaxInterval caps the delay between polls as it backs off
+	DefaultMaxInterval = time.Minute
+
+	// DefaultTimeout is how long to wait for propagation before giving up
+	DefaultTimeout = 10 * time.Minute
+
+	// backoffFactor multiplies the delay after each poll that does not see every record
+	backoffFactor = 2
+)
+
+// ErrTimeout is returned by Wait when the records do not propagate before the timeout
+var ErrTimeout = errors.New("timed out waiting for DNS propagation")
+
+// Resolver is a named DNS service to poll
+type Resolver struct {
+	Name    string // Shown in progress, like "1.1.1.1:53" or "system"
+	Service *dnsclaims.Service
+}
+
+// Watcher polls the _suns records of a group until every resolver returns the expected group ID
+type Watcher struct {
+	resolvers   []Resolver
+	interval    time.Duration
+	maxInterval time.Duration
+	timeout     time.Duration
+}
+
+// NewWatcher creates a watcher that polls each of resolvers, with the default interval and timeout
+func NewWatcher(resolvers []Resolver) *Watcher {
+	return &Watcher{
+		resolvers:   resolvers,
+		interval:    DefaultInterval,
+		maxInterval: DefaultMaxInterval,
+		timeout:     DefaultTimeout,
+	}
+}
+
+// SetInterval sets the delay before the second poll; it doubles after each poll, up to the maximum interval
+func (w *Watcher) SetInterval(interval time.Duration) {
+	w.interval = interval
+}
+
+// SetMaxInterval sets the longest delay between polls
+func (w *Watcher) SetMaxInterval(maxInterval time.Duration) {
+	w.maxInterval = maxInterval
+}
+
+// SetTimeout sets how long to wait for propagation before giving up
+func (w *Watcher) SetTimeout(timeout time.Duration) {
+	w.timeout = timeout
+}
+
+// ResolverProgress is what one resolver returned for a domain in a poll
+type ResolverProgress struct {
+	Resolver string
+	Seen     bool   // The expected group ID was returned
+	Error    string // The lookup error, if the lookup failed
+}
+
+// DomainProgress is what every resolver returned for a domain in a poll
+type DomainProgress struct {
+	Hostname  string
+	Resolvers []ResolverProgress
+}
+
+// Propagated reports whether every resolver returned the expected group ID for the domain
+func (d *DomainProgress) Propagated() bool {
+	for _, r := range d.Resolvers {
+		if !r.Seen {
+			return false
+		}
+	}
+	return true
+}
+
+// Progress is the result of one poll
+type Progress struct {
+	Attempt  int
+	Elapsed  time.Duration
+	NextPoll time.Duration // Delay before the next poll; zero if this poll saw every record
+	Domains  []DomainProgress
+}
+
+// Propagated reports whether every domain has propagated to every resolver
+func (p *Progress) Propagated() bool {
+	for _, d := range p.Domains {
+		if !d.Propagated() {
+			return false
+		}
+	}
+	return true
+}
+
+// Wait polls each domain's _suns records on every resolver until all of them return expectedID.
+// progress, if not nil, is called after each poll.
+// It returns the last poll's progress, with ErrTimeout if the timeout passed first,
+// or the context's error if ctx was cancelled.
+func (w *Watcher) Wait(ctx context.Context, expectedID string, domains []string, progress func(*Progress)) (*Progress, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	start := time.Now()
+	delay := w.interval
+	for attempt := 1; ; attempt++ {
+		p := w.poll(expectedID, domains)
+		p.Attempt = attempt
+		p.Elapsed = time.Since(start)
+		if !p.Propagated() {
+			p.NextPoll = delay
+		}
+		if progress != nil {
+			progress(p)
+		}
+		if p.Propagated() {
+			return p, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return p, ErrTimeout
+			}
+			return p, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= backoffFactor
+		if delay > w.maxInterval {
+			delay = w.maxInterval
+		}
+	}
+}
+
+// poll looks up every domain on every resolver once
+func (w *Watcher) poll(expectedID string, domains []string) *Progress {
+	p := &Progress{}
+	for _, domain := range domains {
+		d := DomainProgress{Hostname: domain}
+		for _, resolver := range w.resolvers {
+			r := ResolverProgress{Resolver: resolver.Name}
+			records, err := resolver.Service.Lookup(domain)
+			if err != nil {
+				r.Error = err.Error()
+			}
+			for _, record := range records {
+				if record == expectedID {
+					r.Seen = true
+				}
+			}
+			d.Resolvers = append(d.Resolvers, r)
+		}
+		p.Domains = append(p.Domains, d)
+	}
+	return p
+}
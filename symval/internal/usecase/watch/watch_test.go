@@ -0,0 +1,98 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+)
+
+// propagatingResolver is a mock DNS resolver that starts returning a TXT record after a number of lookups
+type propagatingResolver struct {
+	mu      sync.Mutex
+	record  string
+	after   int // Number of lookups of each name that return nothing
+	lookups map[string]int
+}
+
+func (m *propagatingResolver) LookupTXT(domain string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookups == nil {
+		m.lookups = make(map[string]int)
+	}
+	m.lookups[domain]++
+	if m.lookups[domain] > m.after {
+		return []string{m.record}, nil
+	}
+	return nil, dnsclaimstest.NotFound(domain)
+}
+
+func (m *propagatingResolver) LookupCNAME(domain string) (string, error) {
+	return "", dnsclaimstest.NotFound(domain)
+}
+
+func newTestWatcher(resolvers ...*propagatingResolver) *Watcher {
+	var named []Resolver
+	for i, r := range resolvers {
+		named = append(named, Resolver{Name: string(rune('a' + i)), Service: dnsclaims.NewServiceWithResolver(r)})
+	}
+	w := NewWatcher(named)
+	w.SetInterval(time.Millisecond)
+	w.SetMaxInterval(4 * time.Millisecond)
+	w.SetTimeout(time.Second)
+	return w
+}
+
+func TestWait_Propagates(t *testing.T) {
+	fast := &propagatingResolver{record: "v1:a:owner:domains", after: 0}
+	slow := &propagatingResolver{record: "v1:a:owner:domains", after: 3}
+	w := newTestWatcher(fast, slow)
+
+	var polls []*Progress
+	last, err := w.Wait(context.Background(), "v1:a:owner:domains", []string{"aba.example", "abba.example"}, func(p *Progress) {
+		polls = append(polls, p)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !last.Propagated() || last.Attempt != 4 {
+		t.Errorf("expected propagation on attempt 4, got attempt %d (propagated %v)", last.Attempt, last.Propagated())
+	}
+	if len(polls) != 4 {
+		t.Fatalf("expected progress for 4 polls, got %d", len(polls))
+	}
+
+	// The first poll sees the record on the fast resolver only
+	first := polls[0].Domains[0]
+	if !first.Resolvers[0].Seen || first.Resolvers[1].Seen {
+		t.Errorf("first poll: expected only the fast resolver to see the record, got %+v", first.Resolvers)
+	}
+
+	// The delay backs off, up to the maximum interval
+	wantDelays := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 0}
+	for i, want := range wantDelays {
+		if polls[i].NextPoll != want {
+			t.Errorf("poll %d: next poll in %s, want %s", i+1, polls[i].NextPoll, want)
+		}
+	}
+}
+
+func TestWait_Timeout(t *testing.T) {
+	never := &propagatingResolver{record: "v1:a:owner:other", after: 0}
+	w := newTestWatcher(never)
+	w.SetTimeout(10 * time.Millisecond)
+
+	last, err := w.Wait(context.Background(), "v1:a:owner:domains", []string{"aba.example"}, nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if last == nil || last.Propagated() {
+		t.Errorf("expected the last unpropagated poll, got %+v", last)
+	}
+}
@@ -0,0 +1,141 @@
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+)
+
+// WithdrawUseCase removes a group from membership as soon as its _suns records say so,
+// instead of waiting for reattestation and its grace period
+type WithdrawUseCase struct {
+	dnsService *dnsclaims.Service
+	repository model.DomainRepository
+	dryRun     bool
+}
+
+// NewWithdrawUseCase creates a new withdraw use case
+func NewWithdrawUseCase(dnsService *dnsclaims.Service, repo model.DomainRepository) *WithdrawUseCase {
+	return &WithdrawUseCase{
+		dnsService: dnsService,
+		repository: repo,
+	}
+}
+
+// SetDryRun checks the withdrawal without removing any records
+func (uc *WithdrawUseCase) SetDryRun(dryRun bool) {
+	uc.dryRun = dryRun
+}
+
+// DomainWithdrawal describes whether one domain in the group has withdrawn
+type DomainWithdrawal struct {
+	Hostname  string
+	Withdrawn bool
+	Marker    bool   // The domain publishes the withdrawal marker
+	Reason    string // Why the domain has not withdrawn
+}
+
+// WithdrawResult contains the result of a withdrawal
+type WithdrawResult struct {
+	GroupID      string
+	Owner        string
+	Type         string
+	Domains      []DomainWithdrawal
+	IsWithdrawn  bool // Every domain has withdrawn
+	ErrorMessage string
+	Deleted      int // Records removed from the data store
+	Skipped      int // Records that changed while withdrawing, and were left in place
+}
+
+// Withdraw checks that every domain in the group has withdrawn, and if so removes the group's records.
+// A domain has withdrawn if its _suns records no longer include the group ID,
+// or if they include the withdrawal marker from groupid.WithdrawalMarker.
+// A domain whose records cannot be looked up has not withdrawn, so that DNS outages never remove a group.
+// Records are removed with DeleteIfUnchanged, so records re-attested during the withdrawal are kept.
+// It returns model.ErrNotFound if the group has no records.
+func (uc *WithdrawUseCase) Withdraw(ctx context.Context, groupID string) (*WithdrawResult, error) {
+	records, err := uc.repository.ListByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records for group %s: %w", groupID, err)
+	}
+	if len(records) == 0 {
+		return nil, model.ErrNotFound
+	}
+
+	model.SortRecords(records, string(model.SortByDomain))
+
+	result := &WithdrawResult{
+		GroupID: groupID,
+		Owner:   records[0].Owner,
+		Type:    string(records[0].Type),
+	}
+
+	var remaining []string
+	for _, record := range records {
+		domain := uc.checkDomain(groupID, record.Hostname)
+		if !domain.Withdrawn {
+			remaining = append(remaining, fmt.Sprintf("%s: %s", domain.Hostname, domain.Reason))
+		}
+		result.Domains = append(result.Domains, domain)
+	}
+
+	if len(remaining) > 0 {
+		result.ErrorMessage = fmt.Sprintf("group has not withdrawn: %s", strings.Join(remaining, "; "))
+		return result, nil
+	}
+	result.IsWithdrawn = true
+
+	if uc.dryRun {
+		return result, nil
+	}
+
+	for _, record := range records {
+		err := uc.repository.DeleteIfUnchanged(ctx, groupID, record.Hostname, record.Rev)
+		if errors.Is(err, model.ErrRevConflict) || errors.Is(err, model.ErrNotFound) {
+			// Changed or removed since it was listed; leave it for reattestation
+			result.Skipped++
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to delete record for %s: %w", record.Hostname, err)
+		} else {
+			result.Deleted++
+		}
+	}
+
+	return result, nil
+}
+
+// checkDomain looks up a domain's _suns records and reports whether it has withdrawn from groupID
+func (uc *WithdrawUseCase) checkDomain(groupID, hostname string) DomainWithdrawal {
+	domain := DomainWithdrawal{Hostname: hostname}
+
+	trace, err := uc.dnsService.Trace(hostname)
+	if err != nil {
+		domain.Reason = err.Error()
+		return domain
+	}
+
+	marker := groupid.WithdrawalMarker(groupID)
+	published := false
+	for _, value := range trace.Records {
+		if value == marker {
+			domain.Marker = true
+		} else if value == groupID {
+			published = true
+		}
+	}
+
+	switch {
+	case domain.Marker:
+		domain.Withdrawn = true
+	case published:
+		domain.Reason = fmt.Sprintf("%s still publishes the group ID; remove it or publish %q", trace.Label, marker)
+	default:
+		domain.Withdrawn = true
+	}
+	return domain
+}
@@ -0,0 +1,118 @@
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/model"
+	"github.com/mrled/suns/symval/internal/repository/memrepo"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims"
+	"github.com/mrled/suns/symval/internal/service/dnsclaims/dnsclaimstest"
+	"github.com/mrled/suns/symval/internal/symgroup"
+)
+
+func TestWithdraw(t *testing.T) {
+	owner := "alice@example.com"
+	domains := []string{"com.example", "example.com"}
+	groupID, err := groupid.CalculateV1(owner, string(symgroup.MirrorNames), domains)
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	marker := groupid.WithdrawalMarker(groupID)
+
+	tests := []struct {
+		name          string
+		txtRecords    map[string][]string
+		errors        map[string]error
+		dryRun        bool
+		wantWithdrawn bool
+		wantDeleted   int
+	}{
+		{
+			name:          "records removed",
+			txtRecords:    map[string][]string{},
+			wantWithdrawn: true,
+			wantDeleted:   2,
+		},
+		{
+			name: "withdrawal markers",
+			txtRecords: map[string][]string{
+				"_suns.example.com": {groupID, marker},
+				"_suns.com.example": {marker},
+			},
+			wantWithdrawn: true,
+			wantDeleted:   2,
+		},
+		{
+			name: "one domain still publishes the group ID",
+			txtRecords: map[string][]string{
+				"_suns.example.com": {groupID},
+			},
+			wantWithdrawn: false,
+		},
+		{
+			name:       "lookup failure",
+			txtRecords: map[string][]string{},
+			errors: map[string]error{
+				"_suns.example.com": &net.DNSError{Err: "server misbehaving", Name: "_suns.example.com", IsTemporary: true},
+			},
+			wantWithdrawn: false,
+		},
+		{
+			name:          "dry run",
+			txtRecords:    map[string][]string{},
+			dryRun:        true,
+			wantWithdrawn: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memrepo.NewMemoryRepository()
+			for _, domain := range domains {
+				repo.Upsert(ctx, &model.DomainRecord{
+					Owner:        owner,
+					Type:         symgroup.MirrorNames,
+					Hostname:     domain,
+					GroupID:      groupID,
+					ValidateTime: time.Now(),
+				})
+			}
+
+			dnsService := dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{TXTRecords: tt.txtRecords, Errors: tt.errors})
+			uc := NewWithdrawUseCase(dnsService, repo)
+			uc.SetDryRun(tt.dryRun)
+
+			result, err := uc.Withdraw(ctx, groupID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.IsWithdrawn != tt.wantWithdrawn {
+				t.Errorf("IsWithdrawn = %v, want %v (error: %s)", result.IsWithdrawn, tt.wantWithdrawn, result.ErrorMessage)
+			}
+			if !tt.wantWithdrawn && result.ErrorMessage == "" {
+				t.Errorf("expected an error message")
+			}
+			if result.Deleted != tt.wantDeleted {
+				t.Errorf("Deleted = %d, want %d", result.Deleted, tt.wantDeleted)
+			}
+
+			remaining, _ := repo.ListByGroupID(ctx, groupID)
+			if len(remaining) != len(domains)-tt.wantDeleted {
+				t.Errorf("%d records remain, want %d", len(remaining), len(domains)-tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestWithdrawUnknownGroup(t *testing.T) {
+	uc := NewWithdrawUseCase(dnsclaims.NewServiceWithResolver(&dnsclaimstest.Resolver{}), memrepo.NewMemoryRepository())
+	if _, err := uc.Withdraw(context.Background(), "v1:m:nobody:nothing"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -15,13 +15,13 @@ func validateDoubleFlip180(data []*model.DomainRecord) (bool, error) {
 		return false, fmt.Errorf("doubleflip180 validation expects exactly two domains, got %d", len(data))
 	}
 
-	hostname1 := data[0].Hostname
-	hostname2 := data[1].Hostname
+	hostname1 := symmetryHostname(data[0])
+	hostname2 := symmetryHostname(data[1])
 
-	flipped1, err1 := Flip180String(hostname1)
+	flipped1, err1 := Flip180Hostname(hostname1)
 	if err1 == nil && strings.EqualFold(flipped1, hostname2) {
 		// Also verify the reverse: hostname2 flipped should equal hostname1
-		flipped2, err2 := Flip180String(hostname2)
+		flipped2, err2 := Flip180Hostname(hostname2)
 		if err2 == nil && strings.EqualFold(flipped2, hostname1) {
 			return true, nil
 		}
@@ -32,7 +32,7 @@ func validateDoubleFlip180(data []*model.DomainRecord) (bool, error) {
 	}
 
 	// Verify the reverse transformation
-	flipped2, err2 := Flip180String(hostname2)
+	flipped2, err2 := Flip180Hostname(hostname2)
 	if err2 != nil {
 		return false, fmt.Errorf("cannot flip hostname %q: %v", hostname2, err2)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/mrled/suns/symval/internal/idn"
 	"github.com/mrled/suns/symval/internal/model"
 )
 
@@ -46,6 +47,27 @@ var flip180Mapping = map[rune]rune{
 	'-': '-',
 }
 
+// flip180UnicodeMapping maps ASCII letters to the Unicode glyphs that look like them rotated 180 degrees, and back.
+// It is only consulted when flip180Mapping cannot flip a string,
+// and its results are only accepted if browsers would display them as Unicode.
+var flip180UnicodeMapping = map[rune]rune{
+	'a': 'ɐ', 'ɐ': 'a',
+	'c': 'ɔ', 'ɔ': 'c',
+	'e': 'ǝ', 'ǝ': 'e',
+	'f': 'ɟ', 'ɟ': 'f',
+	'g': 'ƃ', 'ƃ': 'g',
+	'h': 'ɥ', 'ɥ': 'h',
+	'i': 'ᴉ', 'ᴉ': 'i',
+	'j': 'ɾ', 'ɾ': 'j',
+	'k': 'ʞ', 'ʞ': 'k',
+	'm': 'ɯ', 'ɯ': 'm',
+	'r': 'ɹ', 'ɹ': 'r',
+	't': 'ʇ', 'ʇ': 't',
+	'v': 'ʌ', 'ʌ': 'v',
+	'w': 'ʍ', 'ʍ': 'w',
+	'y': 'ʎ', 'ʎ': 'y',
+}
+
 // Flip180String returns the 180-degree rotated version of a string
 // Exported for use in doubleflip180 validation
 func Flip180String(s string) (string, error) {
@@ -68,9 +90,46 @@ func Flip180String(s string) (string, error) {
 	return string(result), nil
 }
 
+// Flip180UnicodeString returns the 180-degree rotated version of a string,
+// using Unicode glyphs for letters that have no ASCII rotation (for example 'm' -> 'ɯ').
+// It returns an error if a character cannot be rotated,
+// or if browsers would display the result as punycode rather than Unicode.
+func Flip180UnicodeString(s string) (string, error) {
+	s = strings.ToLower(s)
+	runes := []rune(s)
+	result := make([]rune, len(runes))
+
+	for i := 0; i < len(runes); i++ {
+		char := runes[len(runes)-1-i]
+		if flipped, ok := flip180Mapping[char]; ok {
+			result[i] = flipped
+		} else if flipped, ok := flip180UnicodeMapping[char]; ok {
+			result[i] = flipped
+		} else {
+			return "", fmt.Errorf("character '%c' cannot be rotated 180 degrees", char)
+		}
+	}
+
+	flipped := string(result)
+	if !idn.Displayable(flipped) {
+		return "", fmt.Errorf("rotated hostname %q would not be displayed as Unicode by browsers", flipped)
+	}
+
+	return flipped, nil
+}
+
+// Flip180Hostname returns the 180-degree rotated version of a hostname.
+// It uses the ASCII-only mapping when possible, and falls back to Unicode glyphs otherwise.
+func Flip180Hostname(s string) (string, error) {
+	if flipped, err := Flip180String(s); err == nil {
+		return flipped, nil
+	}
+	return Flip180UnicodeString(s)
+}
+
 // isFlip180 checks if a string is identical to its 180-degree rotated version
 func isFlip180(s string) bool {
-	flipped, err := Flip180String(s)
+	flipped, err := Flip180Hostname(s)
 	if err != nil {
 		return false
 	}
@@ -83,7 +142,7 @@ func validateFlip180(data []*model.DomainRecord) (bool, error) {
 		return false, fmt.Errorf("flip180 validation expects exactly one domain, got %d", len(data))
 	}
 
-	hostname := data[0].Hostname
+	hostname := symmetryHostname(data[0])
 	if !isFlip180(hostname) {
 		return false, fmt.Errorf("hostname %q does not have 180-degree flip symmetry", hostname)
 	}
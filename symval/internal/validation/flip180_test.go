@@ -51,6 +51,41 @@ func TestFlip180String(t *testing.T) {
 	}
 }
 
+func TestFlip180UnicodeString(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		shouldError bool
+	}{
+		{"ascii only", "pods", "spod", false},
+		{"m flips to turned m", "com", "ɯoɔ", false},
+		{"turned m flips back", "ɯoɔ", "com", false},
+		{"self-symmetric", "ǝe", "ǝe", false},
+		{"confusable with ASCII", "ms.su", "", true}, // ns.sɯ looks like ns.sw
+		{"self-symmetric but confusable with ASCII", "mɯ", "", true},
+		{"unmappable char", "bücher", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Flip180UnicodeString(tt.input)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected error for input %q, but got none", tt.input)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+				}
+				if result != tt.expected {
+					t.Errorf("For input %q, expected %q, got %q", tt.input, tt.expected, result)
+				}
+			}
+		})
+	}
+}
+
 func TestIsFlip180(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -94,6 +129,8 @@ func TestValidateFlip180(t *testing.T) {
 		{"Numbers 88", "88", true},
 		{"Not symmetric", "example.com", false},
 		{"Not symmetric pods", "pods", false},
+		{"Self-symmetric punycode", "xn--e-7ta", true}, // ǝe
+		{"Confusable punycode", "xn--m-l2a", false},    // mɯ, which looks like rnw
 	}
 
 	for _, tt := range tests {
@@ -39,8 +39,8 @@ func validateMirrorNames(data []*model.DomainRecord) (bool, error) {
 		return false, fmt.Errorf("mirrornames validation expects exactly two domains, got %d", len(data))
 	}
 
-	hostname1 := data[0].Hostname
-	hostname2 := data[1].Hostname
+	hostname1 := symmetryHostname(data[0])
+	hostname2 := symmetryHostname(data[1])
 
 	if !isMirrorPair(hostname1, hostname2) {
 		return false, fmt.Errorf("hostnames %q and %q are not mirror pairs", hostname1, hostname2)
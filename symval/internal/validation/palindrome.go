@@ -30,7 +30,7 @@ func validatePalindrome(data []*model.DomainRecord) (bool, error) {
 		return false, fmt.Errorf("palindrome validation expects exactly one domain, got %d", len(data))
 	}
 
-	hostname := data[0].Hostname
+	hostname := symmetryHostname(data[0])
 	if !isPalindrome(hostname) {
 		return false, fmt.Errorf("hostname %q is not a palindrome", hostname)
 	}
@@ -4,12 +4,17 @@ import (
 	"fmt"
 
 	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
 
 // ValidateBase checks that all DomainRecord structs have consistent owner, type, and groupid,
 // and that the groupid matches the calculated groupid for the given hostnames.
+// Hostnames are canonicalized (RFC 1123 syntax, A-label form) before the groupid is calculated.
+// Groups attested before hostnames were canonicalized have groupids calculated from the hostnames as given,
+// such as with uppercase letters or a trailing dot; these are still accepted during the transition,
+// so that stored groups keep validating until their owners publish the canonical groupid.
 // Returns the common owner, groupID, and type if validation succeeds.
 func ValidateBase(data []*model.DomainRecord) (string, string, symgroup.SymmetryType, error) {
 	if len(data) == 0 {
@@ -24,6 +29,7 @@ func ValidateBase(data []*model.DomainRecord) (string, string, symgroup.Symmetry
 
 	// Collect all hostnames and validate consistency
 	hostnames := make([]string, 0, len(data))
+	legacyHostnames := make([]string, 0, len(data))
 	for _, d := range data {
 		if d.Owner != owner {
 			return "", "", "", fmt.Errorf("owner mismatch: expected %s, got %s", owner, d.Owner)
@@ -34,7 +40,12 @@ func ValidateBase(data []*model.DomainRecord) (string, string, symgroup.Symmetry
 		if d.GroupID != groupID {
 			return "", "", "", fmt.Errorf("groupID mismatch: expected %s, got %s", groupID, d.GroupID)
 		}
-		hostnames = append(hostnames, d.Hostname)
+		name, err := hostname.Canonicalize(d.Hostname)
+		if err != nil {
+			return "", "", "", err
+		}
+		hostnames = append(hostnames, name)
+		legacyHostnames = append(legacyHostnames, d.Hostname)
 	}
 
 	// Calculate the expected groupID
@@ -43,14 +54,28 @@ func ValidateBase(data []*model.DomainRecord) (string, string, symgroup.Symmetry
 		return "", "", "", fmt.Errorf("failed to calculate group ID: %w", err)
 	}
 
-	// Compare the provided groupID with the calculated one
-	if groupID != expectedGroupID {
+	// Compare the provided groupID with the calculated one, or with the legacy one
+	if groupID != expectedGroupID && groupID != LegacyGroupID(owner, symmetryType, legacyHostnames) {
 		return "", "", "", fmt.Errorf("groupID validation failed: expected %s, got %s", expectedGroupID, groupID)
 	}
 
 	return owner, groupID, symmetryType, nil
 }
 
+// LegacyGroupID returns the groupid calculated from hostnames as given, before canonicalization,
+// as groups were attested before hostnames were canonicalized.
+// It returns "" if the hostnames are already canonical, so that there is no legacy groupid to accept.
+func LegacyGroupID(owner string, symmetryType symgroup.SymmetryType, hostnames []string) string {
+	if hostname.CheckCanonical(hostnames) == nil {
+		return ""
+	}
+	legacyGroupID, err := groupid.CalculateV1(owner, string(symmetryType), hostnames)
+	if err != nil {
+		return ""
+	}
+	return legacyGroupID
+}
+
 // Validate performs base validation and then calls the appropriate type-specific validator
 func Validate(data []*model.DomainRecord) (bool, error) {
 	// Perform base validation
@@ -75,3 +100,10 @@ func Validate(data []*model.DomainRecord) (bool, error) {
 		return false, fmt.Errorf("unknown symmetry type: %s", symmetryType)
 	}
 }
+
+// symmetryHostname returns the hostname used for symmetry checks.
+// This is the U-label form, so that punycode labels are compared by the characters
+// they display rather than by their xn-- encoding.
+func symmetryHostname(record *model.DomainRecord) string {
+	return record.DisplayHostname()
+}
@@ -1,8 +1,11 @@
 package validation
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/mrled/suns/symval/internal/groupid"
+	"github.com/mrled/suns/symval/internal/hostname"
 	"github.com/mrled/suns/symval/internal/model"
 	"github.com/mrled/suns/symval/internal/symgroup"
 )
@@ -32,6 +35,68 @@ func TestValidateBase_Success(t *testing.T) {
 	}
 }
 
+func TestValidateBase_LegacyGroupID(t *testing.T) {
+	owner := "alice@example.com"
+	canonicalID, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{"aba"})
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	// Calculated from the hostname as given, as groups were before hostnames were canonicalized
+	legacyID, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{"ABA."})
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+	otherID, err := groupid.CalculateV1(owner, string(symgroup.Palindrome), []string{"abba"})
+	if err != nil {
+		t.Fatalf("failed to calculate group ID: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hostname string
+		groupID  string
+		wantErr  bool
+	}{
+		{"canonical group ID", "ABA.", canonicalID, false},
+		{"legacy group ID", "ABA.", legacyID, false},
+		{"legacy group ID of another spelling", "aba.", legacyID, true},
+		{"legacy group ID of canonical hostname", "aba", legacyID, true},
+		{"other group ID", "ABA.", otherID, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []*model.DomainRecord{
+				{Owner: owner, Type: symgroup.Palindrome, Hostname: tt.hostname, GroupID: tt.groupID},
+			}
+			_, _, _, err := ValidateBase(data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_InvalidHostname(t *testing.T) {
+	data := []*model.DomainRecord{
+		{
+			Owner:    "alice@example.com",
+			Type:     symgroup.Palindrome,
+			Hostname: "https://aba",
+			GroupID:  "v1:a:/42YGfwOEr8NJIkuRZh+JJoo3Og2qFytYOKOqqjG2XY=:4SStzOH7L4jh6nmcPQgghF7TQ+bHOeVBMfyzpW5Lwb0=",
+		},
+	}
+
+	valid, err := Validate(data)
+	var syntaxErr *hostname.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a hostname syntax error, got: %v", err)
+	}
+	if valid {
+		t.Error("Expected valid=false for invalid hostname")
+	}
+}
+
 func TestValidate_EmptyList(t *testing.T) {
 	data := []*model.DomainRecord{}
 
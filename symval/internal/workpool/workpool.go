@@ -0,0 +1,37 @@
+// Package workpool runs independent jobs on a bounded number of goroutines
+package workpool
+
+import "sync"
+
+// Map calls fn for every index in [0, n) using at most workers goroutines,
+// and returns the results in index order, however the calls interleave.
+// A workers value below 1 runs the jobs one at a time.
+func Map[T any](n, workers int, fn func(i int) T) []T {
+	results := make([]T, n)
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
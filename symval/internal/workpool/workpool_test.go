@@ -0,0 +1,52 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		workers int
+	}{
+		{"no jobs", 0, 4},
+		{"fewer jobs than workers", 2, 4},
+		{"more jobs than workers", 20, 3},
+		{"zero workers", 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak atomic.Int32
+			results := Map(tt.n, tt.workers, func(i int) int {
+				now := running.Add(1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				// Finish later jobs first, so that results arrive out of order
+				time.Sleep(time.Duration(tt.n-i) * time.Millisecond)
+				running.Add(-1)
+				return i * i
+			})
+
+			if len(results) != tt.n {
+				t.Fatalf("got %d results, want %d", len(results), tt.n)
+			}
+			for i, r := range results {
+				if r != i*i {
+					t.Errorf("results[%d] = %d, want %d", i, r, i*i)
+				}
+			}
+			limit := max(tt.workers, 1)
+			if int(peak.Load()) > limit {
+				t.Errorf("%d jobs ran at once, want at most %d", peak.Load(), limit)
+			}
+		})
+	}
+}
@@ -21,6 +21,9 @@ class DomainRecords extends HTMLElement {
     // Get priority owner attribute (single owner to show first)
     this.priorityOwner = this.getAttribute('priority-owner') || null;
 
+    // Get group-by attribute: "owner" (default) or "registrable" (eTLD+1)
+    this.groupBy = this.getAttribute('group-by') || 'owner';
+
     await this.fetchAndRender();
   }
 
@@ -44,6 +47,14 @@ class DomainRecords extends HTMLElement {
     return this.typeCodeToName[typeCode] || typeCode;
   }
 
+  // Show the Unicode form of a hostname unless the homograph check flagged it for display as punycode
+  listedHostname(record) {
+    if (record.IDNSafety && record.IDNSafety !== 'ok') {
+      return record.Hostname;
+    }
+    return record.UnicodeHostname || record.Hostname;
+  }
+
   groupRecordsByOwnerAndGroup(records) {
     const grouped = {};
 
@@ -59,7 +70,30 @@ class DomainRecords extends HTMLElement {
         };
       }
 
-      grouped[record.Owner][record.GroupID].hostnames.push(record.Hostname);
+      grouped[record.Owner][record.GroupID].hostnames.push(this.listedHostname(record));
+    });
+
+    return grouped;
+  }
+
+  // Group records by registrable domain (eTLD+1), then by group ID
+  groupRecordsByRegistrableDomainAndGroup(records) {
+    const grouped = {};
+
+    records.forEach(record => {
+      const registrable = record.RegistrableDomain || record.Hostname;
+      if (!grouped[registrable]) {
+        grouped[registrable] = {};
+      }
+
+      if (!grouped[registrable][record.GroupID]) {
+        grouped[registrable][record.GroupID] = {
+          type: record.Type,
+          hostnames: []
+        };
+      }
+
+      grouped[registrable][record.GroupID].hostnames.push(this.listedHostname(record));
     });
 
     return grouped;
@@ -85,8 +119,22 @@ class DomainRecords extends HTMLElement {
     return sortedOwners;
   }
 
+  // Count how many groups each hostname belongs to, since a hostname can be in several groups
+  countGroupsByHostname(records) {
+    const counts = {};
+    records.forEach(record => {
+      const hostname = this.listedHostname(record);
+      counts[hostname] = (counts[hostname] || 0) + 1;
+    });
+    return counts;
+  }
+
   render(records) {
-    const grouped = this.groupRecordsByOwnerAndGroup(records);
+    const byRegistrable = this.groupBy === 'registrable';
+    const groupCounts = this.countGroupsByHostname(records);
+    const grouped = byRegistrable
+      ? this.groupRecordsByRegistrableDomainAndGroup(records)
+      : this.groupRecordsByOwnerAndGroup(records);
 
     let html = `
       <style>
@@ -102,15 +150,24 @@ class DomainRecords extends HTMLElement {
     } else {
       html += '<ul>';
 
-      // Sort owners based on priority
-      const sortedOwners = this.sortOwnersByPriority(Object.keys(grouped));
-
-      for (const owner of sortedOwners) {
-        const groups = grouped[owner];
-        html += `<li class="owner"><a href="${owner}">${owner}</a><ul>`;
+      // Sort owners based on priority, or registrable domains alphabetically
+      const sortedKeys = byRegistrable
+        ? Object.keys(grouped).sort()
+        : this.sortOwnersByPriority(Object.keys(grouped));
+
+      for (const key of sortedKeys) {
+        const groups = grouped[key];
+        if (byRegistrable) {
+          html += `<li class="registrable"><code>${key}</code><ul>`;
+        } else {
+          html += `<li class="owner"><a href="${key}">${key}</a><ul>`;
+        }
         for (const [groupId, group] of Object.entries(groups)) {
           const humanReadableType = this.getHumanReadableType(group.type);
-          const domainList = group.hostnames.map(h => `<code>${h}</code>`).join(', ');
+          const domainList = group.hostnames.map(h => {
+            const count = groupCounts[h];
+            return count > 1 ? `<code title="In ${count} groups">${h}</code>` : `<code>${h}</code>`;
+          }).join(', ');
           html += `<li><span>${humanReadableType}</span>: ${domainList}</li>`;
         }
 
@@ -8,6 +8,17 @@ class GroupIDCalculator extends HTMLElement {
     this.attachEventHandlers();
   }
 
+  // Normalize a hostname to its punycode (A-label) form, matching symval's UTS-46 normalization
+  normalizeHostname(hostname) {
+    let ascii;
+    try {
+      ascii = new URL(`http://${hostname}`).hostname;
+    } catch {
+      throw new Error(`Invalid hostname: ${hostname}`);
+    }
+    return ascii.replace(/\.$/, '');
+  }
+
   async calculateGroupID(owner, type, hostnames) {
     // Validate inputs
     if (!owner) {
@@ -20,8 +31,8 @@ class GroupIDCalculator extends HTMLElement {
       throw new Error('At least one hostname is required');
     }
 
-    // Sort hostnames for consistent hashing
-    const sorted = [...hostnames].sort();
+    // Normalize and sort hostnames for consistent hashing
+    const sorted = hostnames.map(h => this.normalizeHostname(h)).sort();
 
     // Hash the owner using SHA-256
     const ownerBytes = new TextEncoder().encode(owner);